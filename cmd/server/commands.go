@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+)
+
+// usage описание команд администрирования
+const usage = `Использование:
+  server [-skip-migrate]                  запустить сервер
+  server migrate up [-steps N]            применить новые миграции, по умолчанию все
+  server migrate down [-steps N]          откатить последние миграции, по умолчанию одну
+  server migrate status                   показать примененные и новые миграции
+  server apikey create -name N -subject S [-roles admin,analyst] [-ttl 720h] [-tenant T]
+  server apikey list [-tenant T]
+  server apikey revoke -id ID [-tenant T]
+  server tenant create -id T -name N [-currency RUB] [-timezone Europe/Moscow]
+  server tenant list`
+
+// runCommand выполняет команду администрирования и возвращает код завершения
+func runCommand(cfg *config.Config, args []string) int {
+	if (args[0] != "apikey" && args[0] != "tenant" && args[0] != "migrate") || len(args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	db := database.ConnectDB(cfg)
+	if args[0] == "migrate" {
+		return migrateCommand(db, args[1], args[2:])
+	}
+	if cfg.AutoMigrate {
+		database.Migrate(db)
+	}
+	tenants := services.NewTenantService(repository.NewTenantRepository(db))
+	apiKeys := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+	var err error
+	switch args[0] + " " + args[1] {
+	case "apikey create":
+		err = createAPIKeyCommand(tenants, apiKeys, args[2:])
+	case "apikey list":
+		err = listAPIKeysCommand(tenants, apiKeys, args[2:])
+	case "apikey revoke":
+		err = revokeAPIKeyCommand(tenants, apiKeys, args[2:])
+	case "tenant create":
+		err = createTenantCommand(tenants, args[2:])
+	case "tenant list":
+		err = listTenantsCommand(tenants)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка:", err)
+		return 1
+	}
+	return 0
+}
+
+// migrateCommand применяет, откатывает или показывает миграции и возвращает код завершения
+func migrateCommand(db *gorm.DB, action string, args []string) int {
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	steps := flags.Int("steps", 0, "количество миграций")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var err error
+	switch action {
+	case "up":
+		var applied []database.Migration
+		if applied, err = database.MigrateUp(db, *steps); err == nil {
+			printMigrations("Применена", applied)
+		}
+	case "down":
+		var reverted []database.Migration
+		if reverted, err = database.MigrateDown(db, *steps); err == nil {
+			printMigrations("Откачена", reverted)
+		}
+	case "status":
+		err = migrationStatusCommand(db)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка:", err)
+		return 1
+	}
+	return 0
+}
+
+// printMigrations печатает выполненные миграции
+func printMigrations(verb string, migrations []database.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("Нет миграций для выполнения")
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s миграция %04d_%s\n", verb, migration.Version, migration.Name)
+	}
+}
+
+// migrationStatusCommand печатает миграции и время их применения
+func migrationStatusCommand(db *gorm.DB) error {
+	states, err := database.MigrationStatus(db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ВЕРСИЯ\tНАЗВАНИЕ\tПРИМЕНЕНА")
+	for _, state := range states {
+		applied := "нет"
+		if state.AppliedAt != nil {
+			applied = state.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", state.Version, state.Name, applied)
+	}
+	return w.Flush()
+}
+
+// tenantContext возвращает контекст с организацией id; организация должна существовать
+func tenantContext(tenants *services.TenantService, id string) (context.Context, error) {
+	t, err := tenants.GetTenant(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("организация %q не найдена", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tenant.WithTenant(context.Background(), t), nil
+}
+
+// createAPIKeyCommand создает ключ API и печатает его
+func createAPIKeyCommand(tenants *services.TenantService, service *services.APIKeyService, args []string) error {
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	tenantID := flags.String("tenant", models.DefaultTenantID, "организация ключа")
+	name := flags.String("name", "", "название ключа")
+	subject := flags.String("subject", "", "идентификатор владельца, для пользователя - его UUID")
+	roles := flags.String("roles", "", "роли через запятую: admin, analyst")
+	ttl := flags.Duration("ttl", 0, "срок действия ключа, 0 - без срока")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var roleList []string
+	for _, role := range strings.Split(*roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roleList = append(roleList, role)
+		}
+	}
+	var expiresAt *time.Time
+	if *ttl > 0 {
+		expiry := time.Now().Add(*ttl)
+		expiresAt = &expiry
+	}
+
+	ctx, err := tenantContext(tenants, *tenantID)
+	if err != nil {
+		return err
+	}
+	apiKey, key, err := service.CreateAPIKey(ctx, *name, *subject, roleList, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Создан ключ API %d (%s). Сохраните ключ, он больше не будет показан:\n%s\n", apiKey.ID, apiKey.Name, key)
+	return nil
+}
+
+// listAPIKeysCommand печатает список ключей API организации
+func listAPIKeysCommand(tenants *services.TenantService, service *services.APIKeyService, args []string) error {
+	flags := flag.NewFlagSet("apikey list", flag.ContinueOnError)
+	tenantID := flags.String("tenant", models.DefaultTenantID, "организация ключей")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, err := tenantContext(tenants, *tenantID)
+	if err != nil {
+		return err
+	}
+	keys, err := service.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tНАЗВАНИЕ\tПРЕФИКС\tВЛАДЕЛЕЦ\tРОЛИ\tСОСТОЯНИЕ")
+	now := time.Now()
+	for _, key := range keys {
+		state := "активен"
+		if !key.Usable(now) {
+			state = "недействителен"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.Subject, strings.Join(key.Roles, ","), state)
+	}
+	return w.Flush()
+}
+
+// revokeAPIKeyCommand отзывает ключ API
+func revokeAPIKeyCommand(tenants *services.TenantService, service *services.APIKeyService, args []string) error {
+	flags := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+	id := flags.Uint("id", 0, "ID ключа")
+	tenantID := flags.String("tenant", models.DefaultTenantID, "организация ключа")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return fmt.Errorf("не указан -id")
+	}
+
+	ctx, err := tenantContext(tenants, *tenantID)
+	if err != nil {
+		return err
+	}
+	if err := service.RevokeAPIKey(ctx, *id); err != nil {
+		return err
+	}
+	fmt.Printf("Ключ API %d отозван\n", *id)
+	return nil
+}
+
+// createTenantCommand создает организацию
+func createTenantCommand(service *services.TenantService, args []string) error {
+	flags := flag.NewFlagSet("tenant create", flag.ContinueOnError)
+	id := flags.String("id", "", "идентификатор организации")
+	name := flags.String("name", "", "название организации")
+	currency := flags.String("currency", models.DefaultTenantCurrency, "валюта отчетов, код ISO 4217")
+	timezone := flags.String("timezone", models.DefaultTenantTimezone, "часовой пояс организации")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	t := &models.Tenant{ID: *id, Name: *name, Currency: *currency, Timezone: *timezone}
+	if err := service.CreateTenant(t); err != nil {
+		return err
+	}
+	fmt.Printf("Создана организация %s (%s)\n", t.ID, t.Name)
+	return nil
+}
+
+// listTenantsCommand печатает список организаций
+func listTenantsCommand(service *services.TenantService) error {
+	tenants, err := service.ListTenants()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tНАЗВАНИЕ\tВАЛЮТА\tЧАСОВОЙ ПОЯС")
+	for _, t := range tenants {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Currency, t.Timezone)
+	}
+	return w.Flush()
+}
@@ -17,43 +17,52 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // часовые пояса организаций доступны и в образе без системной базы поясов
 
 	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/auth"
 	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/grpcserver"
+	"effective-mobile-subscription/internal/publisher"
+	"effective-mobile-subscription/internal/ratelimit"
+	"effective-mobile-subscription/internal/repository"
 	"effective-mobile-subscription/internal/routes"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+	"effective-mobile-subscription/internal/worker"
 	"effective-mobile-subscription/pkg/middleware"
 	"effective-mobile-subscription/pkg/utils"
 
-	_ "effective-mobile-subscription/docs" // docs is generated by Swag CLI
+	docsv1 "effective-mobile-subscription/docs/v1" // docs is generated by Swag CLI
 
 	httpSwagger "github.com/swaggo/http-swagger"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
 )
 
-//	@title			Subscription Management API
-//	@version		1.0
-//	@description	This is a subscription management service API.
-//	@termsOfService	http://swagger.io/terms/
-
-//	@contact.name	API Support
-//	@contact.url	http://www.swagger.io/support
-//	@contact.email	support@swagger.io
-
-//	@license.name	Apache 2.0
-//	@license.url	http://www.apache.org/licenses/LICENSE-2.0.html
-
-//	@host		localhost:8080
-//	@BasePath	/
-
 func main() {
 	// Загрузить конфигурацию
 	cfg := config.LoadConfig()
+	skipMigrate := flag.Bool("skip-migrate", false, "не применять миграции при запуске, как AUTO_MIGRATE=false")
+	flag.Parse()
+	if *skipMigrate {
+		cfg.AutoMigrate = false
+	}
+
+	// Выполнить команду администрирования вместо запуска сервера
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(cfg, flag.Args()))
+	}
 
 	// Создать логгер
 	logger := utils.NewLogger()
@@ -62,14 +71,86 @@ func main() {
 	// Подключиться к базе данных
 	db := database.ConnectDB(cfg)
 
-	// Выполнить миграции
-	database.Migrate(db)
+	// Выполнить миграции; при нескольких экземплярах их применяет первый, остальные ждут блокировку
+	if cfg.AutoMigrate {
+		database.Migrate(db)
+	} else {
+		logger.Info("Миграции при запуске отключены, схема обновляется командой migrate up")
+	}
+
+	// Создать сервис вебхуков; он ставит события подписок в очередь доставки
+	webhookService := services.NewWebhookService(repository.NewWebhookRepository(db), cfg.WebhookTimeout, cfg.WebhookMaxAttempts)
+
+	// Создать поток событий; он сохраняет изменения подписок в журнал для SSE клиентов всех экземпляров
+	eventStream := services.NewEventStream(repository.NewEventRepository(db))
+
+	// Создать внешний получатель событий, выбранный в конфигурации
+	sink, closeSink, err := publisher.New(cfg, logger)
+	if err != nil {
+		log.Fatalf("Не удалось создать получатель событий: %v", err)
+	}
+	defer closeSink()
+
+	// События изменений записываются в outbox вместе с подписками и публикуются всем получателям
+	publishers := []services.EventPublisher{webhookService, eventStream}
+	if sink != nil {
+		publishers = append(publishers, sink)
+	}
+	outboxRelay := services.NewOutboxRelay(repository.NewOutboxRepository(db), publishers...)
+
+	// Создать сервис организаций; данные каждой организации изолированы от остальных
+	tenantService := services.NewTenantService(repository.NewTenantRepository(db))
+
+	// Создать сервис подписок, общий для REST и gRPC API, над хранилищем, выбранным в DB_DRIVER.
+	// Выгрузка и обезличивание данных пользователя работают с тем же хранилищем
+	subscriptionRepo := newSubscriptionRepository(cfg, db)
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
+	userDataService := services.NewUserDataService(subscriptionRepo)
+	idempotencyService := services.NewIdempotencyService(repository.NewIdempotencyRepository(db), cfg.IdempotencyTTL)
+
+	// Создать аутентификатор: ключи API хранятся в базе данных, JWT проверяются секретом или JWKS
+	apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+	var authenticator *auth.Authenticator
+	if cfg.AuthEnabled {
+		jwtVerifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+			HMACSecret: cfg.JWTHMACSecret,
+			JWKSFile:   cfg.JWTJWKSFile,
+			Issuer:     cfg.JWTIssuer,
+			Audience:   cfg.JWTAudience,
+		})
+		if err != nil {
+			log.Fatalf("Не удалось настроить проверку JWT: %v", err)
+		}
+		authenticator = auth.NewAuthenticator(apiKeyService, jwtVerifier)
+	} else {
+		logger.Warn("Аутентификация отключена, API доступно без учетных данных")
+	}
+
+	// Создать хранилище ограничений частоты запросов, выбранное в конфигурации
+	rateLimits, err := ratelimit.New(cfg, db)
+	if err != nil {
+		log.Fatalf("Не удалось создать ограничение частоты запросов: %v", err)
+	}
 
 	// Настроить маршруты
-	router := routes.SetupRoutes(db)
+	router := routes.SetupRoutes(db, cfg, logger, routes.Services{
+		Subscriptions: subscriptionService,
+		Webhooks:      webhookService,
+		Events:        eventStream,
+		APIKeys:       apiKeyService,
+		Tenants:       tenantService,
+		UserData:      userDataService,
+		Idempotency:   idempotencyService,
+		RateLimits:    rateLimits,
+		Authenticator: authenticator,
+	})
 
-	// Добавить маршрут для Swagger документации
-	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	// Добавить маршруты для Swagger документации: отдельная спецификация для каждой версии API
+	router.PathPrefix("/swagger/v1/").Handler(httpSwagger.Handler(
+		httpSwagger.URL("/swagger/v1/doc.json"),
+		httpSwagger.InstanceName(docsv1.SwaggerInfov1.InstanceName()),
+	))
+	router.PathPrefix("/swagger/").Handler(http.RedirectHandler("/swagger/v1/index.html", http.StatusFound))
 
 	// Добавить middleware
 	router.Use(middleware.ErrorMiddleware(logger))
@@ -81,6 +162,13 @@ func main() {
 		Handler: router,
 	}
 
+	// Закрыть потоки событий при остановке, иначе открытые SSE соединения задержат завершение
+	server.RegisterOnShutdown(eventStream.Close)
+
+	// Создать gRPC сервер
+	grpcAddr := fmt.Sprintf(":%d", cfg.GRPCPort)
+	grpcServer := grpcserver.NewServer(subscriptionService, authenticator, tenant.NewResolver(tenantService), rateLimits, ratelimit.NewPolicy(cfg), cfg.RateLimitIPHeader, logger)
+
 	// Запустить сервер в горутине
 	go func() {
 		logger.Info("Сервер запускается", "адрес", addr)
@@ -89,6 +177,80 @@ func main() {
 		}
 	}()
 
+	// Запустить gRPC сервер в горутине
+	go func() {
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Не удалось запустить gRPC сервер: %v", err)
+		}
+		logger.Info("gRPC сервер запускается", "адрес", grpcAddr)
+		if err := grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			log.Fatalf("Не удалось запустить gRPC сервер: %v", err)
+		}
+	}()
+
+	// Запустить фоновые задачи: публикацию outbox, раздачу событий, доставку вебхуков,
+	// очистку корзины и ключей идемпотентности и уведомления о скором окончании подписок
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Go(func() {
+		worker.Every(workerCtx, cfg.OutboxRelayInterval, "публикация outbox", logger, func(ctx context.Context) error {
+			_, err := outboxRelay.RelayPending(ctx)
+			return err
+		})
+	})
+	workers.Go(func() {
+		worker.Every(workerCtx, time.Hour, "очистка outbox", logger, func(ctx context.Context) error {
+			_, err := outboxRelay.Prune(cfg.OutboxRetention)
+			return err
+		})
+	})
+	workers.Go(func() {
+		eventStream.Run(workerCtx)
+	})
+	workers.Go(func() {
+		worker.Every(workerCtx, time.Hour, "очистка журнала событий", logger, func(ctx context.Context) error {
+			_, err := eventStream.Prune(cfg.EventRetention)
+			return err
+		})
+	})
+	workers.Go(func() {
+		worker.Every(workerCtx, cfg.WebhookDispatchInterval, "доставка вебхуков", logger, func(ctx context.Context) error {
+			_, err := webhookService.DispatchDue(ctx)
+			return err
+		})
+	})
+	workers.Go(func() {
+		worker.Every(workerCtx, time.Hour, "очистка корзины", logger, func(ctx context.Context) error {
+			_, err := subscriptionService.PurgeDeleted(cfg.TrashRetention)
+			return err
+		})
+	})
+	workers.Go(func() {
+		worker.Every(workerCtx, time.Hour, "очистка ключей идемпотентности", logger, func(ctx context.Context) error {
+			_, err := idempotencyService.PruneExpired()
+			return err
+		})
+	})
+	if rateLimits != nil {
+		workers.Go(func() {
+			// Корзина, не использовавшаяся дольше окна, уже полна и не отличается от отсутствующей
+			worker.Every(workerCtx, time.Hour, "очистка ограничений частоты", logger, func(ctx context.Context) error {
+				_, err := rateLimits.Prune(ctx, cfg.RateLimitWindow)
+				return err
+			})
+		})
+	}
+	workers.Go(func() {
+		worker.Every(workerCtx, cfg.EndingSoonCheckInterval, "окончание подписок", logger, func(ctx context.Context) error {
+			// Текущий день определяется в часовом поясе каждой организации
+			return tenantService.ForEachTenant(ctx, func(ctx context.Context) error {
+				_, err := subscriptionService.NotifyEndingSoon(ctx, cfg.EndingSoonWindow)
+				return err
+			})
+		})
+	})
+
 	// Дождаться сигнала прерывания для корректного завершения работы сервера
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -104,5 +266,31 @@ func main() {
 		logger.Error("Сервер принудительно завершен", "ошибка", err)
 	}
 
+	// Дождаться завершения текущих gRPC вызовов в пределах того же срока
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		logger.Error("gRPC сервер принудительно завершен", "ошибка", ctx.Err())
+	}
+
+	// Остановить фоновые задачи; неопубликованные события и незавершенные доставки будут повторены после перезапуска
+	stopWorkers()
+	workers.Wait()
+
 	logger.Info("Сервер завершен")
 }
+
+// newSubscriptionRepository создает хранилище подписок, выбранное в DB_DRIVER. Хранилище в памяти
+// пишет outbox и журнал аудита в db, чтобы события публиковались так же, как с базой данных
+func newSubscriptionRepository(cfg *config.Config, db *gorm.DB) repository.Subscriptions {
+	if cfg.DBDriver == database.DriverMemory {
+		return repository.NewMemorySubscriptionRepository(db)
+	}
+	return repository.NewSubscriptionRepository(db)
+}
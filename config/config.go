@@ -4,18 +4,86 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Структура конфигурации
 type Config struct {
-	DBHost     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBPort     int
-	ServerPort int
+	// Хранилище: postgres, sqlite или memory, и файл базы данных SQLite
+	DBDriver   string
+	SQLitePath string
+
+	DBHost         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	DBPort         int
+	ServerPort     int
+	GRPCPort       int
+	IdempotencyTTL time.Duration
+
+	// Применять миграции базы данных при запуске
+	AutoMigrate bool
+
+	// Ограничения запросов GraphQL
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+
+	// Маршруты без префикса версии, оставленные на переходный период
+	LegacyRoutesEnabled      bool
+	LegacyRoutesDeprecatedAt time.Time
+	LegacyRoutesSunset       time.Time
+
+	// Доставка вебхуков
+	WebhookDispatchInterval time.Duration
+	WebhookMaxAttempts      int
+	WebhookTimeout          time.Duration
+
+	// Уведомления о скором окончании подписок
+	EndingSoonWindow        time.Duration
+	EndingSoonCheckInterval time.Duration
+
+	// Срок хранения журнала событий для возобновления потока
+	EventRetention time.Duration
+
+	// Срок хранения удаленных подписок в корзине до окончательного удаления
+	TrashRetention time.Duration
+
+	// Публикация событий из outbox
+	OutboxRelayInterval time.Duration
+	OutboxRetention     time.Duration
+	OutboxSink          string
+	OutboxHTTPURL       string
+	OutboxHTTPTimeout   time.Duration
+	NATSURL             string
+	NATSSubject         string
+
+	// Аутентификация
+	AuthEnabled     bool
+	AuthPublicPaths []string
+	JWTHMACSecret   string
+	JWTJWKSFile     string
+	JWTIssuer       string
+	JWTAudience     string
+
+	// Заголовок с организацией запроса; учитывается, если организация не задана учетными данными
+	TenantHeader string
+
+	// Ограничение частоты запросов: хранилище корзин, окно и число запросов клиента за окно
+	// для чтения, изменений и тяжелых маршрутов
+	RateLimitBackend    string
+	RateLimitWindow     time.Duration
+	RateLimitRead       int
+	RateLimitWrite      int
+	RateLimitHeavy      int
+	RateLimitHeavyPaths []string
+	RateLimitIPHeader   string
+
+	// Ограничение всех запросов с одного IP адреса до аутентификации; 0 отключает его
+	RateLimitIP int
 }
 
 // LoadConfig загружает конфигурацию из переменных окружения
@@ -27,12 +95,63 @@ func LoadConfig() *Config {
 	}
 
 	config := &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "admin"),
-		DBName:     getEnv("DB_NAME", "subscriptions"),
-		DBPort:     getEnvAsInt("DB_PORT", 5432),
-		ServerPort: getEnvAsInt("SERVER_PORT", 8080),
+		DBDriver:   getEnv("DB_DRIVER", "postgres"),
+		SQLitePath: getEnv("SQLITE_PATH", "subscriptions.db"),
+
+		DBHost:         getEnv("DB_HOST", "localhost"),
+		DBUser:         getEnv("DB_USER", "postgres"),
+		DBPassword:     getEnv("DB_PASSWORD", "admin"),
+		DBName:         getEnv("DB_NAME", "subscriptions"),
+		DBPort:         getEnvAsInt("DB_PORT", 5432),
+		ServerPort:     getEnvAsInt("SERVER_PORT", 8080),
+		GRPCPort:       getEnvAsInt("GRPC_PORT", 9090),
+		IdempotencyTTL: getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		AutoMigrate: getEnvAsBool("AUTO_MIGRATE", true),
+
+		GraphQLMaxDepth:      getEnvAsInt("GRAPHQL_MAX_DEPTH", 8),
+		GraphQLMaxComplexity: getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+
+		LegacyRoutesEnabled:      getEnvAsBool("LEGACY_ROUTES_ENABLED", true),
+		LegacyRoutesDeprecatedAt: getEnvAsDate("LEGACY_ROUTES_DEPRECATED_AT", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+		LegacyRoutesSunset:       getEnvAsDate("LEGACY_ROUTES_SUNSET", time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)),
+
+		WebhookDispatchInterval: getEnvAsDuration("WEBHOOK_DISPATCH_INTERVAL", time.Second),
+		WebhookMaxAttempts:      getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookTimeout:          getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		EndingSoonWindow:        getEnvAsDuration("ENDING_SOON_WINDOW", 7*24*time.Hour),
+		EndingSoonCheckInterval: getEnvAsDuration("ENDING_SOON_CHECK_INTERVAL", time.Hour),
+
+		EventRetention: getEnvAsDuration("EVENT_RETENTION", 7*24*time.Hour),
+
+		TrashRetention: getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour),
+
+		OutboxRelayInterval: getEnvAsDuration("OUTBOX_RELAY_INTERVAL", time.Second),
+		OutboxRetention:     getEnvAsDuration("OUTBOX_RETENTION", 24*time.Hour),
+		OutboxSink:          getEnv("OUTBOX_SINK", "log"),
+		OutboxHTTPURL:       getEnv("OUTBOX_HTTP_URL", ""),
+		OutboxHTTPTimeout:   getEnvAsDuration("OUTBOX_HTTP_TIMEOUT", 10*time.Second),
+		NATSURL:             getEnv("NATS_URL", "nats://localhost:4222"),
+		NATSSubject:         getEnv("NATS_SUBJECT", "subscriptions"),
+
+		AuthEnabled:     getEnvAsBool("AUTH_ENABLED", true),
+		AuthPublicPaths: getEnvAsList("AUTH_PUBLIC_PATHS", []string{"/health", "/api/v1/health", "/swagger/"}),
+		JWTHMACSecret:   getEnv("JWT_HMAC_SECRET", ""),
+		JWTJWKSFile:     getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:       getEnv("JWT_ISSUER", ""),
+		JWTAudience:     getEnv("JWT_AUDIENCE", ""),
+
+		TenantHeader: getEnv("TENANT_HEADER", "X-Tenant-ID"),
+
+		RateLimitBackend:    getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitWindow:     getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+		RateLimitRead:       getEnvAsInt("RATE_LIMIT_READ", 300),
+		RateLimitWrite:      getEnvAsInt("RATE_LIMIT_WRITE", 60),
+		RateLimitHeavy:      getEnvAsInt("RATE_LIMIT_HEAVY", 10),
+		RateLimitHeavyPaths: getEnvAsList("RATE_LIMIT_HEAVY_PATHS", []string{"/subscriptions/cost", "/subscriptions/export"}),
+		RateLimitIPHeader:   getEnv("RATE_LIMIT_IP_HEADER", ""),
+		RateLimitIP:         getEnvAsInt("RATE_LIMIT_IP", 600),
 	}
 
 	return config
@@ -55,3 +174,49 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsDuration получает переменную окружения как длительность (например, 24h) или возвращает значение по умолчанию
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool получает переменную окружения как логическое значение или возвращает значение по умолчанию
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList получает переменную окружения как список значений через запятую или возвращает значение по умолчанию.
+// Пустая переменная означает пустой список
+func getEnvAsList(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvAsDate получает переменную окружения как дату в формате ГГГГ-ММ-ДД или возвращает значение по умолчанию
+func getEnvAsDate(key string, defaultValue time.Time) time.Time {
+	if value, exists := os.LookupEnv(key); exists {
+		if date, err := time.Parse(time.DateOnly, value); err == nil {
+			return date
+		}
+	}
+	return defaultValue
+}
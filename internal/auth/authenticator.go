@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// APIKeyPrefix начало каждого ключа API; по нему ключ отличается от JWT
+const APIKeyPrefix = "sk_"
+
+var (
+	// ErrMissingCredentials возвращается, если запрос не содержит учетных данных
+	ErrMissingCredentials = errors.New("требуется аутентификация")
+
+	// ErrInvalidCredentials возвращается для неверного, отозванного или истекшего ключа или токена
+	ErrInvalidCredentials = errors.New("неверные учетные данные")
+)
+
+// APIKeyVerifier проверяет ключ API и возвращает его запись
+type APIKeyVerifier interface {
+	VerifyAPIKey(key string) (*models.APIKey, error)
+}
+
+// Authenticator проверяет ключи API и JWT
+type Authenticator struct {
+	apiKeys APIKeyVerifier
+	jwt     *JWTVerifier
+}
+
+// NewAuthenticator создает аутентификатор. Если jwt равен nil, принимаются только ключи API
+func NewAuthenticator(apiKeys APIKeyVerifier, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{apiKeys: apiKeys, jwt: jwt}
+}
+
+// Authenticate проверяет ключ API или JWT и возвращает участника
+func (a *Authenticator) Authenticate(credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	if strings.HasPrefix(credential, APIKeyPrefix) {
+		key, err := a.apiKeys.VerifyAPIKey(credential)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{Subject: key.Subject, Roles: key.Roles, TenantID: key.TenantID, Method: MethodAPIKey, KeyID: key.ID}, nil
+	}
+
+	if a.jwt == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.jwt.Verify(credential)
+}
+
+// Credential извлекает учетные данные из заголовка Authorization: Bearer или X-API-Key
+func Credential(header http.Header) string {
+	if token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return header.Get("X-API-Key")
+}
@@ -0,0 +1,92 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testAPIKey = auth.APIKeyPrefix + "0123456789abcdef"
+
+// fakeAPIKeys принимает только ключ testAPIKey
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) VerifyAPIKey(key string) (*models.APIKey, error) {
+	if key != testAPIKey {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &models.APIKey{ID: 7, TenantID: "acme", Subject: "service-account", Roles: []string{auth.RoleAnalyst}}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+		jwt.MapClaims{"sub": testSubject, "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name          string
+		authenticator *auth.Authenticator
+		credential    string
+		want          *auth.Principal
+		err           error
+	}{
+		{"без учетных данных", auth.NewAuthenticator(fakeAPIKeys{}, verifier), "", nil, auth.ErrMissingCredentials},
+		{"ключ API", auth.NewAuthenticator(fakeAPIKeys{}, verifier), testAPIKey, &auth.Principal{
+			Subject: "service-account", Roles: []string{auth.RoleAnalyst}, TenantID: "acme", Method: auth.MethodAPIKey, KeyID: 7,
+		}, nil},
+		{"неверный ключ API", auth.NewAuthenticator(fakeAPIKeys{}, verifier), auth.APIKeyPrefix + "wrong", nil, auth.ErrInvalidCredentials},
+		{"JWT", auth.NewAuthenticator(fakeAPIKeys{}, verifier), token, &auth.Principal{Subject: testSubject, Method: auth.MethodJWT}, nil},
+		{"JWT без проверки JWT", auth.NewAuthenticator(fakeAPIKeys{}, nil), token, nil, auth.ErrInvalidCredentials},
+		{"неверный JWT", auth.NewAuthenticator(fakeAPIKeys{}, verifier), token + "x", nil, auth.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.authenticator.Authenticate(tt.credential)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Authenticate = %+v, %v, ожидалась %v", principal, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.Subject != tt.want.Subject || principal.TenantID != tt.want.TenantID ||
+				principal.Method != tt.want.Method || principal.KeyID != tt.want.KeyID ||
+				!slices.Equal(principal.Roles, tt.want.Roles) {
+				t.Fatalf("участник = %+v, ожидался %+v", principal, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredential(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"Bearer", http.Header{"Authorization": {"Bearer  token "}}, "token"},
+		{"X-API-Key", http.Header{"X-Api-Key": {testAPIKey}}, testAPIKey},
+		{"Bearer важнее X-API-Key", http.Header{"Authorization": {"Bearer token"}, "X-Api-Key": {testAPIKey}}, "token"},
+		{"другая схема", http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}, ""},
+		{"без заголовков", http.Header{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auth.Credential(tt.header); got != tt.want {
+				t.Fatalf("Credential = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
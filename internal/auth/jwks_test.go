@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"effective-mobile-subscription/internal/auth"
+)
+
+// Открытые ключи из RFC 7517, приложение A.1
+const (
+	rfcECKey  = `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","use":"enc","kid":"1"}`
+	rfcRSAKey = `{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB","alg":"RS256","kid":"2011-04-29"}`
+)
+
+func TestLoadJWKS(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kids    []string
+		err     string
+	}{
+		{"RSA", `{"keys":[` + rfcRSAKey + `]}`, []string{"2011-04-29"}, ""},
+		{"ключ шифрования пропускается", `{"keys":[` + rfcRSAKey + `,` + rfcECKey + `]}`, []string{"2011-04-29"}, ""},
+		{"EC подписи", `{"keys":[` + strings.Replace(rfcECKey, `"enc"`, `"sig"`, 1) + `]}`, []string{"1"}, ""},
+		{"повтор kid", `{"keys":[` + rfcRSAKey + `,` + rfcRSAKey + `]}`, nil, "повторяется"},
+		{"только ключи шифрования", `{"keys":[` + rfcECKey + `]}`, nil, "нет ключей подписи"},
+		{"неизвестный тип", `{"keys":[{"kty":"oct","kid":"k","k":"c2VjcmV0"}]}`, nil, "неподдерживаемый тип"},
+		{"неизвестная кривая", `{"keys":[{"kty":"EC","kid":"k","crv":"P-192","x":"AA","y":"AA"}]}`, nil, "неподдерживаемая кривая"},
+		{"точка не на кривой", `{"keys":[{"kty":"EC","kid":"k","crv":"P-256","x":"AQ","y":"AQ"}]}`, nil, "ключ 0"},
+		{"малая экспонента", `{"keys":[{"kty":"RSA","kid":"k","n":"AQAB","e":"AQ"}]}`, nil, "экспонента"},
+		{"не JSON", `keys`, nil, "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			keys, err := auth.LoadJWKS(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("LoadJWKS: %v, ожидалась ошибка с %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadJWKS: %v", err)
+			}
+			if len(keys) != len(tt.kids) {
+				t.Fatalf("загружено ключей %d, ожидалось %d", len(keys), len(tt.kids))
+			}
+			for _, kid := range tt.kids {
+				switch key := keys[kid].(type) {
+				case *rsa.PublicKey:
+					if key.E != 65537 || key.N.BitLen() != 2048 {
+						t.Fatalf("ключ RSA: e=%d, %d бит", key.E, key.N.BitLen())
+					}
+				case *ecdsa.PublicKey:
+				default:
+					t.Fatalf("ключ %q: %T", kid, key)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadJWKSMissingFile(t *testing.T) {
+	if _, err := auth.LoadJWKS(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Fatalf("LoadJWKS: %v, ожидалась ошибка отсутствия файла", err)
+	}
+}
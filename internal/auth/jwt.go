@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig настройки проверки JWT. Должен быть задан HMACSecret, JWKSFile или оба
+type JWTConfig struct {
+	// Секрет для токенов HS256, HS384 и HS512
+	HMACSecret string
+
+	// Путь к файлу JWKS с открытыми ключами для токенов RS*, PS* и ES*
+	JWKSFile string
+
+	// Ожидаемые iss и aud; пустые значения не проверяются
+	Issuer   string
+	Audience string
+}
+
+// JWTVerifier проверяет подпись и срок действия JWT
+type JWTVerifier struct {
+	secret []byte
+	keys   map[string]crypto.PublicKey
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier создает проверку JWT. Если не задан ни секрет, ни файл JWKS, возвращается nil
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.HMACSecret == "" && cfg.JWKSFile == "" {
+		return nil, nil
+	}
+
+	verifier := &JWTVerifier{}
+	var methods []string
+	if cfg.HMACSecret != "" {
+		verifier.secret = []byte(cfg.HMACSecret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить JWKS: %w", err)
+		}
+		verifier.keys = keys
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	verifier.parser = jwt.NewParser(options...)
+
+	return verifier, nil
+}
+
+// Verify проверяет токен и возвращает участника: subject из claim sub,
+// роли из claim roles (массив) или role (строка), организацию из claim tenant_id
+func (v *JWTVerifier) Verify(tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: в токене нет claim sub", ErrInvalidCredentials)
+	}
+
+	principal := &Principal{Subject: subject, Method: MethodJWT}
+	principal.TenantID, _ = claims["tenant_id"].(string)
+	switch roles := claims["roles"].(type) {
+	case []any:
+		for _, role := range roles {
+			if role, ok := role.(string); ok {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+	case string:
+		principal.Roles = strings.Fields(roles)
+	}
+	if role, ok := claims["role"].(string); ok && role != "" {
+		principal.Roles = append(principal.Roles, role)
+	}
+
+	return principal, nil
+}
+
+// key выбирает ключ проверки подписи по алгоритму и kid токена
+func (v *JWTVerifier) key(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return v.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	// Токен без kid допускается, если в JWKS единственный ключ
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, errors.New("неизвестный kid")
+}
@@ -0,0 +1,273 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret   = "test-secret"
+	testIssuer   = "https://issuer.example"
+	testAudience = "subscriptions"
+	testSubject  = "550e8400-e29b-41d4-a716-446655440000"
+)
+
+// validClaims claims токена, который принимает проверка с testIssuer и testAudience
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": testSubject,
+		"iss": testIssuer,
+		"aud": testAudience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// with возвращает копию claims с измененными значениями; nil удаляет claim
+func with(claims jwt.MapClaims, changes jwt.MapClaims) jwt.MapClaims {
+	result := jwt.MapClaims{}
+	for name, value := range claims {
+		result[name] = value
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// sign подписывает токен методом method; непустой kid записывается в заголовок
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("подпись токена: %v", err)
+	}
+	return signed
+}
+
+// writeJWKS записывает открытые ключи в файл JWKS и возвращает путь к нему
+func writeJWKS(t *testing.T, keys map[string]any) string {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			point, err := key.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := (len(point) - 1) / 2
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "crv": key.Curve.Params().Name,
+				"x": encode(point[1 : 1+size]), "y": encode(point[1+size:]),
+			})
+		default:
+			t.Fatalf("неподдерживаемый ключ %T", key)
+		}
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJWTVerifierHMAC(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: testSecret, Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"действующий токен", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims()), true},
+		{"HS512", sign(t, jwt.SigningMethodHS512, []byte(testSecret), "", validClaims()), true},
+		{"истекший токен", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+			with(validClaims(), jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})), false},
+		{"без срока действия", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+			with(validClaims(), jwt.MapClaims{"exp": nil})), false},
+		{"чужой aud", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+			with(validClaims(), jwt.MapClaims{"aud": "other"})), false},
+		{"чужой iss", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+			with(validClaims(), jwt.MapClaims{"iss": "https://other.example"})), false},
+		{"без sub", sign(t, jwt.SigningMethodHS256, []byte(testSecret), "",
+			with(validClaims(), jwt.MapClaims{"sub": nil})), false},
+		{"неверный секрет", sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "", validClaims()), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims()), false},
+		{"RS256 без JWKS", sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims()), false},
+		{"не JWT", "not-a-token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tt.token)
+			if !tt.valid {
+				if !errors.Is(err, auth.ErrInvalidCredentials) {
+					t.Fatalf("Verify = %+v, %v, ожидалась ErrInvalidCredentials", principal, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if principal.Subject != testSubject || principal.Method != auth.MethodJWT {
+				t.Fatalf("участник = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		JWKSFile: writeJWKS(t, map[string]any{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey}),
+		Issuer:   testIssuer,
+		Audience: testAudience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Открытый ключ RSA в роли секрета HMAC: подмена алгоритма не должна проходить
+	publicKeyBytes := rsaKey.PublicKey.N.Bytes()
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256 по kid", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", validClaims()), true},
+		{"PS256 по kid", sign(t, jwt.SigningMethodPS256, rsaKey, "rsa", validClaims()), true},
+		{"ES256 по kid", sign(t, jwt.SigningMethodES256, ecKey, "ec", validClaims()), true},
+		{"ключ другого kid", sign(t, jwt.SigningMethodRS256, rsaKey, "ec", validClaims()), false},
+		{"неизвестный kid", sign(t, jwt.SigningMethodRS256, rsaKey, "missing", validClaims()), false},
+		{"без kid при нескольких ключах", sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims()), false},
+		{"чужой ключ", sign(t, jwt.SigningMethodRS256, otherKey, "rsa", validClaims()), false},
+		{"HS256 без секрета", sign(t, jwt.SigningMethodHS256, publicKeyBytes, "rsa", validClaims()), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rsa", validClaims()), false},
+		{"истекший токен", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+			with(validClaims(), jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})), false},
+		{"чужой aud", sign(t, jwt.SigningMethodRS256, rsaKey, "rsa",
+			with(validClaims(), jwt.MapClaims{"aud": []string{"other"}})), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, auth.ErrInvalidCredentials) {
+				t.Fatalf("Verify: %v, ожидалась ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+// Токен без kid принимается, если в JWKS единственный ключ
+func TestJWTVerifierSingleKeyWithoutKid(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{JWKSFile: writeJWKS(t, map[string]any{"only": &key.PublicKey})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifier.Verify(sign(t, jwt.SigningMethodES384, key, "", validClaims())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestJWTVerifierClaims(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		roles  []string
+		tenant string
+	}{
+		{"без ролей", jwt.MapClaims{}, nil, ""},
+		{"массив roles", jwt.MapClaims{"roles": []string{"admin", "analyst"}}, []string{"admin", "analyst"}, ""},
+		{"строка roles", jwt.MapClaims{"roles": "admin analyst"}, []string{"admin", "analyst"}, ""},
+		{"claim role", jwt.MapClaims{"roles": []string{"analyst"}, "role": "admin"}, []string{"analyst", "admin"}, ""},
+		{"организация", jwt.MapClaims{"tenant_id": "acme"}, nil, "acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := with(jwt.MapClaims{"sub": testSubject, "exp": time.Now().Add(time.Hour).Unix()}, tt.claims)
+			principal, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if !slices.Equal(principal.Roles, tt.roles) || principal.TenantID != tt.tenant {
+				t.Fatalf("роли %v, организация %q; ожидались %v, %q", principal.Roles, principal.TenantID, tt.roles, tt.tenant)
+			}
+		})
+	}
+}
+
+// Без секрета и JWKS проверка JWT отключена
+func TestNewJWTVerifierDisabled(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{Issuer: testIssuer})
+	if verifier != nil || err != nil {
+		t.Fatalf("NewJWTVerifier = %v, %v, ожидалось nil, nil", verifier, err)
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Middleware аутентифицирует запросы и помещает участника в контекст.
+// Пути из publicPaths доступны без аутентификации: путь, оканчивающийся на "/",
+// открывает все вложенные пути, остальные сравниваются точно
+func Middleware(authenticator *Authenticator, publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsPublic(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(Credential(r.Header))
+			if err != nil {
+				if !errors.Is(err, ErrMissingCredentials) && !errors.Is(err, ErrInvalidCredentials) {
+					slog.Error("Не удалось проверить учетные данные", "ошибка", err)
+					http.Error(w, "Не удалось проверить учетные данные", http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				http.Error(w, "Требуется аутентификация: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireRole пропускает только участников, у которых есть хотя бы одна из ролей
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFrom(r.Context())
+			if !ok || !slices.ContainsFunc(roles, principal.HasRole) {
+				http.Error(w, "Недостаточно прав", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsPublic сообщает, что путь доступен без аутентификации
+func IsPublic(path string, publicPaths []string) bool {
+	for _, public := range publicPaths {
+		if path == public || (strings.HasSuffix(public, "/") && strings.HasPrefix(path, public)) {
+			return true
+		}
+	}
+	return false
+}
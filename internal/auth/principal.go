@@ -0,0 +1,63 @@
+// Package auth аутентифицирует вызывающих API по ключам API и JWT и передает
+// аутентифицированного участника через контекст запроса
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Роли участников
+const (
+	// RoleAdmin полный доступ ко всем данным и управлению ключами API
+	RoleAdmin = "admin"
+
+	// RoleAnalyst чтение всех данных без изменения
+	RoleAnalyst = "analyst"
+)
+
+// Roles все известные роли
+var Roles = []string{RoleAdmin, RoleAnalyst}
+
+// Способы аутентификации
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal аутентифицированный вызывающий
+type Principal struct {
+	// Идентификатор вызывающего; для обычных пользователей - UUID пользователя
+	Subject string
+
+	// Роли вызывающего
+	Roles []string
+
+	// Организация вызывающего; пусто, если учетные данные не привязаны к организации
+	TenantID string
+
+	// Способ аутентификации: api_key или jwt
+	Method string
+
+	// ID ключа API, если вызывающий аутентифицирован ключом
+	KeyID uint
+}
+
+// HasRole сообщает, что у участника есть роль
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// principalKey ключ участника в контексте
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным участником
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom возвращает участника из контекста
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"effective-mobile-subscription/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Хранилища, выбираемые в DB_DRIVER
+const (
+	// DriverPostgres PostgreSQL: основное хранилище, поддерживает несколько экземпляров сервиса
+	DriverPostgres = "postgres"
+	// DriverSQLite файл SQLite для локального запуска одного экземпляра без сервера базы данных
+	DriverSQLite = "sqlite"
+	// DriverMemory подписки в памяти процесса, остальные данные в SQLite в памяти; все теряется при остановке
+	DriverMemory = "memory"
+)
+
+// sqlitePragmas параметры соединения SQLite: каскадное удаление по внешним ключам
+// и ожидание блокировки вместо немедленной ошибки
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+
+// ConnectDB устанавливает соединение с базой данных, выбранной в DB_DRIVER, с использованием GORM
+func ConnectDB(cfg *config.Config) *gorm.DB {
+	db, err := Open(cfg, &gorm.Config{})
+	if err != nil {
+		log.Fatal("Не удалось подключиться к базе данных:", err)
+	}
+
+	log.Printf("Успешное подключение к базе данных (%s)", cfg.DBDriver)
+	return db
+}
+
+// Open открывает базу данных, выбранную в DB_DRIVER, и возвращает ошибку вместо завершения процесса,
+// поэтому подходит для тестов и встраивания сервиса в CLI инструменты
+func Open(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.DBDriver {
+	case DriverPostgres:
+		// Создать строку подключения к PostgreSQL
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
+			cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(cfg.SQLitePath + "?" + sqlitePragmas)
+	case DriverMemory:
+		dialector = sqlite.Open(":memory:?" + sqlitePragmas)
+	default:
+		return nil, fmt.Errorf("неизвестный DB_DRIVER %q, ожидается %s, %s или %s", cfg.DBDriver, DriverPostgres, DriverSQLite, DriverMemory)
+	}
+
+	// Подключиться к базе данных
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite выполняет пишущие транзакции по одной, а база в памяти существует, пока открыто ее соединение,
+	// поэтому используется одно соединение
+	if cfg.DBDriver != DriverPostgres {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
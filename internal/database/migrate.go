@@ -1,22 +1,294 @@
 package database
 
 import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io/fs"
 	"log"
-
-	"effective-mobile-subscription/internal/models"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// Migrate выполняет миграции базы данных
+// migrationFiles SQL миграции схемы: NNNN_название.up.sql и NNNN_название.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID ключ рекомендательной блокировки PostgreSQL: пока она удерживается,
+// другие экземпляры сервиса ждут окончания миграций
+const migrationLockID int64 = 4_718_302_551
+
+// migrationFilePattern разбирает имя файла миграции на номер, название и направление
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// createSchemaMigrations создает таблицу примененных миграций
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+// Migration версионированная миграция схемы базы данных
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState миграция и время ее применения; AppliedAt пусто у непримененной миграции
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration запись о примененной миграции
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName возвращает имя таблицы примененных миграций
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrate применяет все новые миграции и завершает процесс при ошибке.
+// В SQLite вместо миграций таблицы создаются и дополняются по моделям
 func Migrate(db *gorm.DB) {
 	log.Println("Выполнение миграций...")
 
-	// Мигрировать модель Subscription
-	err := db.AutoMigrate(&models.Subscription{})
+	if !isPostgres(db) {
+		if err := migrateSQLite(db); err != nil {
+			log.Fatal("Не удалось создать таблицы SQLite:", err)
+		}
+		log.Println("Таблицы SQLite созданы по моделям")
+		return
+	}
+
+	applied, err := MigrateUp(db, 0)
 	if err != nil {
 		log.Fatal("Не удалось выполнить миграцию базы данных:", err)
 	}
+	for _, migration := range applied {
+		log.Printf("Применена миграция %04d_%s", migration.Version, migration.Name)
+	}
 
 	log.Println("Миграции успешно завершены")
 }
+
+// Migrations возвращает встроенные миграции в порядке номеров
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("неверное имя файла миграции %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("у миграции %d разные названия: %s и %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("у миграции %04d_%s нет файла up", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// MigrateUp применяет steps новых миграций по порядку, при steps <= 0 - все. Каждая миграция
+// выполняется в отдельной транзакции вместе с записью в schema_migrations. Возвращает примененные миграции.
+// В SQLite таблицы создаются по моделям, и список примененных миграций пуст
+func MigrateUp(db *gorm.DB, steps int) ([]Migration, error) {
+	if !isPostgres(db) {
+		return nil, migrateSQLite(db)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("миграция %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown откатывает steps последних примененных миграций, при steps <= 0 - одну.
+// Возвращает откаченные миграции
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if !isEmptySQL(migration.Down) {
+					if err := tx.Exec(migration.Down).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Delete(&schemaMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("откат миграции %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus возвращает встроенные миграции с отметкой о применении
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			state := MigrationState{Migration: migration}
+			if record, ok := done[migration.Version]; ok {
+				state.AppliedAt = &record.AppliedAt
+			}
+			states = append(states, state)
+		}
+		return nil
+	})
+
+	return states, err
+}
+
+// withMigrationLock выполняет fn на одном соединении под рекомендательной блокировкой миграций
+// и создает таблицу schema_migrations, если ее еще нет. Для SQLite возвращает ErrMigrationsPostgresOnly
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	if !isPostgres(db) {
+		return ErrMigrationsPostgresOnly
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		// Новая сессия, чтобы условия одного запроса не переходили в следующие на этом соединении
+		conn = conn.Session(&gorm.Session{})
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+		if err := conn.Exec(createSchemaMigrations).Error; err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// appliedMigrations возвращает примененные миграции по номерам
+func appliedMigrations(conn *gorm.DB) (map[int64]schemaMigration, error) {
+	var records []schemaMigration
+	if err := conn.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// isEmptySQL сообщает, что миграция содержит только комментарии и пробелы
+func isEmptySQL(sql string) bool {
+	for line := range strings.SplitSeq(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
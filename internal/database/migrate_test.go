@@ -0,0 +1,129 @@
+package database_test
+
+import (
+	"os"
+	"testing"
+
+	"effective-mobile-subscription/internal/database"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// upgradeSchema схема PostgreSQL, в которой воспроизводится база исходной версии сервиса
+const upgradeSchema = "migration_upgrade_test"
+
+// baselineSchema таблица подписок, которую создавал AutoMigrate исходной версии: без организаций,
+// заметок, тегов и корзины
+const baselineSchema = `CREATE TABLE subscriptions (
+	id bigserial PRIMARY KEY,
+	service_name text NOT NULL,
+	price bigint NOT NULL,
+	user_id uuid NOT NULL,
+	start_date timestamptz NOT NULL,
+	end_date timestamptz,
+	created_at timestamptz,
+	updated_at timestamptz
+)`
+
+// TestMigrateUpFromBaseline выполняется, только если TEST_POSTGRES_DSN задает тестовую базу данных.
+// Проверка пересоздает в ней схему migration_upgrade_test
+func TestMigrateUpFromBaseline(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан")
+	}
+	db := openUpgradeSchema(t, dsn)
+
+	if err := db.Exec(baselineSchema).Error; err != nil {
+		t.Fatalf("исходная схема: %v", err)
+	}
+	err := db.Exec(`INSERT INTO subscriptions (service_name, price, user_id, start_date, created_at, updated_at)
+		VALUES ('Netflix', 990, '550e8400-e29b-41d4-a716-446655440000', '2025-01-01', '2025-01-01', '2025-01-01')`).Error
+	if err != nil {
+		t.Fatalf("подписка исходной версии: %v", err)
+	}
+
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := database.MigrateUp(db, 0)
+	if err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("применено %d миграций, ожидалось %d", len(applied), len(migrations))
+	}
+
+	var subscription struct {
+		TenantID string
+		Notes    *string
+		Tags     *string
+	}
+	if err := db.Raw("SELECT tenant_id, notes, tags FROM subscriptions").Scan(&subscription).Error; err != nil {
+		t.Fatalf("новые столбцы подписок: %v", err)
+	}
+	if subscription.TenantID != "default" || subscription.Notes != nil || subscription.Tags != nil {
+		t.Fatalf("подписка после миграций = %+v, ожидалась организация default без заметок и тегов", subscription)
+	}
+
+	for table, want := range map[string]int64{"subscription_versions": 1, "subscription_price_history": 1} {
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		if count != want {
+			t.Errorf("записей в %s = %d, ожидалось %d", table, count, want)
+		}
+	}
+
+	// Повторный запуск ничего не применяет
+	if applied, err := database.MigrateUp(db, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("повторный запуск: применено %d, ошибка %v", len(applied), err)
+	}
+}
+
+// openUpgradeSchema пересоздает схему upgradeSchema и открывает базу, в которой она стоит первой
+// в search_path. Расширение pg_trgm остается доступным из public
+func openUpgradeSchema(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("подключение к PostgreSQL: %v", err)
+	}
+	dropSchema := func() error {
+		return admin.Exec("DROP SCHEMA IF EXISTS " + upgradeSchema + " CASCADE").Error
+	}
+	if err := dropSchema(); err != nil {
+		t.Fatalf("удаление схемы: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + upgradeSchema).Error; err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("разбор TEST_POSTGRES_DSN: %v", err)
+	}
+	config.RuntimeParams["search_path"] = upgradeSchema + ", public"
+	conn := stdlib.OpenDB(*config)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("подключение к схеме %s: %v", upgradeSchema, err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = dropSchema()
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS
+	audit_entries, rate_limit_buckets, api_keys, outbox, subscription_events,
+	webhook_deliveries, webhooks, idempotency_keys, subscription_reminders,
+	subscription_versions, subscription_price_history, subscriptions, tenants;
+
+DROP FUNCTION IF EXISTS audit_entries_append_only();
@@ -0,0 +1,239 @@
+-- Схема, которую до перехода на версионированные миграции создавал AutoMigrate.
+-- Таблицы базы, созданной AutoMigrate одной из прежних версий, уже есть, но в них может не быть
+-- столбцов, добавленных позже: их дополняет ALTER TABLE ... ADD COLUMN IF NOT EXISTS перед созданием индексов
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS tenants (
+	id varchar(64) PRIMARY KEY,
+	name text NOT NULL,
+	currency varchar(3) NOT NULL DEFAULT 'RUB',
+	timezone text NOT NULL DEFAULT 'Europe/Moscow',
+	created_at timestamptz,
+	updated_at timestamptz
+);
+
+-- Организация по умолчанию: ей принадлежат данные, созданные до появления организаций
+INSERT INTO tenants (id, name, currency, timezone, created_at, updated_at)
+VALUES ('default', 'Организация по умолчанию', 'RUB', 'Europe/Moscow', now(), now())
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	service_name text NOT NULL,
+	price bigint NOT NULL,
+	user_id uuid NOT NULL,
+	start_date timestamptz NOT NULL,
+	end_date timestamptz,
+	notes text,
+	tags jsonb,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz
+);
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	ADD COLUMN IF NOT EXISTS notes text,
+	ADD COLUMN IF NOT EXISTS tags jsonb,
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_id ON subscriptions (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_created_at_id ON subscriptions (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON subscriptions (deleted_at);
+
+-- Индексы для поиска подписок
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_trgm ON subscriptions USING gin (service_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_notes_trgm ON subscriptions USING gin (notes gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tags_trgm ON subscriptions USING gin ((tags::text) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_search_document ON subscriptions
+	USING gin (to_tsvector('simple', service_name || ' ' || COALESCE(notes, '') || ' ' || COALESCE(tags::text, '')));
+
+CREATE TABLE IF NOT EXISTS subscription_price_history (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	subscription_id bigint NOT NULL,
+	old_price bigint,
+	price bigint NOT NULL,
+	changed_at timestamptz NOT NULL,
+	CONSTRAINT fk_subscription_price_history_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE
+);
+ALTER TABLE subscription_price_history
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_subscription_price_history_tenant_id ON subscription_price_history (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_price_history_subscription_id ON subscription_price_history (subscription_id);
+
+CREATE TABLE IF NOT EXISTS subscription_versions (
+	version_id bigserial PRIMARY KEY,
+	subscription_id bigint NOT NULL,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	service_name text NOT NULL,
+	price bigint NOT NULL,
+	user_id uuid NOT NULL,
+	start_date timestamptz NOT NULL,
+	end_date timestamptz,
+	notes text,
+	tags jsonb,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	valid_from timestamptz NOT NULL,
+	valid_to timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_subscription_versions_tenant_id ON subscription_versions (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_versions_subscription_valid ON subscription_versions (subscription_id, valid_from);
+CREATE INDEX IF NOT EXISTS idx_subscription_versions_valid_to ON subscription_versions (valid_to);
+
+CREATE TABLE IF NOT EXISTS subscription_reminders (
+	subscription_id bigint,
+	end_date timestamptz,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	sent_at timestamptz NOT NULL,
+	PRIMARY KEY (subscription_id, end_date),
+	CONSTRAINT fk_subscription_reminders_subscription FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE
+);
+ALTER TABLE subscription_reminders
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_subscription_reminders_tenant_id ON subscription_reminders (tenant_id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key varchar(255) PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	request_hash varchar(64) NOT NULL,
+	completed boolean NOT NULL DEFAULT false,
+	status_code bigint,
+	content_type text,
+	response_body bytea,
+	created_at timestamptz,
+	expires_at timestamptz NOT NULL
+);
+ALTER TABLE idempotency_keys
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_tenant_id ON idempotency_keys (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	url text NOT NULL,
+	secret text NOT NULL,
+	event_types jsonb NOT NULL DEFAULT '[]',
+	active boolean NOT NULL DEFAULT true,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+ALTER TABLE webhooks
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant_id ON webhooks (tenant_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	webhook_id bigint NOT NULL,
+	event_id varchar(32) NOT NULL,
+	event_type text NOT NULL,
+	payload jsonb NOT NULL,
+	status text NOT NULL DEFAULT 'pending',
+	attempts bigint NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL,
+	last_attempt_at timestamptz,
+	response_status bigint,
+	last_error text,
+	created_at timestamptz,
+	updated_at timestamptz,
+	CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+ALTER TABLE webhook_deliveries
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS subscription_events (
+	sequence bigserial PRIMARY KEY,
+	event_id varchar(32) NOT NULL,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	type text NOT NULL,
+	user_id uuid NOT NULL,
+	payload jsonb NOT NULL,
+	created_at timestamptz
+);
+ALTER TABLE subscription_events
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_events_event_id ON subscription_events (event_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_events_tenant_id ON subscription_events (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_events_user_id ON subscription_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_events_created_at ON subscription_events (created_at);
+
+CREATE TABLE IF NOT EXISTS outbox (
+	id bigserial PRIMARY KEY,
+	event_id varchar(32) NOT NULL,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	event_type text NOT NULL,
+	payload jsonb NOT NULL,
+	created_at timestamptz NOT NULL,
+	published_at timestamptz
+);
+ALTER TABLE outbox
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_event_id ON outbox (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_tenant_id ON outbox (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox (published_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	name text NOT NULL,
+	prefix varchar(16) NOT NULL,
+	key_hash varchar(64) NOT NULL,
+	subject text NOT NULL,
+	roles jsonb NOT NULL DEFAULT '[]',
+	expires_at timestamptz,
+	revoked_at timestamptz,
+	last_used_at timestamptz,
+	created_at timestamptz
+);
+ALTER TABLE api_keys
+	ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	key text PRIMARY KEY,
+	tokens decimal NOT NULL,
+	allowed boolean NOT NULL,
+	updated_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id bigserial PRIMARY KEY,
+	tenant_id varchar(64) NOT NULL DEFAULT 'default',
+	subscription_id bigint NOT NULL,
+	user_id uuid NOT NULL,
+	action varchar(16) NOT NULL,
+	actor text,
+	request_id text,
+	changes jsonb NOT NULL,
+	created_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_tenant_id ON audit_entries (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_subscription_id ON audit_entries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_user_id ON audit_entries (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_request_id ON audit_entries (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
+
+-- Журнал аудита только пополняется. Изменение разрешено только при обезличивании данных пользователя,
+-- которое включает параметр app.audit_erasure
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND current_setting('app.audit_erasure', true) = 'on' THEN
+		RETURN NEW;
+	END IF;
+	RAISE EXCEPTION 'журнал аудита только пополняется';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+	FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
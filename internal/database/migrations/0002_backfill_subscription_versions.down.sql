@@ -0,0 +1 @@
+-- Заполненные версии неотличимы от записанных сервисом и остаются в истории
@@ -0,0 +1,17 @@
+-- Версии подписок, созданных до появления истории версий: действующая с момента создания
+-- и, для подписок в корзине, удаленная с момента удаления
+
+INSERT INTO subscription_versions (subscription_id, tenant_id, service_name, price, user_id, start_date, end_date,
+	notes, tags, created_at, updated_at, valid_from, valid_to)
+SELECT id, tenant_id, service_name, price, user_id, start_date, end_date,
+	notes, tags, created_at, updated_at, created_at, deleted_at
+FROM subscriptions s
+WHERE NOT EXISTS (SELECT 1 FROM subscription_versions v WHERE v.subscription_id = s.id);
+
+INSERT INTO subscription_versions (subscription_id, tenant_id, service_name, price, user_id, start_date, end_date,
+	notes, tags, created_at, updated_at, deleted_at, valid_from)
+SELECT id, tenant_id, service_name, price, user_id, start_date, end_date,
+	notes, tags, created_at, updated_at, deleted_at, deleted_at
+FROM subscriptions s
+WHERE deleted_at IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM subscription_versions v WHERE v.subscription_id = s.id AND v.valid_to IS NULL);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_tenant_user_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_service_name;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_start_date;
@@ -0,0 +1,5 @@
+-- Индексы для фильтров списка, стоимости и выгрузки. Все запросы ограничены организацией,
+-- поэтому tenant_id стоит первым
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_user_id ON subscriptions (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_service_name ON subscriptions (tenant_id, service_name);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_start_date ON subscriptions (tenant_id, start_date);
@@ -0,0 +1,7 @@
+-- Одинаковые ключи разных организаций не помещаются в прежний первичный ключ; остается ключ
+-- организации с наименьшим ID. Ключи хранятся только IDEMPOTENCY_TTL, поэтому потеря повтора допустима
+DELETE FROM idempotency_keys a USING idempotency_keys b WHERE a.key = b.key AND a.tenant_id > b.tenant_id;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_tenant_id ON idempotency_keys (tenant_id);
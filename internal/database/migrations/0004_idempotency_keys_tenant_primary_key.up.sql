@@ -0,0 +1,5 @@
+-- Ключи идемпотентности уникальны в пределах организации: одинаковый Idempotency-Key
+-- разных организаций не должен конфликтовать
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (tenant_id, key);
+DROP INDEX IF EXISTS idx_idempotency_keys_tenant_id;
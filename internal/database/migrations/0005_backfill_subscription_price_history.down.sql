@@ -0,0 +1 @@
+-- Заполненные начальные цены неотличимы от записанных сервисом и остаются в истории
@@ -0,0 +1,8 @@
+-- Начальные цены подписок, созданных до появления истории цен: одна запись без предыдущей цены
+-- на момент создания подписки, как записывает сервис при создании. Цена до первого изменения
+-- неизвестна, поэтому записывается текущая
+
+INSERT INTO subscription_price_history (tenant_id, subscription_id, old_price, price, changed_at)
+SELECT tenant_id, id, NULL, price, created_at
+FROM subscriptions s
+WHERE NOT EXISTS (SELECT 1 FROM subscription_price_history h WHERE h.subscription_id = s.id);
@@ -1,27 +0,0 @@
-package database
-
-import (
-	"fmt"
-	"log"
-
-	"effective-mobile-subscription/config"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-// ConnectDB устанавливает соединение с базой данных PostgreSQL с использованием GORM
-func ConnectDB(cfg *config.Config) *gorm.DB {
-	// Создать строку подключения к PostgreSQL
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
-
-	// Подключиться к базе данных
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal("Не удалось подключиться к базе данных:", err)
-	}
-
-	log.Println("Успешное подключение к базе данных")
-	return db
-}
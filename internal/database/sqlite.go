@@ -0,0 +1,40 @@
+package database
+
+import (
+	"errors"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMigrationsPostgresOnly возвращается командами миграций для SQLite: версионированные
+// миграции написаны для PostgreSQL, а схема SQLite создается по моделям
+var ErrMigrationsPostgresOnly = errors.New("версионированные миграции есть только для PostgreSQL, схема SQLite создается по моделям командой migrate up или при запуске")
+
+// sqliteModels модели, таблицы которых создаются в SQLite
+var sqliteModels = []any{
+	&models.Tenant{}, &models.Subscription{}, &models.PriceChange{}, &models.SubscriptionVersion{},
+	&models.SubscriptionReminder{}, &models.IdempotencyKey{}, &models.Webhook{}, &models.WebhookDelivery{},
+	&models.StoredEvent{}, &models.OutboxMessage{}, &models.APIKey{}, &models.RateLimitBucket{}, &models.AuditEntry{},
+}
+
+// isPostgres сообщает, что база данных - PostgreSQL
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// migrateSQLite создает и дополняет таблицы SQLite по моделям и создает организацию по умолчанию.
+// Индексы поиска и триггер, запрещающий изменять журнал аудита, есть только в PostgreSQL
+func migrateSQLite(db *gorm.DB) error {
+	if err := db.AutoMigrate(sqliteModels...); err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Tenant{
+		ID:       models.DefaultTenantID,
+		Name:     "Организация по умолчанию",
+		Currency: models.DefaultTenantCurrency,
+		Timezone: models.DefaultTenantTimezone,
+	}).Error
+}
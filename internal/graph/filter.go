@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+)
+
+// toModelFilter преобразует фильтр GraphQL в фильтр подписок.
+// Начало диапазона - первый день месяца, конец - последний, если дата задана месяцем
+func toModelFilter(filter *SubscriptionFilter) (models.SubscriptionFilter, error) {
+	var result models.SubscriptionFilter
+	if filter == nil {
+		return result, nil
+	}
+
+	result.UserIDs = filter.UserIds
+	result.ServiceNames = filter.ServiceNames
+	result.ServiceNameContains = strings.TrimSpace(value(filter.ServiceNameContains))
+	result.PriceMin = filter.PriceMin
+	result.PriceMax = filter.PriceMax
+	result.OpenEnded = filter.OpenEnded
+
+	dates := []struct {
+		name       string
+		value      *string
+		endOfMonth bool
+		target     **time.Time
+	}{
+		{"startFrom", filter.StartFrom, false, &result.StartFrom},
+		{"startTo", filter.StartTo, true, &result.StartTo},
+		{"endFrom", filter.EndFrom, false, &result.EndFrom},
+		{"endTo", filter.EndTo, true, &result.EndTo},
+		{"activeAt", filter.ActiveAt, false, &result.ActiveAt},
+	}
+	for _, date := range dates {
+		parsed, err := models.ParseFilterDate(value(date.value), date.endOfMonth)
+		if err != nil {
+			return result, &services.ValidationError{Field: "filter." + date.name, Message: err.Error()}
+		}
+		*date.target = parsed
+	}
+
+	return result, nil
+}
+
+// parseSort разбирает необязательную сортировку
+func parseSort(sort *string) ([]models.SortField, error) {
+	fields, err := models.ParseSort(value(sort))
+	if err != nil {
+		return nil, &services.ValidationError{Field: "sort", Message: err.Error()}
+	}
+	return fields, nil
+}
+
+// argsKey формирует ключ аргументов поля, по которому загрузчики разделяются на пакеты
+func argsKey(args ...any) string {
+	data, _ := json.Marshal(args)
+	return string(data)
+}
+
+// value возвращает значение необязательного аргумента или пустую строку
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,183 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/graph"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+	"effective-mobile-subscription/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	userA = "11111111-1111-1111-1111-111111111111"
+	userB = "22222222-2222-2222-2222-222222222222"
+)
+
+// newHandler создает обработчик GraphQL над новой базой SQLite в памяти с подписками subscriptions
+func newHandler(t *testing.T, maxComplexity int, subscriptions ...*models.Subscription) http.Handler {
+	t.Helper()
+	db, err := database.Open(&config.Config{DBDriver: database.DriverMemory}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("открытие базы данных: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(db))
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+	for _, subscription := range subscriptions {
+		if err := service.CreateSubscription(ctx, subscription); err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+
+	handler := graph.NewHandler(service, 10, maxComplexity, utils.NewLogger())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), &models.Tenant{ID: models.DefaultTenantID})))
+	})
+}
+
+// query выполняет запрос GraphQL и разбирает ответ в data
+func query(t *testing.T, handler http.Handler, q string, variables map[string]any, data any) []string {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": q, "variables": variables})
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("разбор ответа %q: %v", w.Body.String(), err)
+	}
+	var messages []string
+	for _, e := range response.Errors {
+		messages = append(messages, e.Message)
+	}
+	if len(messages) == 0 && data != nil {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatalf("разбор данных: %v", err)
+		}
+	}
+	return messages
+}
+
+type page struct {
+	Nodes []struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"nodes"`
+	NextCursor *string `json:"nextCursor"`
+	TotalCount int     `json:"totalCount"`
+}
+
+func names(p page) []string {
+	result := make([]string, len(p.Nodes))
+	for i, node := range p.Nodes {
+		result[i] = node.ServiceName
+	}
+	return result
+}
+
+func TestNestedSubscriptionsPagination(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := newHandler(t, 1000,
+		&models.Subscription{ServiceName: "Netflix", Price: 990, UserID: userA, StartDate: start},
+		&models.Subscription{ServiceName: "Spotify", Price: 299, UserID: userA, StartDate: start},
+		&models.Subscription{ServiceName: "YouTube", Price: 399, UserID: userA, StartDate: start},
+		&models.Subscription{ServiceName: "Netflix", Price: 500, UserID: userB, StartDate: start},
+	)
+
+	// Первые страницы пользователей загружаются одним пакетом
+	var users struct {
+		Users []struct {
+			ID            string `json:"id"`
+			Subscriptions page   `json:"subscriptions"`
+		} `json:"users"`
+	}
+	const usersQuery = `query($ids: [ID!]!) { users(ids: $ids) { id subscriptions(sort: "price", first: 2) { nodes { serviceName } nextCursor } } }`
+	if errs := query(t, handler, usersQuery, map[string]any{"ids": []string{userA, userB}}, &users); errs != nil {
+		t.Fatalf("users: %v", errs)
+	}
+	if len(users.Users) != 2 {
+		t.Fatalf("users вернул %+v", users)
+	}
+	a, b := users.Users[0].Subscriptions, users.Users[1].Subscriptions
+	if users.Users[0].ID != userA {
+		a, b = b, a
+	}
+	if got := names(a); strings.Join(got, ",") != "Spotify,YouTube" || a.NextCursor == nil {
+		t.Fatalf("первая страница пользователя A: %v, курсор %v", got, a.NextCursor)
+	}
+	if got := names(b); strings.Join(got, ",") != "Netflix" || b.NextCursor != nil {
+		t.Fatalf("страница пользователя B: %v, курсор %v", got, b.NextCursor)
+	}
+
+	// Следующая страница запрашивается тем же курсором
+	var user struct {
+		User struct {
+			Subscriptions page `json:"subscriptions"`
+		} `json:"user"`
+	}
+	const userQuery = `query($id: ID!, $after: String) { user(id: $id) { subscriptions(sort: "price", first: 2, after: $after) { nodes { serviceName } nextCursor totalCount } } }`
+	if errs := query(t, handler, userQuery, map[string]any{"id": userA, "after": *a.NextCursor}, &user); errs != nil {
+		t.Fatalf("user: %v", errs)
+	}
+	next := user.User.Subscriptions
+	if got := names(next); strings.Join(got, ",") != "Netflix" || next.NextCursor != nil || next.TotalCount != 3 {
+		t.Fatalf("вторая страница пользователя A: %v, курсор %v, всего %d", got, next.NextCursor, next.TotalCount)
+	}
+
+	var service struct {
+		Service struct {
+			Subscriptions page `json:"subscriptions"`
+		} `json:"service"`
+	}
+	const serviceQuery = `{ service(name: "Netflix") { subscriptions(sort: "-price", first: 1) { nodes { serviceName } nextCursor } } }`
+	if errs := query(t, handler, serviceQuery, nil, &service); errs != nil {
+		t.Fatalf("service: %v", errs)
+	}
+	if s := service.Service.Subscriptions; len(s.Nodes) != 1 || s.NextCursor == nil {
+		t.Fatalf("страница сервиса: %+v", s)
+	}
+}
+
+// Сложность вложенного списка растет с размером страницы first
+func TestNestedSubscriptionsComplexity(t *testing.T) {
+	handler := newHandler(t, 100)
+	const q = `query($first: Int) { user(id: "` + userA + `") { subscriptions(first: $first) { nodes { id serviceName price } } } }`
+
+	if errs := query(t, handler, q, map[string]any{"first": 5}, nil); errs != nil {
+		t.Fatalf("first 5: %v", errs)
+	}
+	if errs := query(t, handler, q, map[string]any{"first": 100}, nil); len(errs) == 0 {
+		t.Fatal("запрос first 100 не отклонен ограничением сложности")
+	}
+	if errs := query(t, handler, q, map[string]any{"first": 101}, nil); len(errs) == 0 {
+		t.Fatal("first больше максимального размера страницы не отклонен")
+	}
+}
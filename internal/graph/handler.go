@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/utils"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// NewHandler создает обработчик GraphQL с ограничениями глубины и сложности запросов
+func NewHandler(service *services.SubscriptionService, maxDepth, maxComplexity int, logger *utils.Logger) http.Handler {
+	config := Config{Resolvers: &Resolver{service: service}}
+	setComplexity(&config.Complexity)
+
+	server := handler.New(NewExecutableSchema(config))
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	server.Use(extension.Introspection{})
+	server.Use(depthLimit{max: maxDepth})
+	server.Use(extension.FixedComplexityLimit(maxComplexity))
+
+	server.SetErrorPresenter(errorPresenter(logger))
+	server.SetRecoverFunc(func(ctx context.Context, err any) error {
+		logger.Error("Произошла паника", "ошибка", err, "путь", graphql.GetPath(ctx).String())
+		return errors.New("Внутренняя ошибка сервера")
+	})
+
+	return LoadersMiddleware(service)(server)
+}
+
+// errorPresenter возвращает клиенту ошибки валидации, а внутренние ошибки логирует и заменяет общим сообщением
+func errorPresenter(logger *utils.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		presented := graphql.DefaultErrorPresenter(ctx, err)
+
+		// Ошибки разбора и валидации запроса не содержат исходной ошибки
+		if presented.Err == nil {
+			return presented
+		}
+
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			presented.Message = fmt.Sprintf("Неверные параметры запроса: %v", validationErr)
+			errcode.Set(presented, errBadUserInput)
+			return presented
+		}
+
+		logger.Error("Ошибка выполнения запроса GraphQL", "ошибка", err, "путь", presented.Path.String())
+		presented.Message = "Внутренняя ошибка сервера"
+		return presented
+	}
+}
@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// listComplexity предполагаемое количество элементов в списках без ограничения размера
+	listComplexity = 20
+
+	// errDepthLimitExceeded код ошибки превышения глубины запроса
+	errDepthLimitExceeded = "DEPTH_LIMIT_EXCEEDED"
+
+	// errBadUserInput код ошибки неверных аргументов запроса
+	errBadUserInput = "BAD_USER_INPUT"
+)
+
+// setComplexity задает стоимость полей-списков: стоимость элементов умножается на их количество
+func setComplexity(complexity *ComplexityRoot) {
+	complexity.Query.Subscriptions = func(childComplexity int, filter *SubscriptionFilter, sort *string, first *int, after *string) int {
+		return pageComplexity(childComplexity, first)
+	}
+	complexity.Query.Users = func(childComplexity int, ids []string) int {
+		return 1 + childComplexity*len(ids)
+	}
+	complexity.Query.Services = func(childComplexity int, names []string) int {
+		return 1 + childComplexity*len(names)
+	}
+	complexity.User.Subscriptions = func(childComplexity int, sort *string, first *int, after *string) int {
+		return pageComplexity(childComplexity, first)
+	}
+	complexity.Service.Subscriptions = func(childComplexity int, sort *string, first *int, after *string) int {
+		return pageComplexity(childComplexity, first)
+	}
+	complexity.Subscription.PriceHistory = func(childComplexity int) int {
+		return 1 + childComplexity*listComplexity
+	}
+}
+
+// pageComplexity возвращает стоимость страницы подписок: стоимость элементов умножается на размер страницы first
+func pageComplexity(childComplexity int, first *int) int {
+	count := listComplexity
+	if first != nil && *first > 0 {
+		count = *first
+	}
+	return 1 + childComplexity*count
+}
+
+// depthLimit расширение, отклоняющее запросы с вложенностью полей больше max.
+// Поля интроспекции (__schema, __type) не учитываются
+type depthLimit struct {
+	max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+// ExtensionName возвращает имя расширения
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate проверяет схему при подключении расширения
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext проверяет глубину операции перед выполнением
+func (d depthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(rc.Operation.SelectionSet); depth > d.max {
+		err := gqlerror.Errorf("глубина запроса %d превышает допустимую %d", depth, d.max)
+		errcode.Set(err, errDepthLimitExceeded)
+		return err
+	}
+	return nil
+}
+
+// selectionDepth вычисляет глубину вложенности полей с учетом фрагментов
+func selectionDepth(set ast.SelectionSet) int {
+	maxDepth := 0
+	for _, selection := range set {
+		depth := 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(selection.SelectionSet)
+		case *ast.InlineFragment:
+			depth = selectionDepth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			// Циклы фрагментов отклоняются при валидации запроса
+			if selection.Definition != nil {
+				depth = selectionDepth(selection.Definition.SelectionSet)
+			}
+		}
+		maxDepth = max(maxDepth, depth)
+	}
+	return maxDepth
+}
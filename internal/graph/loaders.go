@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loadersKey ключ контекста для загрузчиков запроса
+type loadersKey struct{}
+
+// Loaders загрузчики связанных данных одного запроса. Ключи, запрошенные резолверами
+// одновременно, собираются в пакет и загружаются одним запросом к базе данных
+type Loaders struct {
+	users                *dataloader.Loader[string, *models.UserSummary]
+	services             *dataloader.Loader[string, *models.ServiceSummary]
+	priceHistory         *dataloader.Loader[uint, []*models.PriceChange]
+	userSubscriptions    loaderSet[string, []*models.Subscription]
+	serviceSubscriptions loaderSet[string, []*models.Subscription]
+	userCosts            loaderSet[string, int64]
+	serviceCosts         loaderSet[string, int64]
+}
+
+// NewLoaders создает загрузчики для одного запроса
+func NewLoaders(service *services.SubscriptionService) *Loaders {
+	return &Loaders{
+		users: dataloader.NewBatchedLoader(batchFromMap(func(ctx context.Context, ids []string) (map[string]*models.UserSummary, error) {
+			summaries, err := service.UserSummaries(ctx, ids)
+			return pointers(summaries), err
+		})),
+		services: dataloader.NewBatchedLoader(batchFromMap(func(ctx context.Context, names []string) (map[string]*models.ServiceSummary, error) {
+			summaries, err := service.ServiceSummaries(ctx, names)
+			return pointers(summaries), err
+		})),
+		priceHistory: dataloader.NewBatchedLoader(batchFromMap(func(ctx context.Context, ids []uint) (map[uint][]*models.PriceChange, error) {
+			history, err := service.PriceHistory(ctx, ids)
+			result := make(map[uint][]*models.PriceChange, len(history))
+			for id, changes := range history {
+				result[id] = slicePointers(changes)
+			}
+			return result, err
+		})),
+	}
+}
+
+// LoadersMiddleware создает загрузчики для каждого запроса и сохраняет их в контексте
+func LoadersMiddleware(service *services.SubscriptionService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), loadersKey{}, NewLoaders(service))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// loadersFor возвращает загрузчики запроса из контекста
+func loadersFor(ctx context.Context) *Loaders {
+	return ctx.Value(loadersKey{}).(*Loaders)
+}
+
+// loaderSet загрузчики одного вида для разных аргументов поля.
+// Ключи с одинаковыми аргументами (например, сортировкой) попадают в один пакет
+type loaderSet[K comparable, V any] struct {
+	mu      sync.Mutex
+	loaders map[string]*dataloader.Loader[K, V]
+}
+
+// get возвращает загрузчик для аргументов args, создавая его при первом обращении
+func (s *loaderSet[K, V]) get(args string, batch dataloader.BatchFunc[K, V]) *dataloader.Loader[K, V] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaders == nil {
+		s.loaders = make(map[string]*dataloader.Loader[K, V])
+	}
+	loader, ok := s.loaders[args]
+	if !ok {
+		loader = dataloader.NewBatchedLoader(batch)
+		s.loaders[args] = loader
+	}
+	return loader
+}
+
+// batchFromMap создает пакетную функцию из функции, загружающей значения по ключам в map.
+// Для отсутствующих ключей возвращается нулевое значение
+func batchFromMap[K comparable, V any](fetch func(context.Context, []K) (map[K]V, error)) dataloader.BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) []*dataloader.Result[V] {
+		values, err := fetch(ctx, keys)
+
+		results := make([]*dataloader.Result[V], len(keys))
+		for i, key := range keys {
+			if err != nil {
+				results[i] = &dataloader.Result[V]{Error: err}
+				continue
+			}
+			results[i] = &dataloader.Result[V]{Data: values[key]}
+		}
+		return results
+	}
+}
+
+// pointers преобразует значения map в указатели
+func pointers[K comparable, V any](values map[K]V) map[K]*V {
+	result := make(map[K]*V, len(values))
+	for key := range values {
+		value := values[key]
+		result[key] = &value
+	}
+	return result
+}
+
+// slicePointers возвращает указатели на элементы среза
+func slicePointers[V any](values []V) []*V {
+	result := make([]*V, len(values))
+	for i := range values {
+		result[i] = &values[i]
+	}
+	return result
+}
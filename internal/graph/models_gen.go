@@ -0,0 +1,37 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graph
+
+import (
+	"effective-mobile-subscription/internal/models"
+)
+
+type Query struct {
+}
+
+// A page of subscriptions
+type SubscriptionConnection struct {
+	Nodes []*models.Subscription `json:"nodes"`
+	// Pass as after to get the next page; null on the last page
+	NextCursor *string `json:"nextCursor,omitempty"`
+	TotalCount int     `json:"totalCount"`
+}
+
+// Filter for subscriptions. Empty fields are ignored.
+// Dates are in MM-YYYY or YYYY-MM-DD format.
+type SubscriptionFilter struct {
+	UserIds      []string `json:"userIds,omitempty"`
+	ServiceNames []string `json:"serviceNames,omitempty"`
+	// Case-insensitive part of the service name
+	ServiceNameContains *string `json:"serviceNameContains,omitempty"`
+	PriceMin            *int    `json:"priceMin,omitempty"`
+	PriceMax            *int    `json:"priceMax,omitempty"`
+	StartFrom           *string `json:"startFrom,omitempty"`
+	StartTo             *string `json:"startTo,omitempty"`
+	EndFrom             *string `json:"endFrom,omitempty"`
+	EndTo               *string `json:"endTo,omitempty"`
+	// Subscriptions active at least one day in this month
+	ActiveAt *string `json:"activeAt,omitempty"`
+	// Only subscriptions without (true) or with (false) an end date
+	OpenEnded *bool `json:"openEnded,omitempty"`
+}
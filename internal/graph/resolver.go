@@ -0,0 +1,118 @@
+package graph
+
+//go:generate go tool gqlgen generate
+
+import (
+	"context"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"slices"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+const (
+	// defaultPageSize размер страницы списка подписок по умолчанию
+	defaultPageSize = 10
+
+	// maxPageSize максимальный размер страницы списка подписок
+	maxPageSize = services.MaxListLimit
+)
+
+// Resolver корневой резолвер GraphQL. Связанные данные загружаются через загрузчики из контекста запроса
+type Resolver struct {
+	service *services.SubscriptionService
+}
+
+// subscriptionPage получает страницу подписок пользователя или сервиса key для поля subscriptions.
+// Первые страницы всех пользователей или сервисов запроса загружаются одним пакетом через loaders.
+// Следующие страницы и общее количество запрашиваются для key отдельно с фильтром filter,
+// так же как Query.subscriptions, поэтому курсор страницы годится для обоих полей
+func (r *Resolver) subscriptionPage(ctx context.Context, loaders *loaderSet[string, []*models.Subscription], key string, filter models.SubscriptionFilter, sort *string, first *int, after *string, fetch func(context.Context, []string, []models.SortField, int) (map[string][]models.Subscription, error)) (*SubscriptionConnection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	sortFields, err := parseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	includeTotal := slices.Contains(graphql.CollectAllFields(ctx), "totalCount")
+	if value(after) != "" || includeTotal {
+		result, err := r.service.ListSubscriptions(ctx, services.ListParams{
+			Page:         1,
+			Limit:        limit,
+			Cursor:       value(after),
+			IncludeTotal: includeTotal,
+			Filter:       filter,
+			Sort:         sortFields,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return newConnection(result), nil
+	}
+
+	// Загрузить на одну подписку больше, чтобы узнать, есть ли следующая страница
+	loader := loaders.get(argsKey(sortFields, limit), groupedSubscriptions(func(ctx context.Context, keys []string) (map[string][]models.Subscription, error) {
+		return fetch(ctx, keys, sortFields, limit+1)
+	}))
+	subscriptions, err := loader.Load(ctx, key)()
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &SubscriptionConnection{Nodes: subscriptions}
+	if len(subscriptions) > limit {
+		connection.Nodes = subscriptions[:limit]
+		cursor := repository.CursorAfter(subscriptions[limit-1], sortFields).Encode()
+		connection.NextCursor = &cursor
+	}
+	return connection, nil
+}
+
+// newConnection преобразует страницу списка подписок в SubscriptionConnection
+func newConnection(result *services.ListResult) *SubscriptionConnection {
+	connection := &SubscriptionConnection{Nodes: slicePointers(result.Subscriptions)}
+	if result.NextCursor != "" {
+		connection.NextCursor = &result.NextCursor
+	}
+	if result.Total != nil {
+		connection.TotalCount = int(*result.Total)
+	}
+	return connection
+}
+
+// pageSize проверяет размер страницы first; по умолчанию возвращается defaultPageSize
+func pageSize(first *int) (int, error) {
+	if first == nil {
+		return defaultPageSize, nil
+	}
+	if *first <= 0 || *first > maxPageSize {
+		return 0, &services.ValidationError{Field: "first", Message: "допустимо от 1 до " + strconv.Itoa(maxPageSize)}
+	}
+	return *first, nil
+}
+
+// groupedSubscriptions создает пакетную функцию для подписок, сгруппированных по ключу
+func groupedSubscriptions(fetch func(context.Context, []string) (map[string][]models.Subscription, error)) dataloader.BatchFunc[string, []*models.Subscription] {
+	return batchFromMap(func(ctx context.Context, keys []string) (map[string][]*models.Subscription, error) {
+		grouped, err := fetch(ctx, keys)
+		result := make(map[string][]*models.Subscription, len(grouped))
+		for key, subscriptions := range grouped {
+			result[key] = slicePointers(subscriptions)
+		}
+
+		// У ключей без подписок пустой список вместо null
+		for _, key := range keys {
+			if result[key] == nil {
+				result[key] = []*models.Subscription{}
+			}
+		}
+		return result, err
+	})
+}
@@ -0,0 +1,183 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"gorm.io/gorm"
+)
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id string) (*models.Subscription, error) {
+	subscriptionID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, &services.ValidationError{Field: "id", Message: "ожидается целое число"}
+	}
+
+	subscription, err := r.service.GetSubscription(ctx, uint(subscriptionID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return subscription, err
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *queryResolver) Subscriptions(ctx context.Context, filter *SubscriptionFilter, sort *string, first *int, after *string) (*SubscriptionConnection, error) {
+	params := services.ListParams{
+		Page:   1,
+		Cursor: value(after),
+		// Общее количество подсчитывается, только если поле запрошено
+		IncludeTotal: slices.Contains(graphql.CollectAllFields(ctx), "totalCount"),
+	}
+
+	// Разобрать размер страницы, фильтр и сортировку
+	var err error
+	if params.Limit, err = pageSize(first); err != nil {
+		return nil, err
+	}
+	if params.Filter, err = toModelFilter(filter); err != nil {
+		return nil, err
+	}
+	if params.Sort, err = parseSort(sort); err != nil {
+		return nil, err
+	}
+
+	// Получить страницу подписок
+	result, err := r.service.ListSubscriptions(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(result), nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*models.UserSummary, error) {
+	return loadersFor(ctx).users.Load(ctx, id)()
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, ids []string) ([]*models.UserSummary, error) {
+	users, errs := loadersFor(ctx).users.LoadMany(ctx, ids)()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return slices.DeleteFunc(users, func(user *models.UserSummary) bool { return user == nil }), nil
+}
+
+// Service is the resolver for the service field.
+func (r *queryResolver) Service(ctx context.Context, name string) (*models.ServiceSummary, error) {
+	return loadersFor(ctx).services.Load(ctx, name)()
+}
+
+// Services is the resolver for the services field.
+func (r *queryResolver) Services(ctx context.Context, names []string) ([]*models.ServiceSummary, error) {
+	summaries, errs := loadersFor(ctx).services.LoadMany(ctx, names)()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return slices.DeleteFunc(summaries, func(summary *models.ServiceSummary) bool { return summary == nil }), nil
+}
+
+// TotalCost is the resolver for the totalCost field.
+func (r *queryResolver) TotalCost(ctx context.Context, filter *SubscriptionFilter, from *string, to *string) (int, error) {
+	modelFilter, err := toModelFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return r.service.CalculateTotalCost(ctx, modelFilter, value(from), value(to))
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *serviceResolver) Subscriptions(ctx context.Context, obj *models.ServiceSummary, sort *string, first *int, after *string) (*SubscriptionConnection, error) {
+	filter := models.SubscriptionFilter{ServiceNames: []string{obj.Name}}
+	return r.subscriptionPage(ctx, &loadersFor(ctx).serviceSubscriptions, obj.Name, filter, sort, first, after, r.service.SubscriptionsByServices)
+}
+
+// TotalCost is the resolver for the totalCost field.
+func (r *serviceResolver) TotalCost(ctx context.Context, obj *models.ServiceSummary, filter *SubscriptionFilter, from *string, to *string) (int, error) {
+	modelFilter, err := toModelFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	loader := loadersFor(ctx).serviceCosts.get(argsKey(modelFilter, from, to), batchFromMap(func(ctx context.Context, names []string) (map[string]int64, error) {
+		return r.service.TotalCostByServices(ctx, names, modelFilter, value(from), value(to))
+	}))
+	totalCost, err := loader.Load(ctx, obj.Name)()
+	return int(totalCost), err
+}
+
+// User is the resolver for the user field.
+func (r *subscriptionResolver) User(ctx context.Context, obj *models.Subscription) (*models.UserSummary, error) {
+	user, err := loadersFor(ctx).users.Load(ctx, obj.UserID)()
+	if user == nil && err == nil {
+		// Подписка могла быть удалена между запросами
+		user = &models.UserSummary{ID: obj.UserID}
+	}
+	return user, err
+}
+
+// Service is the resolver for the service field.
+func (r *subscriptionResolver) Service(ctx context.Context, obj *models.Subscription) (*models.ServiceSummary, error) {
+	service, err := loadersFor(ctx).services.Load(ctx, obj.ServiceName)()
+	if service == nil && err == nil {
+		// Подписка могла быть удалена между запросами
+		service = &models.ServiceSummary{Name: obj.ServiceName}
+	}
+	return service, err
+}
+
+// PriceHistory is the resolver for the priceHistory field.
+func (r *subscriptionResolver) PriceHistory(ctx context.Context, obj *models.Subscription) ([]*models.PriceChange, error) {
+	history, err := loadersFor(ctx).priceHistory.Load(ctx, obj.ID)()
+	if history == nil && err == nil {
+		history = []*models.PriceChange{}
+	}
+	return history, err
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *userResolver) Subscriptions(ctx context.Context, obj *models.UserSummary, sort *string, first *int, after *string) (*SubscriptionConnection, error) {
+	filter := models.SubscriptionFilter{UserIDs: []string{obj.ID}}
+	return r.subscriptionPage(ctx, &loadersFor(ctx).userSubscriptions, obj.ID, filter, sort, first, after, r.service.SubscriptionsByUsers)
+}
+
+// TotalCost is the resolver for the totalCost field.
+func (r *userResolver) TotalCost(ctx context.Context, obj *models.UserSummary, filter *SubscriptionFilter, from *string, to *string) (int, error) {
+	modelFilter, err := toModelFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	loader := loadersFor(ctx).userCosts.get(argsKey(modelFilter, from, to), batchFromMap(func(ctx context.Context, ids []string) (map[string]int64, error) {
+		return r.service.TotalCostByUsers(ctx, ids, modelFilter, value(from), value(to))
+	}))
+	totalCost, err := loader.Load(ctx, obj.ID)()
+	return int(totalCost), err
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Service returns ServiceResolver implementation.
+func (r *Resolver) Service() ServiceResolver { return &serviceResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type serviceResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
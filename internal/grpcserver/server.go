@@ -0,0 +1,251 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/ratelimit"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+	"effective-mobile-subscription/pkg/middleware"
+	subscriptionv1 "effective-mobile-subscription/pkg/pb/subscription/v1"
+	"effective-mobile-subscription/pkg/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer создает gRPC сервер с сервисом подписок, проверкой состояния и reflection.
+// Если authenticator задан, вызовы сервиса подписок требуют учетных данных в метаданных
+// authorization ("Bearer <ключ API или JWT>") или x-api-key; проверка состояния остается открытой.
+// Организация вызова определяется учетными данными или метаданными x-tenant-id.
+// Если limits задан, частота вызовов ограничивается теми же корзинами, что и в REST API:
+// по IP адресу до аутентификации и по клиенту и классу метода после нее. Если ipHeader задан,
+// адрес берется из этих метаданных прокси
+func NewServer(service *services.SubscriptionService, authenticator *auth.Authenticator, resolver *tenant.Resolver, limits ratelimit.Store, policy ratelimit.Policy, ipHeader string, logger *utils.Logger) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{recoveryInterceptor(logger), requestIDInterceptor()}
+	if limits != nil && policy.IP.Requests > 0 {
+		interceptors = append(interceptors, ipRateLimitInterceptor(limits, policy.IP, ipHeader, logger))
+	}
+	if authenticator != nil {
+		interceptors = append(interceptors, authInterceptor(authenticator, logger))
+	}
+	if limits != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(limits, policy, ipHeader, logger))
+	}
+	interceptors = append(interceptors, tenantInterceptor(resolver, logger))
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+
+	// Зарегистрировать сервис подписок
+	subscriptionv1.RegisterSubscriptionServiceServer(server, NewSubscriptionServer(service))
+
+	// Проверка состояния: общий статус сервера и статус сервиса подписок
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(subscriptionv1.SubscriptionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	// Reflection позволяет клиентам вроде grpcurl получать описание сервисов
+	reflection.Register(server)
+
+	return server
+}
+
+// recoveryInterceptor перехватывает паники в обработчиках и возвращает ошибку Internal
+func recoveryInterceptor(logger *utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				// Залогировать панику и трассировку стека
+				logger.Error("Произошла паника",
+					"ошибка", r,
+					"стек", string(debug.Stack()),
+					"метод", info.FullMethod,
+				)
+				err = status.Error(codes.Internal, "Внутренняя ошибка сервера")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// requestIDInterceptor помещает в контекст ID вызова из метаданных x-request-id или создает новый
+// и возвращает его в заголовке ответа
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-request-id"); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		if id == "" {
+			id = middleware.NewRequestID()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+
+		return handler(middleware.WithRequestID(ctx, id), req)
+	}
+}
+
+// authInterceptor аутентифицирует вызовы и помещает участника в контекст
+func authInterceptor(authenticator *auth.Authenticator, logger *utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		// Метаданные gRPC передаются как HTTP/2 заголовки
+		header := http.Header{}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, name := range []string{"authorization", "x-api-key"} {
+				if values := md.Get(name); len(values) > 0 {
+					header.Set(name, values[0])
+				}
+			}
+		}
+
+		principal, err := authenticator.Authenticate(auth.Credential(header))
+		if err != nil {
+			if errors.Is(err, auth.ErrMissingCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+				return nil, status.Error(codes.Unauthenticated, "Требуется аутентификация: "+err.Error())
+			}
+			logger.Error("Не удалось проверить учетные данные", "ошибка", err, "метод", info.FullMethod)
+			return nil, status.Error(codes.Internal, "Не удалось проверить учетные данные")
+		}
+
+		return handler(auth.WithPrincipal(ctx, principal), req)
+	}
+}
+
+// tenantInterceptor определяет организацию вызова и помещает ее в контекст
+func tenantInterceptor(resolver *tenant.Resolver, logger *utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		var requested string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-tenant-id"); len(values) > 0 {
+				requested = values[0]
+			}
+		}
+
+		t, err := resolver.Resolve(ctx, requested)
+		switch {
+		case errors.Is(err, tenant.ErrTenantMismatch):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, tenant.ErrUnknownTenant):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err != nil:
+			logger.Error("Не удалось определить организацию", "ошибка", err, "метод", info.FullMethod)
+			return nil, status.Error(codes.Internal, "Не удалось определить организацию")
+		}
+
+		return handler(tenant.WithTenant(ctx, t), req)
+	}
+}
+
+// ipRateLimitInterceptor ограничивает частоту вызовов с одного IP адреса до аутентификации,
+// чтобы подбор учетных данных тоже ограничивался
+func ipRateLimitInterceptor(limits ratelimit.Store, limit ratelimit.Limit, ipHeader string, logger *utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		key := ratelimit.Key(ratelimit.ClassIP, nil, peerIP(ctx, ipHeader))
+		if err := takeToken(ctx, limits, key, limit, info.FullMethod, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitInterceptor ограничивает частоту вызовов клиента по классу метода: чтение,
+// изменение или тяжелый агрегат. Клиент определяется участником из контекста или IP адресом
+func rateLimitInterceptor(limits ratelimit.Store, policy ratelimit.Policy, ipHeader string, logger *utils.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isHealthCheck(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		principal, _ := auth.PrincipalFrom(ctx)
+		class, limit := classifyMethod(info.FullMethod, policy)
+		key := ratelimit.Key(class, principal, peerIP(ctx, ipHeader))
+		if err := takeToken(ctx, limits, key, limit, info.FullMethod, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// takeToken списывает токен из корзины key и возвращает ошибку ResourceExhausted с метаданными
+// retry-after, если ограничение превышено. Если хранилище недоступно, вызов разрешается
+func takeToken(ctx context.Context, limits ratelimit.Store, key string, limit ratelimit.Limit, method string, logger *utils.Logger) error {
+	result, err := limits.Take(ctx, key, limit)
+	if err != nil {
+		logger.Error("Не удалось проверить ограничение частоты запросов", "ошибка", err, "метод", method)
+		return nil
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	retryAfter := ratelimit.RetryAfterSeconds(result)
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return status.Errorf(codes.ResourceExhausted, "Превышено ограничение частоты запросов, повторите через %d с", retryAfter)
+}
+
+// classifyMethod определяет класс метода сервиса подписок так же, как классы маршрутов REST API
+func classifyMethod(method string, policy ratelimit.Policy) (string, ratelimit.Limit) {
+	name := method[strings.LastIndex(method, "/")+1:]
+	switch {
+	case name == "CalculateTotalCost":
+		return ratelimit.ClassHeavy, policy.Heavy
+	case strings.HasPrefix(name, "Get"), strings.HasPrefix(name, "List"):
+		return ratelimit.ClassRead, policy.Read
+	default:
+		return ratelimit.ClassWrite, policy.Write
+	}
+}
+
+// peerIP возвращает IP адрес клиента из метаданных ipHeader прокси или адреса соединения
+func peerIP(ctx context.Context, ipHeader string) string {
+	if ipHeader != "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(ipHeader); len(values) > 0 && values[0] != "" {
+				first, _, _ := strings.Cut(values[0], ",")
+				return strings.TrimSpace(first)
+			}
+		}
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// isHealthCheck сообщает, что вызов относится к открытой проверке состояния
+func isHealthCheck(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/ratelimit"
+	"effective-mobile-subscription/pkg/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitInterceptors(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	policy := ratelimit.Policy{
+		Read:  ratelimit.Limit{Requests: 5, Window: time.Minute},
+		Write: ratelimit.Limit{Requests: 1, Window: time.Minute},
+		Heavy: ratelimit.Limit{Requests: 1, Window: time.Minute},
+		IP:    ratelimit.Limit{Requests: 3, Window: time.Minute},
+	}
+	logger := utils.NewLogger()
+	chain := []grpc.UnaryServerInterceptor{
+		ipRateLimitInterceptor(store, policy.IP, "", logger),
+		rateLimitInterceptor(store, policy, "", logger),
+	}
+
+	call := func(method, addr string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 5000}})
+		info := &grpc.UnaryServerInfo{FullMethod: "/subscription.v1.SubscriptionService/" + method}
+		handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+		for i := len(chain) - 1; i >= 0; i-- {
+			interceptor, next := chain[i], handler
+			handler = func(ctx context.Context, req any) (any, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		_, err := handler(ctx, nil)
+		return err
+	}
+
+	// Изменения и агрегаты расходуют собственные корзины
+	if err := call("CreateSubscription", "10.0.0.1"); err != nil {
+		t.Fatalf("первое создание: %v", err)
+	}
+	if err := call("CreateSubscription", "10.0.0.1"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("второе создание: %v, ожидался ResourceExhausted", err)
+	}
+	if err := call("CalculateTotalCost", "10.0.0.1"); err != nil {
+		t.Fatalf("стоимость: %v", err)
+	}
+
+	// Ограничение по IP общее для всех методов
+	if err := call("GetSubscription", "10.0.0.1"); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("чтение сверх ограничения по IP: %v, ожидался ResourceExhausted", err)
+	}
+	if err := call("GetSubscription", "10.0.0.2"); err != nil {
+		t.Fatalf("чтение с другого адреса: %v", err)
+	}
+
+	// Проверка состояния не ограничивается
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	for range 5 {
+		_, err := chain[0](context.Background(), nil, info, func(ctx context.Context, req any) (any, error) { return nil, nil })
+		if err != nil {
+			t.Fatalf("проверка состояния: %v", err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+	subscriptionv1 "effective-mobile-subscription/pkg/pb/subscription/v1"
+	"effective-mobile-subscription/pkg/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// defaultPageSize размер страницы списка по умолчанию
+const defaultPageSize = 10
+
+// SubscriptionServer реализует gRPC сервис подписок поверх services.SubscriptionService
+type SubscriptionServer struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+	service *services.SubscriptionService
+}
+
+// NewSubscriptionServer создает gRPC сервис подписок
+func NewSubscriptionServer(service *services.SubscriptionService) *SubscriptionServer {
+	return &SubscriptionServer{service: service}
+}
+
+// CreateSubscription создает новую подписку
+func (s *SubscriptionServer) CreateSubscription(ctx context.Context, req *subscriptionv1.CreateSubscriptionRequest) (*subscriptionv1.CreateSubscriptionResponse, error) {
+	// Разобрать даты
+	startDate, err := utils.ParseMonthYear(req.GetStartDate())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверный формат даты начала, ожидается ММ-ГГГГ")
+	}
+	endDate, err := parseOptionalMonth(req.GetEndDate())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверный формат даты окончания, ожидается ММ-ГГГГ")
+	}
+
+	subscription := &models.Subscription{
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      req.GetUserId(),
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Notes:       req.GetNotes(),
+		Tags:        req.GetTags(),
+	}
+
+	// Сохранить подписку
+	if err := s.service.CreateSubscription(ctx, subscription); err != nil {
+		return nil, toStatus(err, "Не удалось создать подписку")
+	}
+
+	return &subscriptionv1.CreateSubscriptionResponse{Subscription: toProto(subscription)}, nil
+}
+
+// GetSubscription получает подписку по ID
+func (s *SubscriptionServer) GetSubscription(ctx context.Context, req *subscriptionv1.GetSubscriptionRequest) (*subscriptionv1.GetSubscriptionResponse, error) {
+	subscription, err := s.service.GetSubscription(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Не удалось получить подписку")
+	}
+
+	return &subscriptionv1.GetSubscriptionResponse{Subscription: toProto(subscription)}, nil
+}
+
+// UpdateSubscription обновляет заполненные поля подписки
+func (s *SubscriptionServer) UpdateSubscription(ctx context.Context, req *subscriptionv1.UpdateSubscriptionRequest) (*subscriptionv1.UpdateSubscriptionResponse, error) {
+	subscription := &models.Subscription{
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      req.GetUserId(),
+		Notes:       req.GetNotes(),
+		Tags:        req.GetTags(),
+	}
+
+	// Разобрать даты, если предоставлены
+	if req.GetStartDate() != "" {
+		startDate, err := utils.ParseMonthYear(req.GetStartDate())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Неверный формат даты начала, ожидается ММ-ГГГГ")
+		}
+		subscription.StartDate = startDate
+	}
+	endDate, err := parseOptionalMonth(req.GetEndDate())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверный формат даты окончания, ожидается ММ-ГГГГ")
+	}
+	subscription.EndDate = endDate
+
+	// Обновить подписку и вернуть ее новое состояние
+	if err := s.service.UpdateSubscription(ctx, uint(req.GetId()), subscription); err != nil {
+		return nil, toStatus(err, "Не удалось обновить подписку")
+	}
+	updated, err := s.service.GetSubscription(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Не удалось получить обновленную подписку")
+	}
+
+	return &subscriptionv1.UpdateSubscriptionResponse{Subscription: toProto(updated)}, nil
+}
+
+// DeleteSubscription удаляет подписку по ID
+func (s *SubscriptionServer) DeleteSubscription(ctx context.Context, req *subscriptionv1.DeleteSubscriptionRequest) (*subscriptionv1.DeleteSubscriptionResponse, error) {
+	if err := s.service.DeleteSubscription(ctx, uint(req.GetId())); err != nil {
+		return nil, toStatus(err, "Не удалось удалить подписку")
+	}
+
+	return &subscriptionv1.DeleteSubscriptionResponse{}, nil
+}
+
+// ListSubscriptions получает страницу подписок; страницы связываются токеном keyset-пагинации
+func (s *SubscriptionServer) ListSubscriptions(ctx context.Context, req *subscriptionv1.ListSubscriptionsRequest) (*subscriptionv1.ListSubscriptionsResponse, error) {
+	// Разобрать фильтр и сортировку
+	filter, err := fromProtoFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверный фильтр: "+err.Error())
+	}
+	sort, err := models.ParseSort(req.GetSort())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверная сортировка: "+err.Error())
+	}
+
+	params := services.ListParams{
+		Page:         1,
+		Limit:        int(req.GetPageSize()),
+		Cursor:       req.GetPageToken(),
+		IncludeTotal: req.GetIncludeTotal(),
+		Filter:       filter,
+		Sort:         sort,
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultPageSize
+	}
+
+	// Получить список подписок
+	result, err := s.service.ListSubscriptions(ctx, params)
+	if err != nil {
+		return nil, toStatus(err, "Не удалось получить список подписок")
+	}
+
+	response := &subscriptionv1.ListSubscriptionsResponse{
+		Subscriptions: make([]*subscriptionv1.Subscription, len(result.Subscriptions)),
+		NextPageToken: result.NextCursor,
+		Total:         result.Total,
+	}
+	for i := range result.Subscriptions {
+		response.Subscriptions[i] = toProto(&result.Subscriptions[i])
+	}
+
+	return response, nil
+}
+
+// CalculateTotalCost вычисляет общую стоимость подписок, подходящих под фильтр, за период
+func (s *SubscriptionServer) CalculateTotalCost(ctx context.Context, req *subscriptionv1.CalculateTotalCostRequest) (*subscriptionv1.CalculateTotalCostResponse, error) {
+	filter, err := fromProtoFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Неверный фильтр: "+err.Error())
+	}
+
+	totalCost, err := s.service.CalculateTotalCost(ctx, filter, req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, toStatus(err, "Не удалось рассчитать общую стоимость")
+	}
+
+	return &subscriptionv1.CalculateTotalCostResponse{TotalCost: int64(totalCost)}, nil
+}
+
+// toStatus преобразует ошибку сервиса в статус gRPC; внутренние ошибки заменяются сообщением message
+func toStatus(err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "Подписка не найдена")
+	case services.IsValidationError(err):
+		return status.Error(codes.InvalidArgument, "Неверные параметры запроса: "+err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// toProto преобразует модель подписки в сообщение protobuf
+func toProto(subscription *models.Subscription) *subscriptionv1.Subscription {
+	message := &subscriptionv1.Subscription{
+		Id:          uint64(subscription.ID),
+		ServiceName: subscription.ServiceName,
+		Price:       int64(subscription.Price),
+		UserId:      subscription.UserID,
+		StartDate:   timestamppb.New(subscription.StartDate),
+		Notes:       subscription.Notes,
+		Tags:        subscription.Tags,
+		CreatedAt:   timestamppb.New(subscription.CreatedAt),
+		UpdatedAt:   timestamppb.New(subscription.UpdatedAt),
+	}
+	if subscription.EndDate != nil {
+		message.EndDate = timestamppb.New(*subscription.EndDate)
+	}
+	return message
+}
+
+// fromProtoFilter преобразует фильтр protobuf в фильтр подписок.
+// Начало диапазона - первый день месяца, конец - последний, если дата задана месяцем
+func fromProtoFilter(filter *subscriptionv1.SubscriptionFilter) (models.SubscriptionFilter, error) {
+	var result models.SubscriptionFilter
+	if filter == nil {
+		return result, nil
+	}
+
+	result.UserIDs = trimList(filter.GetUserIds())
+	result.ServiceNames = trimList(filter.GetServiceNames())
+	result.ServiceNameContains = strings.TrimSpace(filter.GetServiceNameContains())
+	result.OpenEnded = filter.OpenEnded
+
+	if filter.PriceMin != nil {
+		priceMin := int(filter.GetPriceMin())
+		result.PriceMin = &priceMin
+	}
+	if filter.PriceMax != nil {
+		priceMax := int(filter.GetPriceMax())
+		result.PriceMax = &priceMax
+	}
+
+	var err error
+	if result.StartFrom, err = parseFilterDate("start_from", filter.GetStartFrom(), false); err != nil {
+		return result, err
+	}
+	if result.StartTo, err = parseFilterDate("start_to", filter.GetStartTo(), true); err != nil {
+		return result, err
+	}
+	if result.EndFrom, err = parseFilterDate("end_from", filter.GetEndFrom(), false); err != nil {
+		return result, err
+	}
+	if result.EndTo, err = parseFilterDate("end_to", filter.GetEndTo(), true); err != nil {
+		return result, err
+	}
+	if result.ActiveAt, err = parseFilterDate("active_at", filter.GetActiveAt(), false); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// parseFilterDate разбирает необязательную дату фильтра в формате ММ-ГГГГ или ГГГГ-ММ-ДД
+func parseFilterDate(name, value string, endOfMonth bool) (*time.Time, error) {
+	date, err := models.ParseFilterDate(value, endOfMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return date, nil
+}
+
+// parseOptionalMonth разбирает необязательную дату в формате ММ-ГГГГ; пустая строка дает nil
+func parseOptionalMonth(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := utils.ParseMonthYear(value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// trimList убирает пробелы и пропускает пустые значения
+func trimList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// APIKeyHandler обрабатывает HTTP запросы управления ключами API
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+// NewAPIKeyHandler создает новый обработчик ключей API
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// createAPIKeyRequest тело запроса на создание ключа API
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Subject   string     `json:"subject"`
+	Roles     []string   `json:"roles,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// createAPIKeyResponse созданный ключ API вместе с самим ключом, который возвращается только один раз
+type createAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey создает ключ API
+//
+//	@Summary		Create an API key
+//	@Description	Create an API key of the caller's tenant for a subject (the user UUID for regular users) with optional roles admin or analyst. The key is returned only in this response; only its hash is stored. Requires the admin role
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	body		object{name=string,subject=string,roles=[]string,expires_at=string}	true	"API key"
+//	@Success		201	{object}	object{id=int,name=string,prefix=string,subject=string,roles=[]string,expires_at=string,created_at=string,key=string}
+//	@Failure		400	{object}	string	"Invalid request body"
+//	@Failure		401	{object}	string	"Authentication required"
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		500	{object}	string	"Failed to create API key"
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	apiKey, key, err := h.service.CreateAPIKey(r.Context(), req.Name, req.Subject, req.Roles, req.ExpiresAt)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные данные ключа: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось создать ключ API", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createAPIKeyResponse{APIKey: *apiKey, Key: key})
+}
+
+// ListAPIKeys получает список ключей API
+//
+//	@Summary		List API keys
+//	@Description	Get all API keys of the caller's tenant without the keys themselves. Requires the admin role
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{array}		models.APIKey
+//	@Failure		401	{object}	string	"Authentication required"
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		500	{object}	string	"Failed to list API keys"
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		http.Error(w, "Не удалось получить список ключей API", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeAPIKey отзывает ключ API
+//
+//	@Summary		Revoke an API key
+//	@Description	Revoke an API key; requests with it are rejected from now on. Requires the admin role
+//	@Tags			Admin
+//	@Param			id	path	int	true	"API key ID"
+//	@Success		204
+//	@Failure		400	{object}	string	"Invalid API key ID"
+//	@Failure		401	{object}	string	"Authentication required"
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		404	{object}	string	"API key not found or already revoked"
+//	@Failure		500	{object}	string	"Failed to revoke API key"
+//	@Security		BearerAuth
+//	@Router			/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID ключа API", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Ключ API не найден или уже отозван", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось отозвать ключ API", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// AuditHandler обрабатывает HTTP запросы журнала аудита
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler создает новый обработчик журнала аудита
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetSubscriptionHistory получает историю изменений подписки
+//
+//	@Summary		Get subscription history
+//	@Description	Get the audit entries of a subscription, newest first: who created, changed or deleted it, when, in which request and which fields changed. The history of deleted subscriptions stays available. Regular users see only the history of their own subscriptions
+//	@Tags			Audit
+//	@Produce		json
+//	@Param			id	path		int	true	"Subscription ID"
+//	@Success		200	{object}	object{data=[]models.AuditEntry}
+//	@Failure		400	{object}	string	"Invalid subscription ID"
+//	@Failure		404	{object}	string	"Subscription history not found"
+//	@Failure		500	{object}	string	"Failed to get subscription history"
+//	@Security		BearerAuth
+//	@Router			/subscriptions/{id}/history [get]
+func (h *AuditHandler) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID подписки", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.SubscriptionHistory(r.Context(), uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "История подписки не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось получить историю подписки", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	response := struct {
+		Data []models.AuditEntry `json:"data"`
+	}{
+		Data: entries,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListAuditEntries получает записи журнала аудита
+//
+//	@Summary		List audit entries
+//	@Description	Get audit entries of subscription changes, newest first, with optional filters. Regular users see only entries about their own subscriptions
+//	@Tags			Audit
+//	@Produce		json
+//	@Param			subscription_id	query		int		false	"Filter by subscription ID"
+//	@Param			user_id			query		string	false	"Filter by subscription owner UUID"
+//	@Param			actor			query		string	false	"Filter by the subject who made the change"
+//	@Param			action			query		string	false	"Filter by action: create, update, delete"
+//	@Param			request_id		query		string	false	"Filter by request ID (X-Request-ID)"
+//	@Param			from			query		string	false	"Changes at or after this time (RFC 3339)"
+//	@Param			to				query		string	false	"Changes before this time (RFC 3339)"
+//	@Param			page			query		int		false	"Page number (default: 1)"
+//	@Param			limit			query		int		false	"Items per page (default: 50, max: 500)"
+//	@Success		200				{object}	object{data=[]models.AuditEntry,pagination=object{page=int,limit=int}}
+//	@Failure		400				{object}	string	"Invalid filter"
+//	@Failure		500				{object}	string	"Failed to list audit entries"
+//	@Security		BearerAuth
+//	@Router			/audit [get]
+func (h *AuditHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	// Получить параметры фильтра
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		UserID:    query.Get("user_id"),
+		Actor:     query.Get("actor"),
+		Action:    query.Get("action"),
+		RequestID: query.Get("request_id"),
+	}
+	if value := query.Get("subscription_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Неверный фильтр: subscription_id: ожидается число", http.StatusBadRequest)
+			return
+		}
+		filter.SubscriptionID = uint(id)
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "Неверный фильтр: "+name+": ожидается дата в формате RFC 3339", http.StatusBadRequest)
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	// Получить параметры пагинации
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	entries, err := h.service.ListAuditEntries(r.Context(), filter, page, limit)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось получить журнал аудита", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	response := struct {
+		Data       []models.AuditEntry `json:"data"`
+		Pagination struct {
+			Page  int `json:"page"`
+			Limit int `json:"limit"`
+		} `json:"pagination"`
+	}{
+		Data: entries,
+	}
+	response.Pagination.Page = page
+	response.Pagination.Limit = limit
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
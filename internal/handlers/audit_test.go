@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"github.com/gorilla/mux"
+)
+
+// Пустая страница журнала возвращается как пустой список, а не null;
+// история подписки без записей не найдена
+func TestAuditHandlerEmptyResults(t *testing.T) {
+	handler := NewAuditHandler(services.NewAuditService(repository.NewAuditRepository(openDB(t))))
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+
+	w := httptest.NewRecorder()
+	handler.ListAuditEntries(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/audit?action=delete", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"data":[]`) {
+		t.Fatalf("журнал аудита: %d %s, ожидался пустой список data", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/subscriptions/1/history", nil)
+	w = httptest.NewRecorder()
+	handler.GetSubscriptionHistory(w, mux.SetURLVars(req, map[string]string{"id": "1"}))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("история подписки без записей: %d, ожидался 404", w.Code)
+	}
+}
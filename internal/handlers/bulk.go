@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"effective-mobile-subscription/internal/services"
+)
+
+// bulkCreateRequest тело запроса на массовое создание подписок
+type bulkCreateRequest struct {
+	Mode  string                      `json:"mode"`
+	Items []createSubscriptionRequest `json:"items"`
+}
+
+// bulkUpdateItemRequest элемент запроса на массовое обновление подписок
+type bulkUpdateItemRequest struct {
+	ID uint `json:"id"`
+	updateSubscriptionRequest
+}
+
+// bulkUpdateRequest тело запроса на массовое обновление подписок
+type bulkUpdateRequest struct {
+	Mode  string                  `json:"mode"`
+	Items []bulkUpdateItemRequest `json:"items"`
+}
+
+// bulkDeleteRequest тело запроса на массовое удаление подписок по списку ID или по фильтру
+type bulkDeleteRequest struct {
+	Mode   string         `json:"mode"`
+	IDs    []uint         `json:"ids"`
+	Filter map[string]any `json:"filter"`
+}
+
+// BulkCreateSubscriptions создает несколько подписок в одной транзакции
+//
+//	@Summary		Bulk create subscriptions
+//	@Description	Create many subscriptions in one transaction. Mode "atomic" (default) creates all items or none, "best_effort" creates every valid item
+//	@Tags			Subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		object{mode=string,items=[]handlers.createSubscriptionRequest}	true	"Items to create"
+//	@Success		200		{object}	services.BulkResult
+//	@Failure		400		{object}	string	"Invalid request body"
+//	@Failure		403		{object}	string	"Not allowed to modify subscriptions"
+//	@Failure		422		{object}	services.BulkResult	"Atomic operation rolled back"
+//	@Failure		500		{object}	string	"Failed to create subscriptions"
+//	@Router			/subscriptions/bulk [post]
+func (h *SubscriptionHandler) BulkCreateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req bulkCreateRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := services.ParseBulkMode(req.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Разобрать элементы; ошибки разбора попадают в результат элемента
+	items := make([]services.BulkCreateItem, len(req.Items))
+	for i := range req.Items {
+		items[i].Subscription, items[i].Err = req.Items[i].toModel()
+	}
+
+	// Создать подписки
+	result, err := h.service.BulkCreateSubscriptions(r.Context(), items, mode)
+	if err != nil {
+		writeBulkError(w, err, "Не удалось создать подписки")
+		return
+	}
+
+	writeBulkResult(w, result)
+}
+
+// BulkUpdateSubscriptions обновляет несколько подписок в одной транзакции
+//
+//	@Summary		Bulk update subscriptions
+//	@Description	Update many subscriptions in one transaction. Each item contains the subscription id and the fields to change
+//	@Tags			Subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		object{mode=string,items=[]handlers.bulkUpdateItemRequest}	true	"Items to update"
+//	@Success		200		{object}	services.BulkResult
+//	@Failure		400		{object}	string	"Invalid request body"
+//	@Failure		403		{object}	string	"Not allowed to modify subscriptions"
+//	@Failure		422		{object}	services.BulkResult	"Atomic operation rolled back"
+//	@Failure		500		{object}	string	"Failed to update subscriptions"
+//	@Router			/subscriptions/bulk [patch]
+func (h *SubscriptionHandler) BulkUpdateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req bulkUpdateRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := services.ParseBulkMode(req.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Разобрать элементы; ошибки разбора попадают в результат элемента
+	items := make([]services.BulkUpdateItem, len(req.Items))
+	for i := range req.Items {
+		items[i].ID = req.Items[i].ID
+		items[i].Subscription, items[i].Err = req.Items[i].toModel()
+	}
+
+	// Обновить подписки
+	result, err := h.service.BulkUpdateSubscriptions(r.Context(), items, mode)
+	if err != nil {
+		writeBulkError(w, err, "Не удалось обновить подписки")
+		return
+	}
+
+	writeBulkResult(w, result)
+}
+
+// BulkDeleteSubscriptions удаляет несколько подписок по списку ID или по фильтру
+//
+//	@Summary		Bulk delete subscriptions
+//	@Description	Delete subscriptions by a list of ids or by a filter in one transaction. The filter accepts the same keys as the GET /subscriptions query parameters. A filter deletes at most 10000 subscriptions per request: in atomic mode a filter matching more is rejected with 400, in best_effort mode the subscriptions with the lowest ids are deleted and remaining reports how many are left
+//	@Tags			Subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		object{mode=string,ids=[]int,filter=object{user_id=string,service_name=string,price_min=int,price_max=int,active_at=string}}	true	"Ids or filter"
+//	@Success		200		{object}	services.BulkResult
+//	@Failure		400		{object}	string	"Invalid request body"
+//	@Failure		403		{object}	string	"Not allowed to modify subscriptions"
+//	@Failure		422		{object}	services.BulkResult	"Atomic operation rolled back"
+//	@Failure		500		{object}	string	"Failed to delete subscriptions"
+//	@Router			/subscriptions/bulk [delete]
+func (h *SubscriptionHandler) BulkDeleteSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req bulkDeleteRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := services.ParseBulkMode(req.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Удалить подписки по фильтру или по списку ID
+	var result *services.BulkResult
+	switch {
+	case req.Filter != nil && len(req.IDs) > 0:
+		http.Error(w, "Укажите либо ids, либо filter", http.StatusBadRequest)
+		return
+	case req.Filter != nil:
+		filter, filterErr := filterFromMap(req.Filter)
+		if filterErr != nil {
+			http.Error(w, "Неверный фильтр: "+filterErr.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err = h.service.BulkDeleteSubscriptionsByFilter(r.Context(), filter, mode)
+	default:
+		result, err = h.service.BulkDeleteSubscriptions(r.Context(), req.IDs, mode)
+	}
+	if err != nil {
+		writeBulkError(w, err, "Не удалось удалить подписки")
+		return
+	}
+
+	writeBulkResult(w, result)
+}
+
+// writeBulkResult отправляет результат массовой операции.
+// Если операция atomic откатилась, возвращается статус 422
+func writeBulkResult(w http.ResponseWriter, result *services.BulkResult) {
+	status := http.StatusOK
+	if !result.Applied() {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeBulkError отправляет ошибку массовой операции
+func writeBulkError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrBulkEmpty),
+		errors.Is(err, services.ErrBulkTooManyItems),
+		errors.Is(err, services.ErrBulkEmptyFilter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case services.IsValidationError(err):
+		http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+)
+
+// eventsHeartbeatInterval интервал комментариев, которые не дают прокси закрыть простаивающее соединение
+const eventsHeartbeatInterval = 15 * time.Second
+
+// eventsReplayBatchSize количество событий журнала, читаемых за один запрос при возобновлении потока
+const eventsReplayBatchSize = 500
+
+// eventsRetry задержка переподключения клиента в миллисекундах
+const eventsRetry = 3000
+
+// EventStreamHandler обрабатывает потоковые HTTP запросы событий подписок
+type EventStreamHandler struct {
+	stream *services.EventStream
+}
+
+// NewEventStreamHandler создает новый обработчик потока событий
+func NewEventStreamHandler(stream *services.EventStream) *EventStreamHandler {
+	return &EventStreamHandler{stream: stream}
+}
+
+// StreamEvents передает изменения подписок как Server-Sent Events
+//
+//	@Summary		Stream subscription changes
+//	@Description	Server-Sent Events stream of subscription.created, subscription.updated and subscription.deleted events. The SSE id is a persisted sequence number: reconnect with the Last-Event-ID header (or last_event_id query parameter) to receive the events missed since then. Events made on any server instance are delivered
+//	@Tags			Subscriptions
+//	@Produce		text/event-stream
+//	@Param			user_id			query		string	false	"Only events of this user's subscriptions"
+//	@Param			last_event_id	query		int		false	"Resume after this event, same as the Last-Event-ID header"
+//	@Param			Last-Event-ID	header		int		false	"Resume after this event"
+//	@Success		200				{string}	string	"Event stream"
+//	@Failure		400				{object}	string	"Invalid user_id or Last-Event-ID"
+//	@Failure		403				{object}	string	"Events of another user requested"
+//	@Failure		500				{object}	string	"Failed to stream events"
+//	@Router			/subscriptions/events [get]
+func (h *EventStreamHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	// Определить, с какого события продолжить поток
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastEventID != "" {
+		var err error
+		if after, err = strconv.ParseInt(lastEventID, 10, 64); err != nil || after < 0 {
+			http.Error(w, "Неверный Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Подключиться к потоку до чтения журнала, чтобы не пропустить события между ними
+	userID := r.URL.Query().Get("user_id")
+	subscription, err := h.stream.Subscribe(r.Context(), userID)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err == services.ErrForbidden {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Не удалось подключиться к потоку событий", http.StatusInternalServerError)
+		return
+	}
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+
+	// Дочитать из журнала события, пропущенные клиентом.
+	// Ответ уже начат, поэтому при ошибке соединение закрывается и клиент переподключается
+	if lastEventID != "" {
+		for {
+			missed, err := subscription.Replay(after, eventsReplayBatchSize)
+			if err != nil {
+				return
+			}
+			for _, event := range missed {
+				writeEvent(w, event)
+				after = event.Sequence
+			}
+			if len(missed) < eventsReplayBatchSize {
+				break
+			}
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-subscription.Events():
+			// Поток закрыт: клиент переподключится и продолжит с Last-Event-ID
+			if !ok {
+				return
+			}
+			// Событие уже отправлено при чтении журнала
+			if event.Sequence <= after {
+				continue
+			}
+			writeEvent(w, event)
+			after = event.Sequence
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent записывает событие в формате Server-Sent Events
+func writeEvent(w http.ResponseWriter, event models.StoredEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, event.Payload)
+}
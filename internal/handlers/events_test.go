@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	streamOwnerID = "550e8400-e29b-41d4-a716-446655440000"
+	streamOtherID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+)
+
+// sseEvent событие, прочитанное из потока Server-Sent Events
+type sseEvent struct {
+	id        string
+	eventType string
+	data      string
+}
+
+// streamFixture поток событий, раздающий события журнала в памяти
+type streamFixture struct {
+	repo   *repository.EventRepository
+	stream *services.EventStream
+	seq    int
+}
+
+// openDB открывает пустую базу в памяти со всеми таблицами
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(&config.Config{DBDriver: database.DriverMemory}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("открытие базы данных: %v", err)
+	}
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newEventStream открывает базу в памяти и создает поток событий над ее журналом
+func newEventStream(t *testing.T) *streamFixture {
+	t.Helper()
+	repo := repository.NewEventRepository(openDB(t))
+	return &streamFixture{repo: repo, stream: services.NewEventStream(repo)}
+}
+
+// newStreamFixture создает поток событий и запускает раздачу. Возвращает управление,
+// когда поток начал раздавать события, записанные после запуска
+func newStreamFixture(t *testing.T) *streamFixture {
+	t.Helper()
+	f := newEventStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.stream.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		f.stream.Close()
+	})
+
+	// Run сначала запоминает последний номер журнала; события служебной организации,
+	// записанные до этого, не раздаются, поэтому они повторяются, пока одно не дойдет
+	warmup := tenant.WithTenant(context.Background(), &models.Tenant{ID: "warmup"})
+	subscription, err := f.stream.Subscribe(warmup, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscription.Close()
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		f.publish(t, "warmup", streamOwnerID)
+		select {
+		case <-subscription.Events():
+			return f
+		case <-time.After(1500 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("поток событий не запустился")
+		}
+	}
+}
+
+// publish записывает в журнал событие создания подписки пользователя userID организации tenantID
+// и возвращает ID события
+func (f *streamFixture) publish(t *testing.T, tenantID, userID string) string {
+	t.Helper()
+	f.seq++
+	event := models.SubscriptionEvent{
+		ID:           fmt.Sprintf("evt_%d", f.seq),
+		TenantID:     tenantID,
+		Type:         models.EventSubscriptionCreated,
+		OccurredAt:   time.Now(),
+		Subscription: &models.Subscription{ID: uint(f.seq), TenantID: tenantID, ServiceName: "Netflix", Price: 990, UserID: userID},
+	}
+	if err := f.stream.Publish(context.Background(), []models.SubscriptionEvent{event}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return event.ID
+}
+
+// connect открывает поток событий от имени principal организации по умолчанию и возвращает канал событий.
+// Возвращает управление, когда получатель подключен к потоку
+func (f *streamFixture) connect(t *testing.T, principal *auth.Principal, lastEventID string) <-chan sseEvent {
+	t.Helper()
+	handler := NewEventStreamHandler(f.stream)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tenant.WithTenant(r.Context(), &models.Tenant{ID: models.DefaultTenantID})
+		if principal != nil {
+			ctx = auth.WithPrincipal(ctx, principal)
+		}
+		handler.StreamEvents(w, r.WithContext(ctx))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("подключение к потоку: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+		server.Close()
+	})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("ответ %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Первая строка потока отправляется после подключения получателя
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry: ") {
+		t.Fatalf("первая строка потока %q, %v", line, err)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				if event.id != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.eventType = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// next ждет следующее событие потока
+func next(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("поток закрыт")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("событие не получено")
+	}
+	return sseEvent{}
+}
+
+// Поток, возобновленный с Last-Event-ID, дочитывает пропущенные события организации из журнала
+// и продолжается новыми событиями без повторов
+func TestStreamEventsResumesFromLastEventID(t *testing.T) {
+	f := newStreamFixture(t)
+	f.publish(t, models.DefaultTenantID, streamOwnerID)
+	seen, err := f.repo.LastSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	missed := f.publish(t, models.DefaultTenantID, streamOtherID)
+	f.publish(t, "acme", streamOwnerID)
+
+	// Клиент видел первое событие организации
+	events := f.connect(t, &auth.Principal{Subject: streamOtherID, Roles: []string{auth.RoleAdmin}}, strconv.FormatInt(seen, 10))
+
+	event := next(t, events)
+	if event.eventType != models.EventSubscriptionCreated || !strings.Contains(event.data, `"id":"`+missed+`"`) {
+		t.Fatalf("первое событие после Last-Event-ID %d: %+v, ожидалось %s", seen, event, missed)
+	}
+
+	live := f.publish(t, models.DefaultTenantID, streamOwnerID)
+	if event := next(t, events); !strings.Contains(event.data, `"id":"`+live+`"`) {
+		t.Fatalf("новое событие %+v, ожидалось %s; событие другой организации не должно передаваться", event, live)
+	}
+}
+
+// Обычный пользователь получает только события своих подписок своей организации
+func TestStreamEventsFiltersByTenantAndUser(t *testing.T) {
+	f := newStreamFixture(t)
+	events := f.connect(t, &auth.Principal{Subject: streamOwnerID}, "")
+
+	f.publish(t, models.DefaultTenantID, streamOtherID)
+	f.publish(t, "acme", streamOwnerID)
+	own := f.publish(t, models.DefaultTenantID, streamOwnerID)
+
+	event := next(t, events)
+	if !strings.Contains(event.data, `"id":"`+own+`"`) || !strings.Contains(event.data, streamOwnerID) {
+		t.Fatalf("событие %+v, ожидалось %s", event, own)
+	}
+}
+
+func TestStreamEventsRejectsInvalidRequests(t *testing.T) {
+	handler := NewEventStreamHandler(newEventStream(t).stream)
+
+	tests := []struct {
+		name      string
+		target    string
+		principal *auth.Principal
+		status    int
+	}{
+		{"неверный Last-Event-ID", "/subscriptions/events?last_event_id=abc", nil, http.StatusBadRequest},
+		{"неверный user_id", "/subscriptions/events?user_id=123", nil, http.StatusBadRequest},
+		{"события другого пользователя", "/subscriptions/events?user_id=" + streamOtherID,
+			&auth.Principal{Subject: streamOwnerID}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			w := httptest.NewRecorder()
+			handler.StreamEvents(w, httptest.NewRequestWithContext(ctx, http.MethodGet, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("статус %d, ожидался %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/utils"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportFlushEvery количество строк, после которого ответ отправляется клиенту
+const exportFlushEvery = 1000
+
+// maxXLSXExportRows максимальное количество строк выгрузки XLSX. Книга XLSX - ZIP архив,
+// который отправляется клиенту только целиком после записи последней строки, поэтому
+// большие выгрузки нужно получать в CSV или NDJSON. Переменная, чтобы тесты могли уменьшить ограничение
+var maxXLSXExportRows = 100000
+
+// errXLSXTooManyRows возвращается, если под фильтр подходит больше строк, чем помещается в выгрузку XLSX
+var errXLSXTooManyRows = errors.New("выгрузка XLSX превышает ограничение")
+
+// exportHeader заголовок выгрузки
+var exportHeader = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "notes", "tags", "created_at", "updated_at", "cost"}
+
+// exportWriter записывает строки выгрузки в определенном формате
+type exportWriter interface {
+	write(row services.ExportRow) error
+	close() error
+}
+
+// ExportSubscriptions выгружает все подписки, подходящие под фильтры, без пагинации
+//
+//	@Summary		Export subscriptions
+//	@Description	Stream all subscriptions matching the filters as CSV, NDJSON or XLSX. Accepts the same filter and sort parameters as GET /subscriptions. Each row includes the cost of the subscription over the optional from/to window (whole subscription period if omitted)
+//	@Tags			Subscriptions
+//	@Produce		text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format			query		string	false	"Export format: csv (default), ndjson or xlsx. XLSX is limited to 100000 rows, larger exports return 400"
+//	@Param			user_id			query		string	false	"Filter by user IDs, comma separated"
+//	@Param			service_name	query		string	false	"Filter by service names, comma separated"
+//	@Param			sort			query		string	false	"Sort fields, e.g. price,-start_date"
+//	@Param			from			query		string	false	"Cost window start in MM-YYYY format"
+//	@Param			to				query		string	false	"Cost window end in MM-YYYY format"
+//	@Success		200				{file}		file
+//	@Failure		400				{object}	string	"Invalid parameters"
+//	@Failure		500				{object}	string	"Failed to export subscriptions"
+//	@Router			/subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	// Получить параметры запроса
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	// Разобрать фильтр и сортировку так же, как для списка подписок
+	filter, err := parseSubscriptionFilter(query)
+	if err != nil {
+		http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	sort, err := models.ParseSort(query.Get("sort"))
+	if err != nil {
+		http.Error(w, "Неверная сортировка: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Выбрать формат выгрузки
+	out := &countingWriter{w: w}
+	var writer exportWriter
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer = newCSVExportWriter(out)
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		writer = newNDJSONExportWriter(out)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		xlsxWriter, err := newXLSXExportWriter(out)
+		if err != nil {
+			http.Error(w, "Не удалось выгрузить подписки", http.StatusInternalServerError)
+			return
+		}
+		writer = xlsxWriter
+	default:
+		http.Error(w, "Неизвестный формат, ожидается csv, ndjson или xlsx", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+format+`"`)
+
+	// Записывать строки по мере чтения из базы данных
+	written := 0
+	flusher, _ := w.(http.Flusher)
+	err = h.service.ExportSubscriptions(r.Context(), filter, sort, query.Get("from"), query.Get("to"),
+		func(row services.ExportRow) error {
+			if err := writer.write(row); err != nil {
+				return err
+			}
+			written++
+			if flusher != nil && written%exportFlushEvery == 0 {
+				flusher.Flush()
+			}
+			return nil
+		})
+
+	// Ошибку можно вернуть клиенту, только пока ничего не отправлено
+	if err != nil && out.n == 0 {
+		w.Header().Del("Content-Disposition")
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, errXLSXTooManyRows) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось выгрузить подписки", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		slog.Error("Выгрузка подписок прервана", "ошибка", err, "строк", written)
+		return
+	}
+
+	if err := writer.close(); err != nil {
+		slog.Error("Не удалось завершить выгрузку подписок", "ошибка", err)
+	}
+}
+
+// exportRecord возвращает значения колонок строки выгрузки
+func exportRecord(row services.ExportRow) []string {
+	subscription := row.Subscription
+
+	endDate := ""
+	if subscription.EndDate != nil {
+		endDate = utils.FormatMonthYear(*subscription.EndDate)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(subscription.ID), 10),
+		subscription.ServiceName,
+		strconv.Itoa(subscription.Price),
+		subscription.UserID,
+		utils.FormatMonthYear(subscription.StartDate),
+		endDate,
+		subscription.Notes,
+		strings.Join(subscription.Tags, ","),
+		subscription.CreatedAt.Format(time.RFC3339),
+		subscription.UpdatedAt.Format(time.RFC3339),
+		strconv.Itoa(row.Cost),
+	}
+}
+
+// csvExportWriter записывает выгрузку в CSV
+type csvExportWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// newCSVExportWriter создает запись выгрузки в CSV
+func newCSVExportWriter(w io.Writer) *csvExportWriter {
+	return &csvExportWriter{writer: csv.NewWriter(w)}
+}
+
+// write записывает строку CSV, предварительно записав заголовок
+func (c *csvExportWriter) write(row services.ExportRow) error {
+	if !c.headerWritten {
+		if err := c.writer.Write(exportHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	return c.writer.Write(exportRecord(row))
+}
+
+// close записывает заголовок для пустой выгрузки и сбрасывает буфер
+func (c *csvExportWriter) close() error {
+	if !c.headerWritten {
+		if err := c.writer.Write(exportHeader); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// ndjsonExportWriter записывает выгрузку в JSON Lines
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+// newNDJSONExportWriter создает запись выгрузки в JSON Lines
+func newNDJSONExportWriter(w io.Writer) *ndjsonExportWriter {
+	return &ndjsonExportWriter{encoder: json.NewEncoder(w)}
+}
+
+// write записывает подписку и ее стоимость одной строкой JSON
+func (n *ndjsonExportWriter) write(row services.ExportRow) error {
+	return n.encoder.Encode(struct {
+		*models.Subscription
+		Cost int `json:"cost"`
+	}{row.Subscription, row.Cost})
+}
+
+// close ничего не делает: каждая строка JSON записывается сразу
+func (n *ndjsonExportWriter) close() error {
+	return nil
+}
+
+// xlsxExportWriter записывает выгрузку в XLSX потоковой записью листа. Строки накапливаются
+// во временном файле excelize, клиенту книга отправляется при close, поэтому количество строк
+// ограничено maxXLSXExportRows, а превышение можно вернуть клиенту ошибкой
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+// newXLSXExportWriter создает книгу XLSX и записывает заголовок
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]any, len(exportHeader))
+	for i, name := range exportHeader {
+		header[i] = name
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxExportWriter{w: w, file: file, stream: stream, row: 1}, nil
+}
+
+// write добавляет строку на лист; числовые колонки записываются числами
+func (x *xlsxExportWriter) write(row services.ExportRow) error {
+	// Первая строка листа - заголовок
+	if x.row > maxXLSXExportRows {
+		return fmt.Errorf("%w: не больше %d строк, сузьте фильтр или выберите формат csv или ndjson",
+			errXLSXTooManyRows, maxXLSXExportRows)
+	}
+
+	record := exportRecord(row)
+	values := make([]any, len(record))
+	for i, value := range record {
+		values[i] = value
+	}
+	values[0] = row.Subscription.ID
+	values[2] = row.Subscription.Price
+	values[len(values)-1] = row.Cost
+
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+// close завершает лист и отправляет книгу клиенту
+func (x *xlsxExportWriter) close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
+
+// countingWriter считает байты, отправленные клиенту
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write записывает данные и увеличивает счетчик
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportXLSX создает count подписок и выгружает их в XLSX
+func exportXLSX(t *testing.T, count int) *httptest.ResponseRecorder {
+	t.Helper()
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t)))
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+	end := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	for range count {
+		subscription := &models.Subscription{
+			ServiceName: "Netflix", Price: 990, UserID: streamOwnerID,
+			StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &end,
+		}
+		if err := service.CreateSubscription(ctx, subscription); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/subscriptions/export?format=xlsx", nil)
+	NewSubscriptionHandler(service).ExportSubscriptions(w, req)
+	return w
+}
+
+// withMaxXLSXExportRows уменьшает ограничение выгрузки XLSX до конца теста
+func withMaxXLSXExportRows(t *testing.T, limit int) {
+	t.Helper()
+	previous := maxXLSXExportRows
+	maxXLSXExportRows = limit
+	t.Cleanup(func() { maxXLSXExportRows = previous })
+}
+
+func TestExportSubscriptionsXLSX(t *testing.T) {
+	withMaxXLSXExportRows(t, 2)
+
+	w := exportXLSX(t, 2)
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("разбор книги: %v", err)
+	}
+	defer file.Close()
+	rows, err := file.GetRows("Sheet1")
+	if err != nil || len(rows) != 3 || rows[1][1] != "Netflix" || rows[1][len(rows[1])-1] != "1980" {
+		t.Fatalf("лист %v, %v", rows, err)
+	}
+}
+
+// Выгрузка больше ограничения отклоняется до отправки книги
+func TestExportSubscriptionsXLSXTooManyRows(t *testing.T) {
+	withMaxXLSXExportRows(t, 2)
+
+	w := exportXLSX(t, 3)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "не больше 2 строк") {
+		t.Fatalf("ответ %d %s, ожидался 400", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Fatal("ошибка отправлена как файл")
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// representation выбранные клиентом поля и связанные ресурсы подписок в ответе
+type representation struct {
+	fields  []string
+	include []string
+}
+
+// parseRepresentation разбирает параметры fields и include. Неизвестные имена дают ошибку
+func parseRepresentation(query url.Values) (representation, error) {
+	var rep representation
+	var err error
+
+	if rep.fields, err = models.ParseFields(query.Get("fields")); err != nil {
+		return rep, fmt.Errorf("fields: %v", err)
+	}
+	if rep.include, err = models.ParseIncludes(query.Get("include")); err != nil {
+		return rep, fmt.Errorf("include: %v", err)
+	}
+
+	return rep, nil
+}
+
+// isFull сообщает, что клиент не выбирал поля и ресурсы и нужна полная модель подписки
+func (rep representation) isFull() bool {
+	return len(rep.fields) == 0 && len(rep.include) == 0
+}
+
+// render формирует представления подписок: только выбранные поля и встроенные связанные ресурсы.
+// Для подписок в состоянии на момент asOf связанные ресурсы загружаются на тот же момент
+func (h *SubscriptionHandler) render(ctx context.Context, subscriptions []models.Subscription, rep representation, asOf *time.Time) ([]any, error) {
+	items := make([]any, len(subscriptions))
+
+	// Без параметров вернуть подписки целиком, как раньше
+	if rep.isFull() {
+		for i := range subscriptions {
+			items[i] = subscriptions[i]
+		}
+		return items, nil
+	}
+
+	// Загрузить связанные ресурсы для всех подписок сразу
+	includes, err := h.service.LoadIncludes(ctx, subscriptions, rep.include, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		item, err := projectFields(&subscriptions[i], rep.fields)
+		if err != nil {
+			return nil, err
+		}
+
+		// Встроить связанные ресурсы
+		for _, resource := range rep.include {
+			switch resource {
+			case models.IncludeUser:
+				item[resource] = includes.Users[subscriptions[i].UserID]
+			case models.IncludeService:
+				item[resource] = includes.Services[subscriptions[i].ServiceName]
+			case models.IncludePriceHistory:
+				history := includes.PriceHistory[subscriptions[i].ID]
+				if history == nil {
+					history = []models.PriceChange{}
+				}
+				item[resource] = history
+			}
+		}
+
+		items[i] = item
+	}
+
+	return items, nil
+}
+
+// projectFields возвращает JSON-представление подписки только с выбранными полями; без полей - со всеми
+func projectFields(subscription *models.Subscription, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]any, len(all))
+	if len(fields) == 0 {
+		for name, value := range all {
+			item[name] = value
+		}
+		return item, nil
+	}
+
+	// Пустые необязательные поля (end_date, notes, tags) выводятся как null
+	for _, name := range fields {
+		if value, ok := all[name]; ok {
+			item[name] = value
+		} else {
+			item[name] = nil
+		}
+	}
+	return item, nil
+}
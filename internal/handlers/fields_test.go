@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"github.com/gorilla/mux"
+)
+
+// Подписка на момент as_of встраивает историю цен только до этого момента
+func TestGetSubscriptionAsOfPriceHistory(t *testing.T) {
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t)))
+	handler := NewSubscriptionHandler(service)
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+
+	subscription := &models.Subscription{
+		ServiceName: "Netflix", Price: 990, UserID: streamOwnerID,
+		StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := service.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	asOf := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := service.UpdateSubscription(ctx, subscription.ID, &models.Subscription{Price: 1290}); err != nil {
+		t.Fatal(err)
+	}
+
+	id := strconv.FormatUint(uint64(subscription.ID), 10)
+	query := url.Values{"include": {"price_history"}, "as_of": {asOf.Format(time.RFC3339Nano)}}
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/subscriptions/"+id+"?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	handler.GetSubscription(w, mux.SetURLVars(req, map[string]string{"id": id}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+
+	var got struct {
+		Price        int                  `json:"price"`
+		PriceHistory []models.PriceChange `json:"price_history"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Price != 990 || len(got.PriceHistory) != 1 || got.PriceHistory[0].Price != 990 {
+		t.Fatalf("подписка на момент as_of: %s", w.Body.String())
+	}
+}
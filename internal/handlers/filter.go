@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// parseSubscriptionFilter разбирает параметры фильтра подписок.
+// user_id и service_name принимают несколько значений через запятую, даты - в формате ММ-ГГГГ или ГГГГ-ММ-ДД
+func parseSubscriptionFilter(query url.Values) (models.SubscriptionFilter, error) {
+	var filter models.SubscriptionFilter
+	var err error
+
+	filter.UserIDs = splitList(query.Get("user_id"))
+	filter.ServiceNames = splitList(query.Get("service_name"))
+	filter.ServiceNameContains = strings.TrimSpace(query.Get("service_name_contains"))
+
+	if filter.PriceMin, err = parseIntParam(query, "price_min"); err != nil {
+		return filter, err
+	}
+	if filter.PriceMax, err = parseIntParam(query, "price_max"); err != nil {
+		return filter, err
+	}
+
+	// Начало диапазона - первый день месяца, конец - последний, если дата задана месяцем
+	if filter.StartFrom, err = parseDateParam(query, "start_from", false); err != nil {
+		return filter, err
+	}
+	if filter.StartTo, err = parseDateParam(query, "start_to", true); err != nil {
+		return filter, err
+	}
+	if filter.EndFrom, err = parseDateParam(query, "end_from", false); err != nil {
+		return filter, err
+	}
+	if filter.EndTo, err = parseDateParam(query, "end_to", true); err != nil {
+		return filter, err
+	}
+	if filter.ActiveAt, err = parseDateParam(query, "active_at", false); err != nil {
+		return filter, err
+	}
+
+	if value := query.Get("open_ended"); value != "" {
+		openEnded, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("open_ended: ожидается true или false")
+		}
+		filter.OpenEnded = &openEnded
+	}
+
+	if value := query.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("include_deleted: ожидается true или false")
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+
+	if filter.AsOf, err = parseAsOf(query); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseAsOf разбирает необязательный момент времени as_of в формате RFC 3339
+func parseAsOf(query url.Values) (*time.Time, error) {
+	value := query.Get("as_of")
+	if value == "" {
+		return nil, nil
+	}
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("as_of: ожидается время в формате RFC 3339")
+	}
+	return &asOf, nil
+}
+
+// filterFromMap преобразует фильтр из тела JSON запроса в параметры запроса,
+// чтобы разобрать его так же, как фильтр в строке запроса
+func filterFromMap(values map[string]any) (models.SubscriptionFilter, error) {
+	query := url.Values{}
+	for key, value := range values {
+		switch value := value.(type) {
+		case []any:
+			parts := make([]string, len(value))
+			for i, part := range value {
+				parts[i] = filterValue(part)
+			}
+			query.Set(key, strings.Join(parts, ","))
+		default:
+			query.Set(key, filterValue(value))
+		}
+	}
+	return parseSubscriptionFilter(query)
+}
+
+// filterValue преобразует значение JSON в строку параметра запроса. Числа записываются без
+// экспоненты, иначе fmt.Sprint превратил бы цену 1000000 в 1e+06
+func filterValue(value any) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// splitList разбирает список значений через запятую, пропуская пустые
+func splitList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseIntParam разбирает необязательный целочисленный параметр
+func parseIntParam(query url.Values, name string) (*int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ожидается целое число", name)
+	}
+	return &number, nil
+}
+
+// parseDateParam разбирает необязательный параметр даты.
+// Дата, заданная месяцем, приводится к последнему дню месяца, если endOfMonth установлен, иначе к первому
+func parseDateParam(query url.Values, name string, endOfMonth bool) (*time.Time, error) {
+	date, err := models.ParseFilterDate(query.Get(name), endOfMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return date, nil
+}
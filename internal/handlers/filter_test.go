@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFilterFromMapKeepsLargeNumbers(t *testing.T) {
+	var values map[string]any
+	body := `{"price_min": 1000000, "price_max": 25000000, "user_id": ["60601fee-2bf1-4721-ae6f-7636e79a0cba"]}`
+	if err := json.Unmarshal([]byte(body), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := filterFromMap(values)
+	if err != nil {
+		t.Fatalf("filterFromMap: %v", err)
+	}
+	if filter.PriceMin == nil || *filter.PriceMin != 1000000 {
+		t.Errorf("PriceMin = %v, ожидалось 1000000", filter.PriceMin)
+	}
+	if filter.PriceMax == nil || *filter.PriceMax != 25000000 {
+		t.Errorf("PriceMax = %v, ожидалось 25000000", filter.PriceMax)
+	}
+	if len(filter.UserIDs) != 1 {
+		t.Errorf("UserIDs = %v, ожидался один пользователь", filter.UserIDs)
+	}
+}
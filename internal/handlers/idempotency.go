@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+const (
+	// IdempotencyKeyHeader заголовок с ключом идемпотентности
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength максимальная длина ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentBodySize максимальный размер тела запроса с ключом идемпотентности в байтах.
+	// Тело читается в память целиком, чтобы вычислить его хеш
+	maxIdempotentBodySize = 1 << 20
+)
+
+// IdempotencyMiddleware сохраняет первый ответ для запросов с заголовком Idempotency-Key
+// и повторяет его для запросов с тем же ключом и телом
+func IdempotencyMiddleware(service *services.IdempotencyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+
+			// Запросы без ключа обрабатываются как обычно
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Слишком длинный ключ идемпотентности", http.StatusBadRequest)
+				return
+			}
+
+			// Прочитать тело запроса для вычисления хеша и восстановить его для обработчика
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(w, "Слишком большое тело запроса", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			tenantID := tenant.IDFrom(r.Context())
+			key = scopedIdempotencyKey(r, tenantID, key)
+
+			// Зарезервировать ключ или получить сохраненный ответ
+			stored, err := service.Begin(tenantID, key, hashRequest(r, body))
+			switch {
+			case err == services.ErrIdempotencyKeyReused:
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			case err == services.ErrIdempotencyKeyInProgress:
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case err != nil:
+				http.Error(w, "Не удалось проверить ключ идемпотентности", http.StatusInternalServerError)
+				return
+			}
+
+			// Повторить сохраненный ответ
+			if stored != nil {
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.ResponseBody)
+				return
+			}
+
+			// Освободить ключ, если обработчик запаниковал, и передать панику дальше
+			defer func() {
+				if p := recover(); p != nil {
+					if err := service.Release(tenantID, key); err != nil {
+						slog.Error("Не удалось освободить ключ идемпотентности", "ключ", key, "ошибка", err)
+					}
+					panic(p)
+				}
+			}()
+
+			// Выполнить запрос, запоминая ответ
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// Ошибки сервера не сохраняются, чтобы клиент мог повторить запрос
+			if recorder.status >= http.StatusInternalServerError {
+				if err := service.Release(tenantID, key); err != nil {
+					slog.Error("Не удалось освободить ключ идемпотентности", "ключ", key, "ошибка", err)
+				}
+				return
+			}
+
+			if err := service.Complete(tenantID, key, recorder.status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+				slog.Error("Не удалось сохранить ответ для ключа идемпотентности", "ключ", key, "ошибка", err)
+			}
+		})
+	}
+}
+
+// scopedIdempotencyKey возвращает ключ идемпотентности в пространстве организации и участника запроса,
+// чтобы одинаковые ключи разных организаций и пользователей не пересекались и не повторяли чужие ответы
+func scopedIdempotencyKey(r *http.Request, tenantID, key string) string {
+	var subject string
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		subject = principal.Subject
+	}
+	hash := sha256.Sum256([]byte(tenantID + "\x00" + subject + "\x00" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+// hashRequest вычисляет хеш метода, пути и тела запроса
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder записывает ответ клиенту и одновременно сохраняет его копию
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// WriteHeader запоминает статус ответа
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write запоминает тело ответа
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
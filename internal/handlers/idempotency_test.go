@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+// Слишком большое тело отклоняется до резервирования ключа, и ключ можно использовать снова
+func TestIdempotencyMiddlewareBodyTooLarge(t *testing.T) {
+	service := services.NewIdempotencyService(repository.NewIdempotencyRepository(openDB(t)), time.Hour)
+	var calls int
+	handler := IdempotencyMiddleware(service)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/subscriptions", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(strings.Repeat("x", maxIdempotentBodySize+1)); w.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Fatalf("тело больше ограничения: статус %d, вызовов %d; ожидался 413 без вызова", w.Code, calls)
+	}
+
+	body := strings.Repeat("x", maxIdempotentBodySize)
+	if w := send(body); w.Code != http.StatusCreated || w.Body.String() != body || calls != 1 {
+		t.Fatalf("тело на границе ограничения: статус %d, вызовов %d", w.Code, calls)
+	}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"effective-mobile-subscription/internal/services"
+)
+
+const (
+	// importBatchSize количество строк, которые проверяются и сохраняются за один раз
+	importBatchSize = 500
+
+	// maxImportErrors максимальное количество ошибок строк в ответе
+	maxImportErrors = 1000
+
+	// maxImportLineSize максимальная длина строки JSON Lines
+	maxImportLineSize = 1 << 20
+)
+
+// importColumns колонки CSV, соответствующие полям запроса на создание подписки
+var importColumns = []string{"service_name", "price", "user_id", "start_date", "end_date", "notes", "tags"}
+
+// optionalImportColumns колонки CSV, которые можно не указывать
+var optionalImportColumns = map[string]bool{"end_date": true, "notes": true, "tags": true}
+
+// importRow строка импорта с номером строки во входном файле
+type importRow struct {
+	line int
+	req  createSubscriptionRequest
+	err  error
+}
+
+// importRowReader читает строки импорта по одной. Возвращает io.EOF после последней строки
+type importRowReader interface {
+	next() (importRow, error)
+}
+
+// importRowError ошибка строки импорта
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// importSummary итоги импорта
+type importSummary struct {
+	TotalRows int `json:"total_rows"`
+	Valid     int `json:"valid"`
+	Imported  int `json:"imported"`
+	Failed    int `json:"failed"`
+}
+
+// importResponse отчет об импорте
+type importResponse struct {
+	DryRun          bool             `json:"dry_run"`
+	Summary         importSummary    `json:"summary"`
+	Errors          []importRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+}
+
+// ImportSubscriptions импортирует подписки из CSV или JSON Lines
+//
+//	@Summary		Import subscriptions
+//	@Description	Import subscriptions from CSV (header row with service_name, price, user_id, start_date and optional end_date, notes, tags; dates in MM-YYYY, tags comma separated) or JSON Lines. The body is processed as a stream in batches; valid rows are imported and invalid ones are reported. The file may be sent as the raw body or as the first file of a multipart/form-data request
+//	@Tags			Subscriptions
+//	@Accept			text/csv,application/x-ndjson,multipart/form-data
+//	@Produce		json
+//	@Param			format		query		string	false	"Input format: csv (default) or ndjson"
+//	@Param			delimiter	query		string	false	"CSV delimiter (default: ,)"
+//	@Param			dry_run		query		bool	false	"Only validate rows without saving"
+//	@Success		200			{object}	object{dry_run=bool,summary=object{total_rows=int,valid=int,imported=int,failed=int},errors=[]object{row=int,error=string},errors_truncated=bool}
+//	@Failure		400			{object}	string	"Invalid file or parameters"
+//	@Failure		403			{object}	string	"Not allowed to modify subscriptions"
+//	@Failure		500			{object}	string	"Failed to import subscriptions"
+//	@Router			/subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	// Получить параметры запроса
+	format := r.URL.Query().Get("format")
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	// Получить поток с файлом
+	body, err := importBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Создать читатель строк для выбранного формата
+	var reader importRowReader
+	switch format {
+	case "", "csv":
+		reader, err = newCSVRowReader(body, r.URL.Query().Get("delimiter"))
+	case "ndjson", "jsonl":
+		reader = newNDJSONRowReader(body)
+	default:
+		err = errors.New("Неизвестный формат, ожидается csv или ndjson")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := importResponse{DryRun: dryRun, Errors: []importRowError{}}
+	batch := make([]importRow, 0, importBatchSize)
+
+	// Обработать накопленный пакет строк
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		items := make([]services.BulkCreateItem, len(batch))
+		for i := range batch {
+			items[i].Err = batch[i].err
+			if items[i].Err == nil {
+				items[i].Subscription, items[i].Err = batch[i].req.toModel()
+			}
+		}
+
+		var result *services.BulkResult
+		var err error
+		if dryRun {
+			result, err = h.service.ValidateSubscriptions(r.Context(), items)
+		} else {
+			result, err = h.service.BulkCreateSubscriptions(r.Context(), items, services.BulkModeBestEffort)
+		}
+		if err != nil {
+			return err
+		}
+
+		response.addBatch(batch, result)
+		batch = batch[:0]
+		return nil
+	}
+
+	// Читать строки потоком и сохранять их пакетами
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Не удалось прочитать файл: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				writeImportError(w, err)
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	// Вернуть отчет об импорте
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// addBatch добавляет результаты пакета строк в отчет
+func (resp *importResponse) addBatch(batch []importRow, result *services.BulkResult) {
+	for i, item := range result.Results {
+		resp.Summary.TotalRows++
+
+		switch item.Status {
+		case services.BulkStatusCreated:
+			resp.Summary.Valid++
+			resp.Summary.Imported++
+		case services.BulkStatusValid:
+			resp.Summary.Valid++
+		default:
+			resp.Summary.Failed++
+			if len(resp.Errors) == maxImportErrors {
+				resp.ErrorsTruncated = true
+				continue
+			}
+			resp.Errors = append(resp.Errors, importRowError{Row: batch[i].line, Error: item.Error})
+		}
+	}
+}
+
+// importBody возвращает поток с содержимым файла: тело запроса или первый файл multipart/form-data
+func importBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	multipartReader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("Неверный multipart запрос")
+	}
+	for {
+		part, err := multipartReader.NextPart()
+		if err != nil {
+			return nil, errors.New("Файл не найден в multipart запросе")
+		}
+		if part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
+
+// csvRowReader читает строки импорта из CSV с заголовком
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// newCSVRowReader читает заголовок CSV и проверяет наличие обязательных колонок
+func newCSVRowReader(r io.Reader, delimiter string) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	if delimiter != "" {
+		comma, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return nil, errors.New("Разделитель должен быть одним символом")
+		}
+		reader.Comma = comma
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("Пустой файл")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Не удалось прочитать заголовок CSV: %v", err)
+	}
+
+	// Сопоставить колонки по именам; неизвестные колонки игнорируются
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok && !optionalImportColumns[name] {
+			return nil, fmt.Errorf("В заголовке CSV нет колонки %s", name)
+		}
+	}
+
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+// next читает следующую строку CSV
+func (c *csvRowReader) next() (importRow, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return importRow{}, io.EOF
+	}
+
+	// Ошибка разбора строки относится только к этой строке
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{line: parseErr.StartLine, err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := importRow{line: line}
+
+	// Получить значение колонки или пустую строку, если колонки нет в строке
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row.req.ServiceName = field("service_name")
+	row.req.UserID = field("user_id")
+	row.req.StartDate = field("start_date")
+	row.req.EndDate = field("end_date")
+	row.req.Notes = field("notes")
+	row.req.Tags = splitList(field("tags"))
+
+	price, err := strconv.Atoi(field("price"))
+	if err != nil {
+		row.err = &services.ValidationError{Field: "price", Message: "ожидается целое число"}
+		return row, nil
+	}
+	row.req.Price = price
+
+	return row, nil
+}
+
+// ndjsonRowReader читает строки импорта из JSON Lines
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// newNDJSONRowReader создает читатель JSON Lines
+func newNDJSONRowReader(r io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+// next читает следующую непустую строку JSON Lines
+func (n *ndjsonRowReader) next() (importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row := importRow{line: n.line}
+		if err := json.Unmarshal([]byte(line), &row.req); err != nil {
+			row.err = errors.New("неверный JSON")
+		}
+		return row, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
+
+// writeImportError отправляет ошибку сохранения пакета импорта
+func writeImportError(w http.ResponseWriter, err error) {
+	if err == services.ErrForbidden {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, "Не удалось импортировать подписки", http.StatusInternalServerError)
+}
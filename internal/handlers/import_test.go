@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+)
+
+// importFixture обработчик подписок над базой в памяти
+type importFixture struct {
+	db      *gorm.DB
+	handler *SubscriptionHandler
+}
+
+func newImportFixture(t *testing.T) *importFixture {
+	t.Helper()
+	db := openDB(t)
+	return &importFixture{
+		db:      db,
+		handler: NewSubscriptionHandler(services.NewSubscriptionService(repository.NewSubscriptionRepository(db))),
+	}
+}
+
+// importFile отправляет файл импорта и возвращает статус и разобранный отчет
+func (f *importFixture) importFile(t *testing.T, query, contentType, body string) (int, importResponse) {
+	t.Helper()
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/subscriptions/import?"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	f.handler.ImportSubscriptions(w, req)
+
+	var response importResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("разбор отчета %s: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, response
+}
+
+// subscriptions возвращает сохраненные подписки в порядке создания
+func (f *importFixture) subscriptions(t *testing.T) []models.Subscription {
+	t.Helper()
+	var subscriptions []models.Subscription
+	if err := f.db.Order("id").Find(&subscriptions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return subscriptions
+}
+
+const importCSV = "\ufeffService_Name,price,user_id,start_date,end_date,notes,tags,extra\n" +
+	"Netflix,990,550e8400-e29b-41d4-a716-446655440000,01-2025,12-2025,семейный,\"video,family\",x\n" +
+	"Spotify,abc,550e8400-e29b-41d4-a716-446655440000,01-2025,,,,\n" +
+	"Yandex Plus,299,not-a-uuid,01-2025,,,,\n" +
+	"Okko,399,550e8400-e29b-41d4-a716-446655440000,2025-01,,,,\n" +
+	"Kion,\"199,550e8400-e29b-41d4-a716-446655440000,01-2025\n"
+
+func TestImportSubscriptionsCSV(t *testing.T) {
+	f := newImportFixture(t)
+
+	status, response := f.importFile(t, "", "text/csv", importCSV)
+	if status != http.StatusOK {
+		t.Fatalf("статус %d", status)
+	}
+	want := importSummary{TotalRows: 5, Valid: 1, Imported: 1, Failed: 4}
+	if response.Summary != want || response.DryRun {
+		t.Fatalf("итоги %+v, ожидалось %+v", response.Summary, want)
+	}
+
+	// Ошибки указывают номер строки файла, считая заголовок первой строкой
+	rows := make([]int, len(response.Errors))
+	for i, rowErr := range response.Errors {
+		rows[i] = rowErr.Row
+		if rowErr.Error == "" {
+			t.Fatalf("пустая ошибка строки %d", rowErr.Row)
+		}
+	}
+	if len(rows) != 4 || rows[0] != 3 || rows[1] != 4 || rows[2] != 5 || rows[3] != 6 {
+		t.Fatalf("строки с ошибками %v, ожидалось [3 4 5 6]", rows)
+	}
+	if !strings.Contains(response.Errors[0].Error, "price") {
+		t.Fatalf("ошибка цены: %q", response.Errors[0].Error)
+	}
+
+	subscriptions := f.subscriptions(t)
+	if len(subscriptions) != 1 {
+		t.Fatalf("сохранено %d подписок, ожидалась 1", len(subscriptions))
+	}
+	got := subscriptions[0]
+	if got.ServiceName != "Netflix" || got.Price != 990 || got.EndDate == nil || got.Notes != "семейный" ||
+		len(got.Tags) != 2 || got.Tags[0] != "video" || got.Tags[1] != "family" {
+		t.Fatalf("подписка %+v", got)
+	}
+}
+
+func TestImportSubscriptionsCSVDelimiterAndMultipart(t *testing.T) {
+	f := newImportFixture(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("comment", "не файл"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := form.CreateFormFile("file", "subscriptions.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("service_name;price;user_id;start_date\nNetflix;990;550e8400-e29b-41d4-a716-446655440000;01-2025\n"))
+	form.Close()
+
+	status, response := f.importFile(t, "delimiter=%3B", form.FormDataContentType(), body.String())
+	if status != http.StatusOK || response.Summary.Imported != 1 {
+		t.Fatalf("статус %d, итоги %+v", status, response.Summary)
+	}
+}
+
+func TestImportSubscriptionsNDJSON(t *testing.T) {
+	f := newImportFixture(t)
+
+	body := `{"service_name":"Netflix","price":990,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"01-2025","tags":["video"]}
+
+{"service_name":"Spotify","price":
+{"service_name":"","price":199,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"01-2025"}
+{"service_name":"Okko","price":399,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"01-2025","end_date":"12-2024"}
+`
+	status, response := f.importFile(t, "format=ndjson", "application/x-ndjson", body)
+	if status != http.StatusOK {
+		t.Fatalf("статус %d", status)
+	}
+	want := importSummary{TotalRows: 4, Valid: 1, Imported: 1, Failed: 3}
+	if response.Summary != want {
+		t.Fatalf("итоги %+v, ожидалось %+v", response.Summary, want)
+	}
+
+	// Пустые строки пропускаются, но учитываются в номерах строк
+	if len(response.Errors) != 3 || response.Errors[0].Row != 3 || response.Errors[0].Error != "неверный JSON" ||
+		response.Errors[1].Row != 4 || response.Errors[2].Row != 5 {
+		t.Fatalf("ошибки %+v", response.Errors)
+	}
+	if subscriptions := f.subscriptions(t); len(subscriptions) != 1 || subscriptions[0].Tags[0] != "video" {
+		t.Fatalf("сохранены подписки %+v", subscriptions)
+	}
+}
+
+// dry_run проверяет строки и сообщает о том же результате, но ничего не сохраняет
+func TestImportSubscriptionsDryRun(t *testing.T) {
+	f := newImportFixture(t)
+
+	status, response := f.importFile(t, "dry_run=true", "text/csv", importCSV)
+	if status != http.StatusOK {
+		t.Fatalf("статус %d", status)
+	}
+	want := importSummary{TotalRows: 5, Valid: 1, Imported: 0, Failed: 4}
+	if !response.DryRun || response.Summary != want || len(response.Errors) != 4 {
+		t.Fatalf("отчет %+v, ожидались итоги %+v", response, want)
+	}
+	if subscriptions := f.subscriptions(t); len(subscriptions) != 0 {
+		t.Fatalf("dry_run сохранил %d подписок", len(subscriptions))
+	}
+}
+
+func TestImportSubscriptionsRejectsInvalidFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		body  string
+	}{
+		{"пустой файл", "", ""},
+		{"нет обязательной колонки", "", "service_name,price,start_date\nNetflix,990,01-2025\n"},
+		{"разделитель из нескольких символов", "delimiter=%3B%3B", "service_name;price\n"},
+		{"неизвестный формат", "format=xml", "<subscriptions/>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newImportFixture(t)
+			if status, _ := f.importFile(t, tt.query, "text/csv", tt.body); status != http.StatusBadRequest {
+				t.Fatalf("статус %d, ожидался 400", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/pkg/utils"
+)
+
+var (
+	// errInvalidStartDate возвращается при неверном формате даты начала
+	errInvalidStartDate = errors.New("Неверный формат даты начала, ожидается ММ-ГГГГ")
+
+	// errInvalidEndDate возвращается при неверном формате даты окончания
+	errInvalidEndDate = errors.New("Неверный формат даты окончания, ожидается ММ-ГГГГ")
+)
+
+// createSubscriptionRequest тело запроса на создание подписки
+type createSubscriptionRequest struct {
+	ServiceName string   `json:"service_name"`
+	Price       int      `json:"price"`
+	UserID      string   `json:"user_id"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// toModel разбирает даты и создает модель подписки
+func (req *createSubscriptionRequest) toModel() (*models.Subscription, error) {
+	// Разобрать дату начала
+	startDate, err := utils.ParseMonthYear(req.StartDate)
+	if err != nil {
+		return nil, errInvalidStartDate
+	}
+
+	// Разобрать дату окончания, если предоставлена
+	var endDate *time.Time
+	if req.EndDate != "" {
+		end, err := utils.ParseMonthYear(req.EndDate)
+		if err != nil {
+			return nil, errInvalidEndDate
+		}
+		endDate = &end
+	}
+
+	return &models.Subscription{
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		UserID:      req.UserID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Notes:       req.Notes,
+		Tags:        req.Tags,
+	}, nil
+}
+
+// updateSubscriptionRequest тело запроса на обновление подписки; пустые поля не изменяются
+type updateSubscriptionRequest struct {
+	ServiceName string   `json:"service_name,omitempty"`
+	Price       int      `json:"price,omitempty"`
+	UserID      string   `json:"user_id,omitempty"`
+	StartDate   string   `json:"start_date,omitempty"`
+	EndDate     string   `json:"end_date,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// toModel разбирает даты и создает модель подписки только с обновленными полями
+func (req *updateSubscriptionRequest) toModel() (*models.Subscription, error) {
+	subscription := &models.Subscription{
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		UserID:      req.UserID,
+		Notes:       req.Notes,
+		Tags:        req.Tags,
+	}
+
+	// Разобрать дату начала, если предоставлена
+	if req.StartDate != "" {
+		start, err := utils.ParseMonthYear(req.StartDate)
+		if err != nil {
+			return nil, errInvalidStartDate
+		}
+		subscription.StartDate = start
+	}
+
+	// Разобрать дату окончания, если предоставлена
+	if req.EndDate != "" {
+		end, err := utils.ParseMonthYear(req.EndDate)
+		if err != nil {
+			return nil, errInvalidEndDate
+		}
+		subscription.EndDate = &end
+	}
+
+	return subscription, nil
+}
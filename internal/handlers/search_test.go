@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+// Поиск без результатов возвращает пустой список, а не null
+func TestSearchSubscriptionsEmptyResults(t *testing.T) {
+	handler := NewSubscriptionHandler(services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t))))
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+
+	w := httptest.NewRecorder()
+	handler.SearchSubscriptions(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/subscriptions/search?q=netflix", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"data":[]`) {
+		t.Fatalf("ответ %d %s, ожидался пустой список data", w.Code, w.Body.String())
+	}
+}
@@ -4,11 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
-	"time"
 
 	"effective-mobile-subscription/internal/models"
 	"effective-mobile-subscription/internal/services"
-	"effective-mobile-subscription/pkg/utils"
+	"effective-mobile-subscription/internal/tenant"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
@@ -32,18 +31,17 @@ func NewSubscriptionHandler(service *services.SubscriptionService) *Subscription
 //	@Accept			json
 //	@Produce		json
 //	@Param			subscription	body		models.Subscription	true	"Subscription object"
+//	@Param			Idempotency-Key	header		string				false	"Key to safely retry the request"
 //	@Success		201			{object}	models.Subscription
 //	@Failure		400			{object}	string	"Invalid request body"
+//	@Failure		409			{object}	string	"Request with this idempotency key is in progress"
+//	@Failure		413			{object}	string	"Request body with an idempotency key exceeds 1 MiB"
+//	@Failure		422			{object}	string	"Idempotency key reused with a different request"
+//	@Failure		403			{object}	string	"Not allowed to create subscriptions for this user"
 //	@Failure		500			{object}	string	"Failed to create subscription"
 //	@Router			/subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		ServiceName string `json:"service_name"`
-		Price       int    `json:"price"`
-		UserID      string `json:"user_id"`
-		StartDate   string `json:"start_date"`
-		EndDate     string `json:"end_date,omitempty"`
-	}
+	var req createSubscriptionRequest
 
 	// Декодировать тело запроса
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,35 +49,23 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Разобрать дату начала
-	startDate, err := utils.ParseMonthYear(req.StartDate)
+	// Создать модель подписки
+	subscription, err := req.toModel()
 	if err != nil {
-		http.Error(w, "Неверный формат даты начала, ожидается ММ-ГГГГ", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Разобрать дату окончания, если предоставлена
-	var endDate *time.Time
-	if req.EndDate != "" {
-		end, err := utils.ParseMonthYear(req.EndDate)
-		if err != nil {
-			http.Error(w, "Неверный формат даты окончания, ожидается ММ-ГГГГ", http.StatusBadRequest)
+	// Создать подписку в базе данных
+	if err := h.service.CreateSubscription(r.Context(), subscription); err != nil {
+		if err == services.ErrForbidden {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные данные подписки: "+err.Error(), http.StatusBadRequest)
 			return
 		}
-		endDate = &end
-	}
-
-	// Создать модель подписки
-	subscription := &models.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   startDate,
-		EndDate:     endDate,
-	}
-
-	// Создать подписку в базе данных
-	if err := h.service.CreateSubscription(subscription); err != nil {
 		http.Error(w, "Не удалось создать подписку", http.StatusInternalServerError)
 		return
 	}
@@ -93,15 +79,18 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 // GetSubscription получает подписку по ID
 //
 //	@Summary		Get subscription by ID
-//	@Description	Get a subscription by its ID
+//	@Description	Get a subscription by its ID. With as_of the subscription is returned as it was at that moment, and embedded resources such as price_history are loaded as of the same moment
 //	@Tags			Subscriptions
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"Subscription ID"
-//	@Success		200	{object}	models.Subscription
-//	@Failure		400	{object}	string	"Invalid subscription ID"
-//	@Failure		404	{object}	string	"Subscription not found"
-//	@Failure		500	{object}	string	"Failed to retrieve subscription"
+//	@Param			id		path		int		true	"Subscription ID"
+//	@Param			fields	query		string	false	"Return only these fields, comma separated, e.g. id,price"
+//	@Param			include	query		string	false	"Embed related resources, comma separated: user, service, price_history"
+//	@Param			as_of	query		string	false	"Return the subscription as of this moment, RFC 3339 (e.g. 2025-03-01T00:00:00Z)"
+//	@Success		200		{object}	models.Subscription
+//	@Failure		400		{object}	string	"Invalid subscription ID or parameters"
+//	@Failure		404		{object}	string	"Subscription not found"
+//	@Failure		500		{object}	string	"Failed to retrieve subscription"
 //	@Router			/subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
 	// Получить ID из параметров URL
@@ -112,8 +101,25 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Получить подписку из базы данных
-	subscription, err := h.service.GetSubscription(uint(id))
+	// Разобрать выбранные поля и связанные ресурсы
+	rep, err := parseRepresentation(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Получить подписку из базы данных в текущем состоянии или на момент as_of
+	var subscription *models.Subscription
+	if asOf != nil {
+		subscription, err = h.service.GetSubscriptionAsOf(r.Context(), uint(id), *asOf)
+	} else {
+		subscription, err = h.service.GetSubscription(r.Context(), uint(id))
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			http.Error(w, "Подписка не найдена", http.StatusNotFound)
@@ -123,9 +129,16 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Сформировать представление подписки
+	items, err := h.render(r.Context(), []models.Subscription{*subscription}, rep, asOf)
+	if err != nil {
+		http.Error(w, "Не удалось получить подписку", http.StatusInternalServerError)
+		return
+	}
+
 	// Вернуть подписку
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(subscription)
+	json.NewEncoder(w).Encode(items[0])
 }
 
 // UpdateSubscription обновляет существующую подписку
@@ -139,6 +152,7 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 //	@Param			subscription	body		models.Subscription	true	"Subscription object"
 //	@Success		200				{object}	models.Subscription
 //	@Failure		400				{object}	string	"Invalid subscription ID or request body"
+//	@Failure		403				{object}	string	"Not allowed to modify subscriptions"
 //	@Failure		404				{object}	string	"Subscription not found"
 //	@Failure		500				{object}	string	"Failed to update subscription"
 //	@Router			/subscriptions/{id} [put]
@@ -151,13 +165,7 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 		return
 	}
 
-	var req struct {
-		ServiceName string `json:"service_name,omitempty"`
-		Price       int    `json:"price,omitempty"`
-		UserID      string `json:"user_id,omitempty"`
-		StartDate   string `json:"start_date,omitempty"`
-		EndDate     string `json:"end_date,omitempty"`
-	}
+	var req updateSubscriptionRequest
 
 	// Декодировать тело запроса
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -165,58 +173,33 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Разобрать дату начала, если предоставлена
-	var startDate *time.Time
-	if req.StartDate != "" {
-		start, err := utils.ParseMonthYear(req.StartDate)
-		if err != nil {
-			http.Error(w, "Неверный формат даты начала, ожидается ММ-ГГГГ", http.StatusBadRequest)
-			return
-		}
-		startDate = &start
-	}
-
-	// Разобрать дату окончания, если предоставлена
-	var endDate *time.Time
-	if req.EndDate != "" {
-		end, err := utils.ParseMonthYear(req.EndDate)
-		if err != nil {
-			http.Error(w, "Неверный формат даты окончания, ожидается ММ-ГГГГ", http.StatusBadRequest)
-			return
-		}
-		endDate = &end
-	}
-
 	// Создать модель подписки с обновленными полями
-	subscription := &models.Subscription{}
-	if req.ServiceName != "" {
-		subscription.ServiceName = req.ServiceName
-	}
-	if req.Price != 0 {
-		subscription.Price = req.Price
-	}
-	if req.UserID != "" {
-		subscription.UserID = req.UserID
-	}
-	if startDate != nil {
-		subscription.StartDate = *startDate
-	}
-	if endDate != nil {
-		subscription.EndDate = endDate
+	subscription, err := req.toModel()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Обновить подписку в базе данных
-	if err := h.service.UpdateSubscription(uint(id), subscription); err != nil {
+	if err := h.service.UpdateSubscription(r.Context(), uint(id), subscription); err != nil {
+		if err == services.ErrForbidden {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if err == gorm.ErrRecordNotFound {
 			http.Error(w, "Подписка не найдена", http.StatusNotFound)
 			return
 		}
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные данные подписки: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Не удалось обновить подписку", http.StatusInternalServerError)
 		return
 	}
 
 	// Получить обновленную подписку
-	updated, err := h.service.GetSubscription(uint(id))
+	updated, err := h.service.GetSubscription(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Не удалось получить обновленную подписку", http.StatusInternalServerError)
 		return
@@ -230,13 +213,14 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 // DeleteSubscription удаляет подписку по ID
 //
 //	@Summary		Delete subscription
-//	@Description	Delete a subscription by its ID
+//	@Description	Move a subscription to the trash. It is excluded from lists and costs and can be restored with POST /subscriptions/{id}/restore until it is purged after TRASH_RETENTION
 //	@Tags			Subscriptions
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int	true	"Subscription ID"
 //	@Success		204	{object}	string	"No content"
 //	@Failure		400	{object}	string	"Invalid subscription ID"
+//	@Failure		403	{object}	string	"Not allowed to modify subscriptions"
 //	@Failure		404	{object}	string	"Subscription not found"
 //	@Failure		500	{object}	string	"Failed to delete subscription"
 //	@Router			/subscriptions/{id} [delete]
@@ -250,7 +234,11 @@ func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.
 	}
 
 	// Удалить подписку из базы данных
-	if err := h.service.DeleteSubscription(uint(id)); err != nil {
+	if err := h.service.DeleteSubscription(r.Context(), uint(id)); err != nil {
+		if err == services.ErrForbidden {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if err == gorm.ErrRecordNotFound {
 			http.Error(w, "Подписка не найдена", http.StatusNotFound)
 			return
@@ -266,59 +254,116 @@ func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.
 // ListSubscriptions получает список подписок с опциональными фильтрами и пагинацией
 //
 //	@Summary		List subscriptions
-//	@Description	Get a list of subscriptions with optional filters and pagination
+//	@Description	Get a list of subscriptions with optional filters and pagination. Pass next_cursor from the previous response as cursor for keyset pagination; page is used only without a cursor
 //	@Tags			Subscriptions
 //	@Accept			json
 //	@Produce		json
 //	@Param			page			query		int		false	"Page number (default: 1)"
-//	@Param			limit			query		int		false	"Items per page (default: 10)"
-//	@Param			user_id			query		string	false	"Filter by user ID"
-//	@Param			service_name	query		string	false	"Filter by service name"
-//	@Success		200				{object}	object{data=[]models.Subscription,pagination=object{page=int,limit=int,total=int64,pages=int}}
+//	@Param			limit			query		int		false	"Items per page (default: 10, max: 100)"
+//	@Param			cursor			query		string	false	"Opaque cursor from pagination.next_cursor"
+//	@Param			include_total	query		bool	false	"Include total and pages (default: true)"
+//	@Param			user_id					query		string	false	"Filter by user IDs, comma separated"
+//	@Param			service_name			query		string	false	"Filter by exact service names, comma separated"
+//	@Param			service_name_contains	query		string	false	"Filter by part of the service name, case-insensitive"
+//	@Param			price_min				query		int		false	"Minimum price"
+//	@Param			price_max				query		int		false	"Maximum price"
+//	@Param			start_from				query		string	false	"Start date from (MM-YYYY or YYYY-MM-DD)"
+//	@Param			start_to				query		string	false	"Start date to (MM-YYYY or YYYY-MM-DD)"
+//	@Param			end_from				query		string	false	"End date from (MM-YYYY or YYYY-MM-DD)"
+//	@Param			end_to					query		string	false	"End date to (MM-YYYY or YYYY-MM-DD)"
+//	@Param			active_at				query		string	false	"Active at least one day in this month (MM-YYYY or YYYY-MM-DD)"
+//	@Param			open_ended				query		bool	false	"Only subscriptions without (true) or with (false) an end date"
+//	@Param			include_deleted			query		bool	false	"Include subscriptions from the trash (default: false)"
+//	@Param			as_of					query		string	false	"Evaluate against subscriptions as they were at this moment, RFC 3339 (e.g. 2025-03-01T00:00:00Z)"
+//	@Param			sort					query		string	false	"Sort by whitelisted fields, e.g. price,-start_date (default: -created_at)"
+//	@Param			fields					query		string	false	"Return only these fields, comma separated, e.g. id,price"
+//	@Param			include					query		string	false	"Embed related resources, comma separated: user, service, price_history"
+//	@Success		200						{object}	object{data=[]models.Subscription,pagination=object{page=int,limit=int,total=int64,pages=int,next_cursor=string}}
+//	@Failure		400						{object}	string	"Invalid filter, sort or cursor"
 //	@Failure		500				{object}	string	"Failed to list subscriptions"
 //	@Router			/subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
 	// Получить параметры запроса
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	userID := r.URL.Query().Get("user_id")
-	serviceName := r.URL.Query().Get("service_name")
+	query := r.URL.Query()
+	params := services.ListParams{
+		Cursor:       query.Get("cursor"),
+		IncludeTotal: true,
+	}
+	params.Page, _ = strconv.Atoi(query.Get("page"))
+	params.Limit, _ = strconv.Atoi(query.Get("limit"))
+	if includeTotal, err := strconv.ParseBool(query.Get("include_total")); err == nil {
+		params.IncludeTotal = includeTotal
+	}
+
+	// Разобрать фильтр и сортировку
+	var err error
+	if params.Filter, err = parseSubscriptionFilter(query); err != nil {
+		http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.Sort, err = models.ParseSort(query.Get("sort")); err != nil {
+		http.Error(w, "Неверная сортировка: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := parseRepresentation(query)
+	if err != nil {
+		http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Установить значения по умолчанию
-	if page <= 0 {
-		page = 1
+	if params.Page <= 0 || params.Cursor != "" {
+		params.Page = 1
 	}
-	if limit <= 0 {
-		limit = 10
+	if params.Limit <= 0 {
+		params.Limit = 10
 	}
 
 	// Получить список подписок
-	subscriptions, total, err := h.service.ListSubscriptions(page, limit, userID, serviceName)
+	result, err := h.service.ListSubscriptions(r.Context(), params)
 	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Не удалось получить список подписок", http.StatusInternalServerError)
 		return
 	}
 
-	// Вычислить общее количество страниц
-	pages := int((total + int64(limit) - 1) / int64(limit))
+	// Сформировать представления подписок
+	items, err := h.render(r.Context(), result.Subscriptions, rep, params.Filter.AsOf)
+	if err != nil {
+		http.Error(w, "Не удалось получить список подписок", http.StatusInternalServerError)
+		return
+	}
 
 	// Подготовить ответ
 	response := struct {
-		Data       []models.Subscription `json:"data"`
+		Data       []any `json:"data"`
 		Pagination struct {
-			Page  int   `json:"page"`
-			Limit int   `json:"limit"`
-			Total int64 `json:"total"`
-			Pages int   `json:"pages"`
+			Page       int    `json:"page,omitempty"`
+			Limit      int    `json:"limit"`
+			Total      *int64 `json:"total,omitempty"`
+			Pages      *int   `json:"pages,omitempty"`
+			NextCursor string `json:"next_cursor,omitempty"`
 		} `json:"pagination"`
 	}{
-		Data: subscriptions,
+		Data: items,
 	}
 
-	response.Pagination.Page = page
-	response.Pagination.Limit = limit
-	response.Pagination.Total = total
-	response.Pagination.Pages = pages
+	// Номер страницы имеет смысл только при пагинации по смещению
+	if params.Cursor == "" {
+		response.Pagination.Page = params.Page
+	}
+	response.Pagination.Limit = params.Limit
+	response.Pagination.NextCursor = result.NextCursor
+
+	// Вычислить общее количество страниц
+	if result.Total != nil {
+		pages := int((*result.Total + int64(params.Limit) - 1) / int64(params.Limit))
+		response.Pagination.Total = result.Total
+		response.Pagination.Pages = &pages
+	}
 
 	// Вернуть подписки
 	w.Header().Set("Content-Type", "application/json")
@@ -328,40 +373,113 @@ func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.R
 // CalculateTotalCost вычисляет общую стоимость подписок с опциональными фильтрами
 //
 //	@Summary		Calculate total cost
-//	@Description	Calculate the total cost of subscriptions with optional filters
+//	@Description	Calculate the total cost of subscriptions with optional filters. Accepts the same filter parameters as GET /subscriptions. The currency is taken from the tenant settings
 //	@Tags			Subscriptions
 //	@Accept			json
 //	@Produce		json
-//	@Param			user_id			query		string	false	"Filter by user ID"
-//	@Param			service_name	query		string	false	"Filter by service name"
-//	@Param			from			query		string	false	"Start date in MM-YYYY format"
-//	@Param			to				query		string	false	"End date in MM-YYYY format"
-//	@Success		200				{object}	object{total_cost=int}
-//	@Failure		400				{object}	string	"Failed to calculate total cost"
-//	@Failure		500				{object}	string	"Failed to calculate total cost"
+//	@Param			user_id					query		string	false	"Filter by user IDs, comma separated"
+//	@Param			service_name			query		string	false	"Filter by exact service names, comma separated"
+//	@Param			service_name_contains	query		string	false	"Filter by part of the service name, case-insensitive"
+//	@Param			price_min				query		int		false	"Minimum price"
+//	@Param			price_max				query		int		false	"Maximum price"
+//	@Param			start_from				query		string	false	"Start date from (MM-YYYY or YYYY-MM-DD)"
+//	@Param			start_to				query		string	false	"Start date to (MM-YYYY or YYYY-MM-DD)"
+//	@Param			end_from				query		string	false	"End date from (MM-YYYY or YYYY-MM-DD)"
+//	@Param			end_to					query		string	false	"End date to (MM-YYYY or YYYY-MM-DD)"
+//	@Param			active_at				query		string	false	"Active at least one day in this month (MM-YYYY or YYYY-MM-DD)"
+//	@Param			open_ended				query		bool	false	"Only subscriptions without (true) or with (false) an end date"
+//	@Param			include_deleted			query		bool	false	"Include subscriptions from the trash (default: false)"
+//	@Param			as_of					query		string	false	"Evaluate against subscriptions as they were at this moment, RFC 3339 (e.g. 2025-03-01T00:00:00Z)"
+//	@Param			from					query		string	false	"Start date in MM-YYYY format"
+//	@Param			to						query		string	false	"End date in MM-YYYY format"
+//	@Success		200						{object}	object{total_cost=int,currency=string}
+//	@Failure		400						{object}	string	"Failed to calculate total cost"
+//	@Failure		500						{object}	string	"Failed to calculate total cost"
 //	@Router			/subscriptions/cost [get]
 func (h *SubscriptionHandler) CalculateTotalCost(w http.ResponseWriter, r *http.Request) {
 	// Получить параметры запроса
-	userID := r.URL.Query().Get("user_id")
-	serviceName := r.URL.Query().Get("service_name")
 	from := r.URL.Query().Get("from")
 	to := r.URL.Query().Get("to")
+	filter, err := parseSubscriptionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Вычислить общую стоимость
-	totalCost, err := h.service.CalculateTotalCost(userID, serviceName, from, to)
+	totalCost, err := h.service.CalculateTotalCost(r.Context(), filter, from, to)
 	if err != nil {
-		http.Error(w, "Не удалось вычислить общую стоимость: "+err.Error(), http.StatusBadRequest)
+		if services.IsValidationError(err) {
+			http.Error(w, "Не удалось вычислить общую стоимость: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось вычислить общую стоимость", http.StatusInternalServerError)
 		return
 	}
 
-	// Подготовить ответ
+	// Подготовить ответ в валюте организации
 	response := struct {
-		TotalCost int `json:"total_cost"`
+		TotalCost int    `json:"total_cost"`
+		Currency  string `json:"currency,omitempty"`
 	}{
 		TotalCost: totalCost,
 	}
+	if current, ok := tenant.From(r.Context()); ok {
+		response.Currency = current.Currency
+	}
 
 	// Вернуть общую стоимость
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// SearchSubscriptions ищет подписки с допуском опечаток
+//
+//	@Summary		Search subscriptions
+//	@Description	Fuzzy search over service names, notes and tags with typo tolerance. Results are ranked by relevance and can be narrowed with the same filters as GET /subscriptions. Typo tolerance requires PostgreSQL with pg_trgm; with the SQLite and memory drivers the query matches a case-insensitive substring only
+//	@Tags			Subscriptions
+//	@Accept			json
+//	@Produce		json
+//	@Param			q				query		string	true	"Search query, e.g. netflx or yandex plus"
+//	@Param			limit			query		int		false	"Maximum number of results (default: 20, max: 100)"
+//	@Param			user_id			query		string	false	"Filter by user IDs, comma separated"
+//	@Param			service_name	query		string	false	"Filter by exact service names, comma separated"
+//	@Success		200				{object}	object{data=[]models.SubscriptionSearchHit}
+//	@Failure		400				{object}	string	"Invalid query or filter"
+//	@Failure		500				{object}	string	"Failed to search subscriptions"
+//	@Router			/subscriptions/search [get]
+func (h *SubscriptionHandler) SearchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	// Получить параметры запроса
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter, err := parseSubscriptionFilter(query)
+	if err != nil {
+		http.Error(w, "Неверный фильтр: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Найти подписки
+	hits, err := h.service.SearchSubscriptions(r.Context(), query.Get("q"), filter, limit)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные параметры поиска: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось выполнить поиск подписок", http.StatusInternalServerError)
+		return
+	}
+
+	// Подготовить ответ
+	if hits == nil {
+		hits = []models.SubscriptionSearchHit{}
+	}
+	response := struct {
+		Data []models.SubscriptionSearchHit `json:"data"`
+	}{
+		Data: hits,
+	}
+
+	// Вернуть результаты поиска
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"effective-mobile-subscription/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// TenantHandler обрабатывает HTTP запросы настроек организации
+type TenantHandler struct {
+	service *services.TenantService
+}
+
+// NewTenantHandler создает новый обработчик организаций
+func NewTenantHandler(service *services.TenantService) *TenantHandler {
+	return &TenantHandler{service: service}
+}
+
+// updateTenantRequest тело запроса на изменение настроек организации
+type updateTenantRequest struct {
+	Currency string `json:"currency,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// GetTenant получает организацию запроса
+//
+//	@Summary		Get the current tenant
+//	@Description	Get the tenant of the caller with its settings. The tenant comes from the API key or the tenant_id JWT claim; without authentication it is taken from the X-Tenant-ID header and defaults to "default"
+//	@Tags			Tenant
+//	@Produce		json
+//	@Success		200	{object}	models.Tenant
+//	@Failure		400	{object}	string	"Unknown tenant"
+//	@Failure		401	{object}	string	"Authentication required"
+//	@Failure		403	{object}	string	"Tenant does not match credentials"
+//	@Security		BearerAuth
+//	@Router			/tenant [get]
+func (h *TenantHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	current, err := h.service.CurrentTenant(r.Context())
+	if err != nil {
+		http.Error(w, "Организация не определена", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current)
+}
+
+// UpdateTenant изменяет настройки организации запроса
+//
+//	@Summary		Update tenant settings
+//	@Description	Change the currency (ISO 4217 code) and time zone (IANA name) of the caller's tenant. Omitted fields are left unchanged. The currency is reported with costs; the time zone defines the day boundary for subscription.ending_soon notifications. Requires the admin role
+//	@Tags			Tenant
+//	@Accept			json
+//	@Produce		json
+//	@Param			settings	body		object{currency=string,timezone=string}	true	"Tenant settings"
+//	@Success		200			{object}	models.Tenant
+//	@Failure		400			{object}	string	"Invalid request body"
+//	@Failure		401			{object}	string	"Authentication required"
+//	@Failure		403			{object}	string	"Admin role required"
+//	@Failure		404			{object}	string	"Tenant not found"
+//	@Failure		500			{object}	string	"Failed to update tenant"
+//	@Security		BearerAuth
+//	@Router			/tenant [put]
+func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	var req updateTenantRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.service.UpdateSettings(r.Context(), req.Currency, req.Timezone)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные настройки организации: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Организация не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось изменить настройки организации", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListTrash получает подписки из корзины
+//
+//	@Summary		List deleted subscriptions
+//	@Description	Get subscriptions in the trash, most recently deleted first. Deleted subscriptions are purged after TRASH_RETENTION
+//	@Tags			Subscriptions
+//	@Produce		json
+//	@Param			page	query		int	false	"Page number (default: 1)"
+//	@Param			limit	query		int	false	"Items per page (default: 10)"
+//	@Success		200		{object}	object{data=[]models.Subscription,pagination=object{page=int,limit=int}}
+//	@Failure		500		{object}	string	"Failed to list deleted subscriptions"
+//	@Router			/subscriptions/trash [get]
+func (h *SubscriptionHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	// Получить параметры пагинации
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	subscriptions, err := h.service.ListDeletedSubscriptions(r.Context(), page, limit)
+	if err != nil {
+		http.Error(w, "Не удалось получить удаленные подписки", http.StatusInternalServerError)
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []models.Subscription{}
+	}
+
+	response := struct {
+		Data       []models.Subscription `json:"data"`
+		Pagination struct {
+			Page  int `json:"page"`
+			Limit int `json:"limit"`
+		} `json:"pagination"`
+	}{
+		Data: subscriptions,
+	}
+	response.Pagination.Page = page
+	response.Pagination.Limit = limit
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreSubscription возвращает подписку из корзины
+//
+//	@Summary		Restore subscription
+//	@Description	Restore a subscription from the trash. The restore is published as subscription.updated and recorded in the audit log
+//	@Tags			Subscriptions
+//	@Produce		json
+//	@Param			id	path		int	true	"Subscription ID"
+//	@Success		200	{object}	models.Subscription
+//	@Failure		400	{object}	string	"Invalid subscription ID"
+//	@Failure		403	{object}	string	"Not allowed to modify subscriptions"
+//	@Failure		404	{object}	string	"Subscription not found in the trash"
+//	@Failure		500	{object}	string	"Failed to restore subscription"
+//	@Router			/subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID подписки", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := h.service.RestoreSubscription(r.Context(), uint(id))
+	if err != nil {
+		if err == services.ErrForbidden {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Подписка не найдена в корзине", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось восстановить подписку", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(restored)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+// Пустая корзина возвращается как пустой список, а не null
+func TestListTrashEmpty(t *testing.T) {
+	handler := NewSubscriptionHandler(services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t))))
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+
+	w := httptest.NewRecorder()
+	handler.ListTrash(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/subscriptions/trash", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"data":[]`) {
+		t.Fatalf("ответ %d %s, ожидался пустой список data", w.Code, w.Body.String())
+	}
+}
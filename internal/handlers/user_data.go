@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// UserDataHandler обрабатывает HTTP запросы субъекта данных: выгрузку и обезличивание данных пользователя
+type UserDataHandler struct {
+	service *services.UserDataService
+}
+
+// NewUserDataHandler создает новый обработчик данных пользователя
+func NewUserDataHandler(service *services.UserDataService) *UserDataHandler {
+	return &UserDataHandler{service: service}
+}
+
+// userDataFile файл архива с данными пользователя: строки для JSON и CSV
+type userDataFile struct {
+	name   string
+	data   any
+	header []string
+	rows   [][]string
+}
+
+// ExportUserData выгружает все данные пользователя архивом ZIP
+//
+//	@Summary		Export user data
+//	@Description	Download a ZIP archive with everything stored about a user in the tenant: subscriptions (including the trash), price history, subscription versions, audit entries and subscription events. Every dataset is included as JSON and as CSV. Available to admins and to the user themselves
+//	@Tags			Users
+//	@Produce		application/zip
+//	@Param			user_id	path		string	true	"User ID (UUID)"
+//	@Success		200		{file}		file
+//	@Failure		400		{object}	string	"Invalid user ID"
+//	@Failure		403		{object}	string	"Access to the user's data denied"
+//	@Failure		500		{object}	string	"Failed to export user data"
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/data-export [get]
+func (h *UserDataHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["user_id"]
+
+	data, err := h.service.ExportUserData(r.Context(), userID)
+	if err != nil {
+		writeUserDataError(w, err, "Не удалось выгрузить данные пользователя")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="user-`+userID+`.zip"`)
+
+	// Данные уже прочитаны, поэтому ошибкой может быть только обрыв соединения
+	archive := zip.NewWriter(w)
+	for _, file := range userDataFiles(data) {
+		if err := writeUserDataFile(archive, file); err != nil {
+			slog.Error("Выгрузка данных пользователя прервана", "ошибка", err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		slog.Error("Не удалось завершить выгрузку данных пользователя", "ошибка", err)
+	}
+}
+
+// EraseUserData обезличивает все данные пользователя
+//
+//	@Summary		Erase user data
+//	@Description	Anonymize everything stored about a user in the tenant: the user ID is replaced with a random pseudonym and notes and tags are removed from subscriptions, subscription versions, audit entries, subscription events, the outbox and webhook deliveries. Service names, prices and dates stay, so aggregate cost statistics do not change. Returns the pseudonym and the number of anonymized records. Available to admins and to the user themselves
+//	@Tags			Users
+//	@Produce		json
+//	@Param			user_id	path		string	true	"User ID (UUID)"
+//	@Success		200		{object}	models.UserErasure
+//	@Failure		400		{object}	string	"Invalid user ID"
+//	@Failure		403		{object}	string	"Access to the user's data denied"
+//	@Failure		500		{object}	string	"Failed to erase user data"
+//	@Security		BearerAuth
+//	@Router			/users/{user_id}/data [delete]
+func (h *UserDataHandler) EraseUserData(w http.ResponseWriter, r *http.Request) {
+	erasure, err := h.service.EraseUserData(r.Context(), mux.Vars(r)["user_id"])
+	if err != nil {
+		writeUserDataError(w, err, "Не удалось обезличить данные пользователя")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(erasure)
+}
+
+// writeUserDataError возвращает ошибку операции над данными пользователя
+func writeUserDataError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case services.IsValidationError(err):
+		http.Error(w, "Неверный ID пользователя: "+err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, "Нет доступа к данным пользователя", http.StatusForbidden)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// writeUserDataFile записывает набор данных в архив файлами name.json и name.csv
+func writeUserDataFile(archive *zip.Writer, file userDataFile) error {
+	out, err := archive.Create(file.name + ".json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file.data); err != nil {
+		return err
+	}
+
+	out, err = archive.Create(file.name + ".csv")
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(out)
+	if err := writer.Write(file.header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(file.rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// userDataFiles раскладывает данные пользователя по файлам архива
+func userDataFiles(data *models.UserData) []userDataFile {
+	subscriptions := userDataFile{
+		name:   "subscriptions",
+		data:   data.Subscriptions,
+		header: []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "notes", "tags", "created_at", "updated_at", "deleted_at"},
+	}
+	for _, s := range data.Subscriptions {
+		deletedAt := ""
+		if s.DeletedAt.Valid {
+			deletedAt = s.DeletedAt.Time.Format(time.RFC3339)
+		}
+		subscriptions.rows = append(subscriptions.rows, []string{
+			formatID(s.ID), s.ServiceName, strconv.Itoa(s.Price), s.UserID,
+			utils.FormatMonthYear(s.StartDate), formatMonth(s.EndDate), s.Notes, strings.Join(s.Tags, ","),
+			s.CreatedAt.Format(time.RFC3339), s.UpdatedAt.Format(time.RFC3339), deletedAt,
+		})
+	}
+
+	priceHistory := userDataFile{
+		name:   "price_history",
+		data:   data.PriceHistory,
+		header: []string{"subscription_id", "old_price", "price", "changed_at"},
+	}
+	for _, change := range data.PriceHistory {
+		oldPrice := ""
+		if change.OldPrice != nil {
+			oldPrice = strconv.Itoa(*change.OldPrice)
+		}
+		priceHistory.rows = append(priceHistory.rows, []string{
+			formatID(change.SubscriptionID), oldPrice, strconv.Itoa(change.Price), change.ChangedAt.Format(time.RFC3339),
+		})
+	}
+
+	versions := userDataFile{
+		name:   "subscription_versions",
+		data:   data.Versions,
+		header: []string{"version_id", "subscription_id", "service_name", "price", "user_id", "start_date", "end_date", "notes", "tags", "deleted_at", "valid_from", "valid_to"},
+	}
+	for _, v := range data.Versions {
+		versions.rows = append(versions.rows, []string{
+			formatID(v.VersionID), formatID(v.SubscriptionID), v.ServiceName, strconv.Itoa(v.Price), v.UserID,
+			utils.FormatMonthYear(v.StartDate), formatMonth(v.EndDate), v.Notes, strings.Join(v.Tags, ","),
+			formatTime(v.DeletedAt), v.ValidFrom.Format(time.RFC3339), formatTime(v.ValidTo),
+		})
+	}
+
+	auditEntries := userDataFile{
+		name:   "audit_entries",
+		data:   data.AuditEntries,
+		header: []string{"id", "subscription_id", "user_id", "action", "actor", "request_id", "changes", "created_at"},
+	}
+	for _, entry := range data.AuditEntries {
+		auditEntries.rows = append(auditEntries.rows, []string{
+			formatID(entry.ID), formatID(entry.SubscriptionID), entry.UserID, entry.Action, entry.Actor,
+			entry.RequestID, string(entry.Changes), entry.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	events := userDataFile{
+		name:   "events",
+		data:   data.Events,
+		header: []string{"sequence", "event_id", "type", "user_id", "payload", "created_at"},
+	}
+	for _, event := range data.Events {
+		events.rows = append(events.rows, []string{
+			strconv.FormatInt(event.Sequence, 10), event.EventID, event.Type, event.UserID,
+			string(event.Payload), event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return []userDataFile{subscriptions, priceHistory, versions, auditEntries, events}
+}
+
+// formatID форматирует ID записи для CSV
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// formatMonth форматирует необязательную дату в формате ММ-ГГГГ
+func formatMonth(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return utils.FormatMonthYear(*date)
+}
+
+// formatTime форматирует необязательное время в формате RFC 3339
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// WebhookHandler обрабатывает HTTP запросы для вебхуков
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+// NewWebhookHandler создает новый обработчик вебхуков
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// createWebhookRequest тело запроса на регистрацию вебхука
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// createWebhookResponse зарегистрированный вебхук вместе с секретом, который возвращается только один раз
+type createWebhookResponse struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook регистрирует новый вебхук
+//
+//	@Summary		Register a webhook
+//	@Description	Register a URL that receives signed POST callbacks for subscription events: subscription.created, subscription.updated, subscription.deleted, subscription.ending_soon, subscription.price_changed. Empty event_types subscribes to all events. Each callback carries X-Webhook-Signature: sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body). The secret is generated when omitted and is returned only in this response
+//	@Tags			Webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body		object{url=string,secret=string,event_types=[]string}	true	"Webhook"
+//	@Success		201		{object}	object{id=int,url=string,secret=string,event_types=[]string,active=bool,created_at=string,updated_at=string}
+//	@Failure		400		{object}	string	"Invalid request body"
+//	@Failure		403		{object}	string	"Admin role required"
+//	@Failure		500		{object}	string	"Failed to create webhook"
+//	@Router			/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+
+	// Декодировать тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	webhook := &models.Webhook{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+	}
+
+	// Сохранить вебхук
+	if err := h.service.CreateWebhook(r.Context(), webhook); err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные данные вебхука: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Не удалось создать вебхук", http.StatusInternalServerError)
+		return
+	}
+
+	// Вернуть вебхук вместе с секретом
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createWebhookResponse{Webhook: *webhook, Secret: webhook.Secret})
+}
+
+// ListWebhooks получает список вебхуков
+//
+//	@Summary		List webhooks
+//	@Description	Get all registered webhooks. Secrets are not returned
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Success		200	{array}		models.Webhook
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		500	{object}	string	"Failed to list webhooks"
+//	@Router			/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "Не удалось получить список вебхуков", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// GetWebhook получает вебхук по ID
+//
+//	@Summary		Get webhook by ID
+//	@Description	Get a registered webhook. The secret is not returned
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id	path		int	true	"Webhook ID"
+//	@Success		200	{object}	models.Webhook
+//	@Failure		400	{object}	string	"Invalid webhook ID"
+//	@Failure		404	{object}	string	"Webhook not found"
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		500	{object}	string	"Failed to retrieve webhook"
+//	@Router			/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(r.Context(), uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Вебхук не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось получить вебхук", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// DeleteWebhook удаляет вебхук
+//
+//	@Summary		Delete webhook
+//	@Description	Delete a webhook together with its delivery history
+//	@Tags			Webhooks
+//	@Param			id	path	int	true	"Webhook ID"
+//	@Success		204
+//	@Failure		400	{object}	string	"Invalid webhook ID"
+//	@Failure		404	{object}	string	"Webhook not found"
+//	@Failure		403	{object}	string	"Admin role required"
+//	@Failure		500	{object}	string	"Failed to delete webhook"
+//	@Router			/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), uint(id)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Вебхук не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось удалить вебхук", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries получает историю доставок вебхука
+//
+//	@Summary		List webhook deliveries
+//	@Description	Get deliveries of a webhook, newest first, with attempt count, last response status and error
+//	@Tags			Webhooks
+//	@Produce		json
+//	@Param			id		path		int		true	"Webhook ID"
+//	@Param			status	query		string	false	"Filter by status: pending, succeeded, failed"
+//	@Param			page	query		int		false	"Page number (default: 1)"
+//	@Param			limit	query		int		false	"Items per page (default: 10)"
+//	@Success		200		{object}	object{data=[]models.WebhookDelivery,pagination=object{page=int,limit=int}}
+//	@Failure		400		{object}	string	"Invalid webhook ID or parameters"
+//	@Failure		404		{object}	string	"Webhook not found"
+//	@Failure		403		{object}	string	"Admin role required"
+//	@Failure		500		{object}	string	"Failed to list deliveries"
+//	@Router			/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID вебхука", http.StatusBadRequest)
+		return
+	}
+
+	// Получить параметры пагинации
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), uint(id), query.Get("status"), page, limit)
+	if err != nil {
+		if services.IsValidationError(err) {
+			http.Error(w, "Неверные параметры запроса: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "Вебхук не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Не удалось получить доставки вебхука", http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		Data       []models.WebhookDelivery `json:"data"`
+		Pagination struct {
+			Page  int `json:"page"`
+			Limit int `json:"limit"`
+		} `json:"pagination"`
+	}{
+		Data: deliveries,
+	}
+	response.Pagination.Page = page
+	response.Pagination.Limit = limit
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
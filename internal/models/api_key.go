@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey ключ доступа к API. Хранится только SHA-256 хеш ключа, сам ключ возвращается один раз при создании
+type APIKey struct {
+	// The unique identifier of the API key
+	// Read Only: true
+	// Example: 1
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Организация, к данным которой дает доступ ключ
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// Human-readable name of the key
+	// Example: billing-sync
+	Name string `gorm:"not null" json:"name"`
+
+	// First characters of the key to recognize it in lists
+	// Example: sk_3f1c2a9e
+	Prefix string `gorm:"size:16;not null" json:"prefix"`
+
+	// SHA-256 хеш ключа в шестнадцатеричном виде
+	KeyHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+
+	// Identity of the caller, the user UUID for regular users
+	// Example: 550e8400-e29b-41d4-a716-446655440000
+	Subject string `gorm:"not null" json:"subject"`
+
+	// Roles granted to the key
+	// Example: ["admin"]
+	Roles []string `gorm:"serializer:json;type:jsonb;not null;default:'[]'" json:"roles"`
+
+	// When the key expires, empty for keys without expiry
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// When the key was revoked
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	// When the key was last used
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// The creation timestamp
+	// Read Only: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы ключей API
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Usable сообщает, что ключ не отозван и не истек на момент now
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия с подписками, записываемые в журнал аудита
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditActions все действия журнала аудита
+var AuditActions = []string{AuditActionCreate, AuditActionUpdate, AuditActionDelete}
+
+// AuditEntry запись журнала аудита об изменении подписки. Записи только добавляются
+type AuditEntry struct {
+	// The unique identifier of the audit entry
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Организация подписки
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// The changed subscription
+	// Example: 1
+	SubscriptionID uint `gorm:"not null;index" json:"subscription_id"`
+
+	// The owner of the subscription; on transfer - the new owner
+	// Example: 60601fee-2bf1-4721-ae6f-7636e79a0cba
+	UserID string `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// The action: create, update or delete
+	// Example: update
+	Action string `gorm:"size:16;not null" json:"action"`
+
+	// Who made the change: the subject of the credentials, empty when authentication is disabled
+	// Example: 60601fee-2bf1-4721-ae6f-7636e79a0cba
+	Actor string `gorm:"index" json:"actor"`
+
+	// The ID of the request that made the change
+	// Example: 4f9c2a7e1b3d4c5a8e6f7a9b0c1d2e3f
+	RequestID string `gorm:"index" json:"request_id,omitempty"`
+
+	// Changed fields with values before and after, e.g. {"price": {"before": 400, "after": 500}}
+	Changes json.RawMessage `gorm:"type:jsonb;not null" json:"changes" swaggertype:"object"`
+
+	// When the change was made
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// AuditChange значения поля до и после изменения; для создания Before пусто, для удаления - After
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditFilter фильтр журнала аудита; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	SubscriptionID uint
+	UserID         string
+	Actor          string
+	Action         string
+	RequestID      string
+	From           *time.Time
+	To             *time.Time
+}
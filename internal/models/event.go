@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий жизненного цикла подписок
+const (
+	EventSubscriptionCreated      = "subscription.created"
+	EventSubscriptionUpdated      = "subscription.updated"
+	EventSubscriptionDeleted      = "subscription.deleted"
+	EventSubscriptionEndingSoon   = "subscription.ending_soon"
+	EventSubscriptionPriceChanged = "subscription.price_changed"
+)
+
+// EventTypes все типы событий подписок
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionEndingSoon,
+	EventSubscriptionPriceChanged,
+}
+
+// SubscriptionEvent событие жизненного цикла подписки
+type SubscriptionEvent struct {
+	// Уникальный ID события
+	ID string `json:"id"`
+
+	// Организация подписки
+	TenantID string `json:"tenant_id"`
+
+	// Тип события, например subscription.created
+	Type string `json:"type"`
+
+	// Время события
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// Состояние подписки после события; для удаления - последнее состояние
+	Subscription *Subscription `json:"subscription"`
+
+	// Состояние подписки до изменения; задано для updated и price_changed
+	Previous *Subscription `json:"previous,omitempty"`
+}
+
+// SubscriptionReminder отметка об отправленном напоминании об окончании подписки.
+// Напоминание отправляется один раз для каждой даты окончания
+type SubscriptionReminder struct {
+	SubscriptionID uint          `gorm:"primaryKey;autoIncrement:false"`
+	Subscription   *Subscription `gorm:"constraint:OnDelete:CASCADE"`
+	EndDate        time.Time     `gorm:"primaryKey"`
+	TenantID       string        `gorm:"size:64;not null;default:'default';index"`
+	SentAt         time.Time     `gorm:"not null"`
+}
+
+// StoredEvent событие подписки, сохраненное в журнале событий для потоковой передачи.
+// Sequence монотонно возрастает и служит ID события SSE для возобновления потока
+type StoredEvent struct {
+	// Порядковый номер события
+	Sequence int64 `gorm:"primaryKey;autoIncrement" json:"sequence"`
+
+	// ID исходного события; уникален, поэтому повторно опубликованное событие не попадает в журнал дважды
+	EventID string `gorm:"size:32;not null;uniqueIndex" json:"event_id"`
+
+	// Организация события, поток передает получателям только события их организации
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// Тип события
+	Type string `gorm:"not null" json:"type"`
+
+	// ID пользователя подписки, для фильтрации потока
+	UserID string `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// Событие в формате JSON
+	Payload json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+
+	// Время сохранения события
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName возвращает имя таблицы журнала событий
+func (StoredEvent) TableName() string {
+	return "subscription_events"
+}
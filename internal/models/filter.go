@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// SubscriptionFilter условия отбора подписок.
+// Один и тот же фильтр используется для списка, подсчета, выгрузки и расчета стоимости,
+// поэтому эти запросы всегда отбирают одинаковые подписки
+type SubscriptionFilter struct {
+	// Точное совпадение с одним из ID пользователей
+	UserIDs []string
+
+	// Точное совпадение с одним из названий сервисов
+	ServiceNames []string
+
+	// Частичное совпадение названия сервиса без учета регистра
+	ServiceNameContains string
+
+	// Диапазон цены включительно
+	PriceMin *int
+	PriceMax *int
+
+	// Диапазон даты начала включительно
+	StartFrom *time.Time
+	StartTo   *time.Time
+
+	// Диапазон даты окончания включительно
+	EndFrom *time.Time
+	EndTo   *time.Time
+
+	// Подписка действует хотя бы один день в месяце, которому принадлежит дата
+	ActiveAt *time.Time
+
+	// true - только бессрочные подписки, false - только с датой окончания
+	OpenEnded *bool
+
+	// Учитывать удаленные подписки, находящиеся в корзине; по умолчанию они не отбираются
+	IncludeDeleted bool
+
+	// Момент времени, на который отбираются подписки по истории версий; nil - текущее состояние
+	AsOf *time.Time
+}
+
+// IsEmpty сообщает, что фильтр не содержит ни одного условия
+func (f *SubscriptionFilter) IsEmpty() bool {
+	return len(f.UserIDs) == 0 && len(f.ServiceNames) == 0 && f.ServiceNameContains == "" &&
+		f.PriceMin == nil && f.PriceMax == nil &&
+		f.StartFrom == nil && f.StartTo == nil && f.EndFrom == nil && f.EndTo == nil &&
+		f.ActiveAt == nil && f.OpenEnded == nil
+}
+
+// ParseFilterDate разбирает необязательную дату фильтра в формате ММ-ГГГГ или ГГГГ-ММ-ДД.
+// Дата, заданная месяцем, приводится к последнему дню месяца, если endOfMonth установлен, иначе к первому
+func ParseFilterDate(value string, endOfMonth bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, wholeMonth, err := utils.ParseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	if wholeMonth && endOfMonth {
+		date = utils.GetLastDayOfMonth(date)
+	}
+	return &date, nil
+}
+
+// SortField поле сортировки списка подписок
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortableFields поля, по которым разрешена сортировка
+var SortableFields = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at"}
+
+// DefaultSort сортировка списка подписок по умолчанию
+var DefaultSort = []SortField{{Field: "created_at", Desc: true}}
+
+// ParseSort разбирает сортировку вида "price,-start_date"; минус означает сортировку по убыванию
+func ParseSort(value string) ([]SortField, error) {
+	if value == "" {
+		return DefaultSort, nil
+	}
+
+	var sort []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !isSortable(field.Field) {
+			return nil, fmt.Errorf("сортировка по полю %q не поддерживается, доступны: %s", field.Field, strings.Join(SortableFields, ", "))
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("поле %q указано в сортировке несколько раз", field.Field)
+		}
+		seen[field.Field] = true
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+// FormatSort преобразует сортировку обратно в строку вида "price,-start_date"
+func FormatSort(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, field := range sort {
+		parts[i] = field.Field
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// isSortable проверяет, что по полю разрешена сортировка
+func isSortable(field string) bool {
+	return contains(SortableFields, field)
+}
+
+// SubscriptionSearchHit подписка, найденная поиском, с оценкой релевантности
+type SubscriptionSearchHit struct {
+	Subscription
+
+	// Релевантность от 0 до 1, чем больше, тем лучше совпадение
+	Rank float64 `json:"rank"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey хранит первый ответ на запрос с заголовком Idempotency-Key
+type IdempotencyKey struct {
+	// Организация, в которой выполнен запрос; ключи разных организаций не пересекаются
+	TenantID string `gorm:"primaryKey;size:64;default:'default'" json:"-"`
+
+	// Значение заголовка Idempotency-Key
+	Key string `gorm:"primaryKey;size:255" json:"key"`
+
+	// SHA-256 хеш метода, пути и тела запроса
+	RequestHash string `gorm:"size:64;not null" json:"request_hash"`
+
+	// Признак того, что ответ сохранен и запрос больше не выполняется
+	Completed bool `gorm:"not null;default:false" json:"completed"`
+
+	// Сохраненный HTTP статус ответа
+	StatusCode int `json:"status_code"`
+
+	// Сохраненный Content-Type ответа
+	ContentType string `json:"content_type"`
+
+	// Сохраненное тело ответа
+	ResponseBody []byte `json:"-"`
+
+	// Время создания записи
+	CreatedAt time.Time `json:"created_at"`
+
+	// Время, после которого ключ можно использовать повторно
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Связанные ресурсы, которые можно встроить в ответ параметром include
+const (
+	IncludeUser         = "user"
+	IncludeService      = "service"
+	IncludePriceHistory = "price_history"
+)
+
+// IncludableResources связанные ресурсы, доступные в параметре include
+var IncludableResources = []string{IncludeUser, IncludeService, IncludePriceHistory}
+
+// SubscriptionFields поля подписки, доступные в параметре fields
+var SubscriptionFields = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "notes", "tags", "created_at", "updated_at"}
+
+// UserSummary сводка по подпискам пользователя
+type UserSummary struct {
+	// The UUID of the user
+	ID string `json:"id"`
+
+	// Number of subscriptions of the user
+	SubscriptionsCount int64 `json:"subscriptions_count"`
+
+	// Number of subscriptions without an end date or ending in the future
+	ActiveSubscriptions int64 `json:"active_subscriptions"`
+
+	// Sum of monthly prices of active subscriptions
+	MonthlyCost int64 `json:"monthly_cost"`
+}
+
+// ServiceSummary сводка по подпискам на сервис
+type ServiceSummary struct {
+	// The name of the service
+	Name string `json:"name"`
+
+	// Number of distinct subscribed users
+	SubscribersCount int64 `json:"subscribers_count"`
+
+	// Minimum, maximum and average price among subscriptions to the service
+	MinPrice int     `json:"min_price"`
+	MaxPrice int     `json:"max_price"`
+	AvgPrice float64 `json:"avg_price"`
+}
+
+// SubscriptionIncludes связанные ресурсы для набора подписок
+type SubscriptionIncludes struct {
+	Users        map[string]UserSummary
+	Services     map[string]ServiceSummary
+	PriceHistory map[uint][]PriceChange
+}
+
+// ParseFields разбирает список полей вида "id,service_name,price". Неизвестные поля дают ошибку
+func ParseFields(value string) ([]string, error) {
+	return parseList(value, SubscriptionFields, "поле")
+}
+
+// ParseIncludes разбирает список связанных ресурсов вида "user,service". Неизвестные ресурсы дают ошибку
+func ParseIncludes(value string) ([]string, error) {
+	return parseList(value, IncludableResources, "связанный ресурс")
+}
+
+// parseList разбирает список через запятую и проверяет, что все значения разрешены
+func parseList(value string, allowed []string, kind string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var values []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || seen[part] {
+			continue
+		}
+		if !contains(allowed, part) {
+			return nil, fmt.Errorf("неизвестный %s %q, доступны: %s", kind, part, strings.Join(allowed, ", "))
+		}
+		seen[part] = true
+		values = append(values, part)
+	}
+
+	return values, nil
+}
+
+// contains проверяет, что значение есть в списке
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage событие, записанное в outbox в одной транзакции с изменением подписки.
+// Фоновая задача передает неопубликованные события получателям и отмечает их опубликованными
+type OutboxMessage struct {
+	// Порядковый номер сообщения
+	ID uint64 `gorm:"primaryKey"`
+
+	// ID события, получатели используют его для исключения повторов
+	EventID string `gorm:"size:32;not null;uniqueIndex"`
+
+	// Организация события
+	TenantID string `gorm:"size:64;not null;default:'default';index"`
+
+	// Тип события
+	EventType string `gorm:"not null"`
+
+	// Событие в формате JSON
+	Payload json.RawMessage `gorm:"type:jsonb;not null"`
+
+	// Время записи события
+	CreatedAt time.Time `gorm:"not null"`
+
+	// Время публикации, пусто для неопубликованных событий
+	PublishedAt *time.Time `gorm:"index"`
+}
+
+// TableName возвращает имя таблицы outbox
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
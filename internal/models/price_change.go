@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// PriceChange запись истории цены подписки
+type PriceChange struct {
+	// The unique identifier of the price change
+	ID uint `gorm:"primaryKey" json:"-"`
+
+	// Организация подписки
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// The subscription whose price changed
+	SubscriptionID uint `gorm:"not null;index" json:"subscription_id"`
+
+	// История удаляется вместе с подпиской
+	Subscription *Subscription `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+
+	// The previous price, empty for the initial price
+	// Example: 799
+	OldPrice *int `json:"old_price,omitempty"`
+
+	// The new price
+	// Example: 990
+	Price int `gorm:"not null" json:"price"`
+
+	// When the price changed
+	ChangedAt time.Time `gorm:"not null" json:"changed_at"`
+}
+
+// TableName возвращает имя таблицы истории цен
+func (PriceChange) TableName() string {
+	return "subscription_price_history"
+}
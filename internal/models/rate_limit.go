@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket состояние корзины токенов клиента, общее для всех экземпляров сервиса
+type RateLimitBucket struct {
+	// Ключ корзины: класс маршрутов и клиент
+	Key string `gorm:"primaryKey"`
+
+	// Оставшиеся токены на момент UpdatedAt
+	Tokens float64 `gorm:"not null"`
+
+	// Разрешен ли последний запрос
+	Allowed bool `gorm:"not null"`
+
+	// Время последнего запроса; по нему вычисляется пополнение корзины
+	UpdatedAt time.Time `gorm:"not null;index"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // swagger:model
@@ -9,7 +11,10 @@ type Subscription struct {
 	// The unique identifier of the subscription
 	// Read Only: true
 	// Example: 1
-	ID uint `gorm:"primaryKey" json:"id"`
+	ID uint `gorm:"primaryKey;index:idx_subscriptions_created_at_id,priority:2" json:"id"`
+
+	// Организация, которой принадлежит подписка
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
 
 	// The name of the service
 	// Required: true
@@ -36,13 +41,26 @@ type Subscription struct {
 	// Example: 2023-12-31T00:00:00Z
 	EndDate *time.Time `json:"end_date,omitempty"`
 
+	// Free-form notes about the subscription
+	// Example: Family plan, shared with parents
+	Notes string `json:"notes,omitempty"`
+
+	// Tags for grouping and search
+	// Example: ["video","family"]
+	Tags []string `gorm:"serializer:json;type:jsonb" json:"tags,omitempty"`
+
 	// The creation timestamp
 	// Read Only: true
 	// Example: 2023-01-01T00:00:00Z
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `gorm:"index:idx_subscriptions_created_at_id,priority:1" json:"created_at"`
 
 	// The last update timestamp
 	// Read Only: true
 	// Example: 2023-01-01T00:00:00Z
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+
+	// The deletion timestamp, set while the subscription is in the trash
+	// Read Only: true
+	// Example: 2023-06-01T00:00:00Z
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitzero" swaggertype:"string"`
+}
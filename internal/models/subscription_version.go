@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// SubscriptionVersion версия подписки: ее состояние в интервале [ValidFrom, ValidTo).
+// Каждое изменение подписки закрывает текущую версию и добавляет новую, поэтому по версиям
+// можно восстановить состояние подписок на любой момент. Версии остаются после окончательного удаления подписки
+type SubscriptionVersion struct {
+	// ID версии
+	VersionID uint `gorm:"primaryKey" json:"version_id"`
+
+	// ID подписки
+	SubscriptionID uint `gorm:"not null;index:idx_subscription_versions_subscription_valid,priority:1" json:"subscription_id"`
+
+	// Состояние подписки
+	TenantID    string     `gorm:"size:64;not null;default:'default';index" json:"-"`
+	ServiceName string     `gorm:"not null" json:"service_name"`
+	Price       int        `gorm:"not null" json:"price"`
+	UserID      string     `gorm:"type:uuid;not null" json:"user_id"`
+	StartDate   time.Time  `gorm:"not null" json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	Tags        []string   `gorm:"serializer:json;type:jsonb" json:"tags,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime:false" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime:false" json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+
+	// Интервал действия версии; ValidTo пусто у текущей версии
+	ValidFrom time.Time  `gorm:"not null;index:idx_subscription_versions_subscription_valid,priority:2" json:"valid_from"`
+	ValidTo   *time.Time `gorm:"index" json:"valid_to,omitempty"`
+}
+
+// NewSubscriptionVersion создает версию с состоянием подписки, действующую с момента from
+func NewSubscriptionVersion(subscription *Subscription, from time.Time) SubscriptionVersion {
+	version := SubscriptionVersion{
+		SubscriptionID: subscription.ID,
+		TenantID:       subscription.TenantID,
+		ServiceName:    subscription.ServiceName,
+		Price:          subscription.Price,
+		UserID:         subscription.UserID,
+		StartDate:      subscription.StartDate,
+		EndDate:        subscription.EndDate,
+		Notes:          subscription.Notes,
+		Tags:           subscription.Tags,
+		CreatedAt:      subscription.CreatedAt,
+		UpdatedAt:      subscription.UpdatedAt,
+		ValidFrom:      from,
+	}
+	if subscription.DeletedAt.Valid {
+		deletedAt := subscription.DeletedAt.Time
+		version.DeletedAt = &deletedAt
+	}
+	return version
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultTenantID организация, к которой относятся данные, созданные до появления организаций,
+// и запросы без указания организации
+const DefaultTenantID = "default"
+
+// Настройки организации по умолчанию
+const (
+	DefaultTenantCurrency = "RUB"
+	DefaultTenantTimezone = "Europe/Moscow"
+)
+
+// Tenant организация-клиент, данные которой изолированы от других организаций
+type Tenant struct {
+	// The unique identifier of the tenant
+	// Example: acme
+	ID string `gorm:"primaryKey;size:64" json:"id"`
+
+	// Human-readable name of the tenant
+	// Example: Acme Corp
+	Name string `gorm:"not null" json:"name"`
+
+	// ISO 4217 currency of subscription prices
+	// Example: RUB
+	Currency string `gorm:"size:3;not null;default:'RUB'" json:"currency"`
+
+	// IANA timezone used to determine the current date
+	// Example: Europe/Moscow
+	Timezone string `gorm:"not null;default:'Europe/Moscow'" json:"timezone"`
+
+	// The creation timestamp
+	// Read Only: true
+	CreatedAt time.Time `json:"created_at"`
+
+	// The last update timestamp
+	// Read Only: true
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Location возвращает часовой пояс организации; для неизвестного пояса - UTC
+func (t *Tenant) Location() *time.Location {
+	location, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// UserData все данные, хранящиеся о пользователе в организации
+type UserData struct {
+	// Подписки пользователя, включая находящиеся в корзине
+	Subscriptions []Subscription
+
+	// История цен подписок пользователя
+	PriceHistory []PriceChange
+
+	// Версии подписок, принадлежавших пользователю
+	Versions []SubscriptionVersion
+
+	// Записи журнала аудита о подписках пользователя и о его изменениях
+	AuditEntries []AuditEntry
+
+	// События подписок пользователя из журнала событий
+	Events []StoredEvent
+}
+
+// UserErasure результат обезличивания данных пользователя: псевдоним, заменивший ID пользователя,
+// и количество обезличенных записей
+type UserErasure struct {
+	// Случайный ID, которым заменен ID пользователя
+	// Example: 2b8f6a1e-93c4-4d7a-b0e5-8c1f2d3a4b5c
+	Pseudonym string `json:"pseudonym"`
+
+	Subscriptions     int64 `json:"subscriptions"`
+	Versions          int64 `json:"versions"`
+	AuditEntries      int64 `json:"audit_entries"`
+	Events            int64 `json:"events"`
+	OutboxMessages    int64 `json:"outbox_messages"`
+	WebhookDeliveries int64 `json:"webhook_deliveries"`
+
+	// Удаленные ключи идемпотентности, сохраненный ответ которых содержал данные пользователя
+	IdempotencyKeys int64 `json:"idempotency_keys"`
+}
+
+// personalSubscriptionFields поля подписки с личными данными, которые удаляются при обезличивании.
+// Сервис, цена и даты остаются, чтобы не изменилась статистика стоимости
+var personalSubscriptionFields = []string{"notes", "tags"}
+
+// Anonymize заменяет userID на pseudonym и удаляет заметки и метки подписки
+func (s *Subscription) Anonymize(userID, pseudonym string) {
+	if s == nil || s.UserID != userID {
+		return
+	}
+	s.UserID = pseudonym
+	s.Notes = ""
+	s.Tags = nil
+}
+
+// AnonymizeEvent обезличивает событие подписки в формате JSON: подписка и ее предыдущее состояние
+// пользователя userID получают ID pseudonym и теряют заметки и метки
+func AnonymizeEvent(payload json.RawMessage, userID, pseudonym string) (json.RawMessage, error) {
+	var event SubscriptionEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	event.Subscription.Anonymize(userID, pseudonym)
+	event.Previous.Anonymize(userID, pseudonym)
+	return json.Marshal(event)
+}
+
+// AnonymizeAuditChanges обезличивает изменения записи журнала аудита: значения user_id, равные userID,
+// заменяются на pseudonym, а изменения заметок и меток удаляются
+func AnonymizeAuditChanges(changes json.RawMessage, userID, pseudonym string) (json.RawMessage, error) {
+	var fields map[string]AuditChange
+	if err := json.Unmarshal(changes, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range personalSubscriptionFields {
+		delete(fields, name)
+	}
+	if change, ok := fields["user_id"]; ok {
+		if change.Before == userID {
+			change.Before = pseudonym
+		}
+		if change.After == userID {
+			change.After = pseudonym
+		}
+		fields["user_id"] = change
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook зарегистрированный получатель событий подписок
+type Webhook struct {
+	// The unique identifier of the webhook
+	// Read Only: true
+	// Example: 1
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Организация, события которой получает вебхук
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// The URL that receives POST callbacks
+	// Example: https://example.com/hooks/subscriptions
+	URL string `gorm:"not null" json:"url"`
+
+	// Секрет для подписи HMAC-SHA256, возвращается только при создании
+	Secret string `gorm:"not null" json:"-"`
+
+	// Event types to deliver, empty means all events
+	// Example: ["subscription.created","subscription.price_changed"]
+	EventTypes []string `gorm:"serializer:json;type:jsonb;not null;default:'[]'" json:"event_types"`
+
+	// Whether deliveries are enqueued for the webhook
+	// Example: true
+	Active bool `gorm:"not null;default:true" json:"active"`
+
+	// The creation timestamp
+	// Read Only: true
+	CreatedAt time.Time `json:"created_at"`
+
+	// The last update timestamp
+	// Read Only: true
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery доставка одного события одному вебхуку.
+// Доставки хранятся в базе данных и повторяются, пока не будут успешными или не исчерпают попытки
+type WebhookDelivery struct {
+	// The unique identifier of the delivery
+	// Example: 1
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Организация вебхука
+	TenantID string `gorm:"size:64;not null;default:'default';index" json:"-"`
+
+	// The webhook the event is delivered to
+	// Example: 1
+	WebhookID uint `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:1" json:"webhook_id"`
+
+	// Доставки удаляются вместе с вебхуком
+	Webhook *Webhook `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+
+	// The ID of the delivered event, unique per webhook so a republished event is not delivered twice
+	// Example: 3f1c2a9e0b7d4c55a1e8f6b2d9c04e7a
+	EventID string `gorm:"size:32;not null;uniqueIndex:idx_webhook_deliveries_event,priority:2" json:"event_id"`
+
+	// The type of the delivered event
+	// Example: subscription.created
+	EventType string `gorm:"not null" json:"event_type"`
+
+	// The JSON body sent to the webhook
+	Payload json.RawMessage `gorm:"type:jsonb;not null" json:"payload" swaggertype:"object"`
+
+	// Delivery status: pending, succeeded or failed
+	// Example: pending
+	Status string `gorm:"not null;default:pending;index:idx_webhook_deliveries_due,priority:1" json:"status"`
+
+	// Number of delivery attempts made
+	// Example: 1
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+
+	// When the next attempt is due
+	NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+
+	// When the last attempt was made
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+
+	// HTTP status returned by the last attempt
+	// Example: 500
+	ResponseStatus *int `json:"response_status,omitempty"`
+
+	// Error of the last attempt
+	LastError string `json:"last_error,omitempty"`
+
+	// The creation timestamp
+	CreatedAt time.Time `json:"created_at"`
+
+	// The last update timestamp
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribed сообщает, получает ли вебхук события этого типа
+func (w *Webhook) Subscribed(eventType string) bool {
+	return len(w.EventTypes) == 0 || slices.Contains(w.EventTypes, eventType)
+}
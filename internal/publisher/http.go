@@ -0,0 +1,51 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// HTTPPublisher отправляет пакет событий JSON массивом в теле POST запроса
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPPublisher создает получатель, отправляющий события на url
+func NewHTTPPublisher(url string, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Publish отправляет события одним запросом. Любой ответ, кроме 2xx, считается ошибкой,
+// и пакет будет отправлен повторно
+func (p *HTTPPublisher) Publish(ctx context.Context, events []models.SubscriptionEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("получатель ответил статусом %d", resp.StatusCode)
+	}
+	return nil
+}
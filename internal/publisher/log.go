@@ -0,0 +1,31 @@
+package publisher
+
+import (
+	"context"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// LogPublisher записывает события в журнал приложения
+type LogPublisher struct {
+	logger *utils.Logger
+}
+
+// NewLogPublisher создает получатель, записывающий события в журнал
+func NewLogPublisher(logger *utils.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+// Publish записывает каждое событие в журнал
+func (p *LogPublisher) Publish(ctx context.Context, events []models.SubscriptionEvent) error {
+	for _, event := range events {
+		p.logger.Info("Событие подписки",
+			"id", event.ID,
+			"тип", event.Type,
+			"подписка", event.Subscription.ID,
+			"пользователь", event.Subscription.UserID,
+		)
+	}
+	return nil
+}
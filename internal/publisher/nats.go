@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+
+	"effective-mobile-subscription/internal/models"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher публикует каждое событие в NATS в тему "<префикс>.<тип события>",
+// например subscriptions.subscription.created
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSPublisher подключается к серверу NATS
+func NewNATSPublisher(url, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("subscription-service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+// Publish публикует события и дожидается подтверждения сервера.
+// ID события передается в заголовке Nats-Msg-Id, по нему JetStream исключает повторы
+func (p *NATSPublisher) Publish(ctx context.Context, events []models.SubscriptionEvent) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msg := nats.NewMsg(p.prefix + "." + event.Type)
+		msg.Header.Set(nats.MsgIdHdr, event.ID)
+		msg.Data = data
+		if err := p.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close отправляет оставшиеся сообщения и закрывает соединение
+func (p *NATSPublisher) Close() {
+	p.conn.Drain()
+}
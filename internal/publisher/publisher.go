@@ -0,0 +1,43 @@
+// Package publisher содержит внешние получатели событий подписок, в которые OutboxRelay
+// публикует события из outbox: журнал приложения, HTTP endpoint и NATS
+package publisher
+
+import (
+	"fmt"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// Виды внешних получателей событий
+const (
+	SinkNone = "none"
+	SinkLog  = "log"
+	SinkHTTP = "http"
+	SinkNATS = "nats"
+)
+
+// New создает внешний получатель событий, выбранный в конфигурации OUTBOX_SINK.
+// Для SinkNone возвращается nil. Функция close освобождает соединения получателя
+func New(cfg *config.Config, logger *utils.Logger) (sink services.EventPublisher, close func(), err error) {
+	switch cfg.OutboxSink {
+	case SinkNone:
+		return nil, func() {}, nil
+	case SinkLog:
+		return NewLogPublisher(logger), func() {}, nil
+	case SinkHTTP:
+		if cfg.OutboxHTTPURL == "" {
+			return nil, nil, fmt.Errorf("для OUTBOX_SINK=%s требуется OUTBOX_HTTP_URL", SinkHTTP)
+		}
+		return NewHTTPPublisher(cfg.OutboxHTTPURL, cfg.OutboxHTTPTimeout), func() {}, nil
+	case SinkNATS:
+		nats, err := NewNATSPublisher(cfg.NATSURL, cfg.NATSSubject)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nats, nats.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("неизвестный OUTBOX_SINK %q, ожидается %s, %s, %s или %s", cfg.OutboxSink, SinkNone, SinkLog, SinkHTTP, SinkNATS)
+	}
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит корзины токенов в памяти процесса; подходит для одного экземпляра сервиса
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket корзина токенов клиента
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore создает хранилище корзин в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take списывает токен из корзины key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(limit.Requests), b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(limit, b.tokens, allowed), nil
+}
+
+// Prune удаляет корзины, не использовавшиеся дольше idle
+func (s *MemoryStore) Prune(ctx context.Context, idle time.Duration) (int64, error) {
+	before := time.Now().Add(-idle)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/auth"
+)
+
+// Классы маршрутов с отдельными ограничениями
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+	ClassHeavy = "heavy"
+
+	// ClassIP общее ограничение всех запросов с одного IP адреса, проверяемое до аутентификации
+	ClassIP = "ip"
+)
+
+// Policy ограничения для классов маршрутов. Каждый класс расходует собственную корзину клиента
+type Policy struct {
+	// Read ограничение чтения
+	Read Limit
+
+	// Write ограничение изменяющих запросов
+	Write Limit
+
+	// Heavy ограничение тяжелых запросов: агрегатов и выгрузки
+	Heavy Limit
+
+	// HeavyPaths окончания путей тяжелых маршрутов, например /subscriptions/cost
+	HeavyPaths []string
+
+	// IP ограничение всех запросов с одного IP адреса до аутентификации, включая запросы с неверными
+	// учетными данными; Requests <= 0 отключает его
+	IP Limit
+}
+
+// NewPolicy создает ограничения из конфигурации RATE_LIMIT_*
+func NewPolicy(cfg *config.Config) Policy {
+	return Policy{
+		Read:       Limit{Requests: cfg.RateLimitRead, Window: cfg.RateLimitWindow},
+		Write:      Limit{Requests: cfg.RateLimitWrite, Window: cfg.RateLimitWindow},
+		Heavy:      Limit{Requests: cfg.RateLimitHeavy, Window: cfg.RateLimitWindow},
+		HeavyPaths: cfg.RateLimitHeavyPaths,
+		IP:         Limit{Requests: cfg.RateLimitIP, Window: cfg.RateLimitWindow},
+	}
+}
+
+// classify определяет класс маршрута и его ограничение
+func (p Policy) classify(r *http.Request) (string, Limit) {
+	for _, path := range p.HeavyPaths {
+		if strings.HasSuffix(r.URL.Path, path) {
+			return ClassHeavy, p.Heavy
+		}
+	}
+	// GraphQL только читает данные, хотя запросы обычно отправляются методом POST
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions || strings.HasSuffix(r.URL.Path, "/graphql") {
+		return ClassRead, p.Read
+	}
+	return ClassWrite, p.Write
+}
+
+// Middleware ограничивает частоту запросов каждого клиента. Клиент определяется ключом API,
+// субъектом JWT или, без аутентификации, IP адресом; если ipHeader задан, адрес берется из этого
+// заголовка прокси. Ответы содержат заголовки RateLimit-*, превышение ограничения возвращает
+// 429 с Retry-After. Пути publicPaths не ограничиваются. Если хранилище недоступно, запрос пропускается
+func Middleware(store Store, policy Policy, ipHeader string, publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.IsPublic(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, _ := auth.PrincipalFrom(r.Context())
+			class, limit := policy.classify(r)
+			if allow(w, r, store, Key(class, principal, clientIP(r, ipHeader)), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// IPMiddleware ограничивает частоту всех запросов с одного IP адреса. Подключается до аутентификации,
+// поэтому ограничивает и подбор учетных данных: запросы с неверным ключом API или JWT отклоняются
+// аутентификацией раньше Middleware. Пути publicPaths не ограничиваются
+func IPMiddleware(store Store, limit Limit, ipHeader string, publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.IsPublic(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allow(w, r, store, Key(ClassIP, nil, clientIP(r, ipHeader)), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allow списывает токен из корзины key, записывает заголовки RateLimit-* и отвечает 429,
+// если ограничение превышено. Если хранилище недоступно, запрос разрешается
+func allow(w http.ResponseWriter, r *http.Request, store Store, key string, limit Limit) bool {
+	result, err := store.Take(r.Context(), key, limit)
+	if err != nil {
+		slog.Error("Не удалось проверить ограничение частоты запросов", "ошибка", err)
+		return true
+	}
+
+	// Заголовки по draft-ietf-httpapi-ratelimit-headers
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Window)))
+
+	if !result.Allowed {
+		retryAfter := RetryAfterSeconds(result)
+		header.Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, fmt.Sprintf("Превышено ограничение частоты запросов, повторите через %d с", retryAfter), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// Key возвращает ключ корзины класса class для клиента: ключа API или субъекта JWT участника principal,
+// а без аутентификации - IP адреса ip. REST и gRPC API используют одинаковые ключи и общие корзины
+func Key(class string, principal *auth.Principal, ip string) string {
+	switch {
+	case principal == nil:
+		return class + ":ip:" + ip
+	case principal.Method == auth.MethodAPIKey:
+		return fmt.Sprintf("%s:key:%d", class, principal.KeyID)
+	default:
+		return class + ":sub:" + principal.TenantID + ":" + principal.Subject
+	}
+}
+
+// RetryAfterSeconds возвращает, через сколько целых секунд, но не меньше одной, можно повторить запрос
+func RetryAfterSeconds(result Result) int {
+	return max(ceilSeconds(result.RetryAfter), 1)
+}
+
+// clientIP возвращает IP адрес клиента: из заголовка ipHeader, если он задан и присутствует,
+// иначе адрес соединения. Из списка адресов X-Forwarded-For берется первый - адрес клиента
+func clientIP(r *http.Request, ipHeader string) string {
+	if ipHeader != "" {
+		if value := r.Header.Get(ipHeader); value != "" {
+			first, _, _ := strings.Cut(value, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/ratelimit"
+)
+
+// rejectingKeys отклоняет все ключи API
+type rejectingKeys struct{}
+
+func (rejectingKeys) VerifyAPIKey(string) (*models.APIKey, error) {
+	return nil, auth.ErrInvalidCredentials
+}
+
+func TestIPMiddlewareLimitsInvalidCredentials(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 2, Window: time.Minute}
+	authenticator := auth.NewAuthenticator(rejectingKeys{}, nil)
+
+	// Тот же порядок, что и в маршрутизаторе: ограничение по IP до аутентификации
+	handler := ratelimit.IPMiddleware(store, limit, "", []string{"/health"})(
+		auth.Middleware(authenticator, []string{"/health"})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		),
+	)
+
+	request := func(path, addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = addr
+		r.Header.Set("X-API-Key", auth.APIKeyPrefix+"wrong")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := range limit.Requests {
+		if w := request("/subscriptions", "10.0.0.1:1000"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("запрос %d: код %d, ожидался 401", i+1, w.Code)
+		}
+	}
+
+	w := request("/subscriptions", "10.0.0.1:1001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("код %d, ожидался 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("нет заголовка Retry-After")
+	}
+
+	// Другой адрес и открытые пути не ограничиваются
+	if w := request("/subscriptions", "10.0.0.2:1000"); w.Code != http.StatusUnauthorized {
+		t.Errorf("другой адрес: код %d, ожидался 401", w.Code)
+	}
+	if w := request("/health", "10.0.0.1:1000"); w.Code != http.StatusOK {
+		t.Errorf("открытый путь: код %d, ожидался 200", w.Code)
+	}
+}
+
+func TestKeySharesBucketsByClient(t *testing.T) {
+	apiKey := &auth.Principal{Method: auth.MethodAPIKey, KeyID: 7, Subject: "ci"}
+	jwt := &auth.Principal{Subject: "alice", TenantID: "acme"}
+
+	tests := []struct {
+		principal *auth.Principal
+		want      string
+	}{
+		{nil, "read:ip:10.0.0.1"},
+		{apiKey, "read:key:7"},
+		{jwt, "read:sub:acme:alice"},
+	}
+	for _, tt := range tests {
+		if got := ratelimit.Key(ratelimit.ClassRead, tt.principal, "10.0.0.1"); got != tt.want {
+			t.Errorf("Key() = %q, ожидался %q", got, tt.want)
+		}
+	}
+}
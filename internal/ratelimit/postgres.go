@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/internal/repository"
+)
+
+// PostgresStore хранит корзины токенов в PostgreSQL, поэтому ограничение общее для всех экземпляров сервиса
+type PostgresStore struct {
+	repo *repository.RateLimitRepository
+}
+
+// NewPostgresStore создает хранилище корзин в PostgreSQL
+func NewPostgresStore(repo *repository.RateLimitRepository) *PostgresStore {
+	return &PostgresStore{repo: repo}
+}
+
+// Take списывает токен из корзины key
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tokens, allowed, err := s.repo.Take(ctx, key, limit.rate(), limit.Requests)
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, tokens, allowed), nil
+}
+
+// Prune удаляет корзины, не использовавшиеся дольше idle
+func (s *PostgresStore) Prune(ctx context.Context, idle time.Duration) (int64, error) {
+	return s.repo.DeleteIdle(ctx, time.Now().Add(-idle))
+}
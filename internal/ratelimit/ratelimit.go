@@ -0,0 +1,95 @@
+// Package ratelimit ограничивает частоту запросов клиентов алгоритмом корзины токенов.
+// Корзины хранятся в памяти процесса для одного экземпляра или в PostgreSQL,
+// чтобы ограничение было общим для всех экземпляров сервиса
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Хранилища корзин токенов
+const (
+	BackendNone     = "none"
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Limit ограничение частоты: не больше Requests запросов за Window.
+// Корзина вмещает Requests токенов и пополняется равномерно за Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// rate скорость пополнения корзины в токенах в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// Result результат списания токена
+type Result struct {
+	// Запрос разрешен
+	Allowed bool
+
+	// Оставшиеся целые токены
+	Remaining int
+
+	// Через сколько появится следующий токен; ноль, если запрос разрешен
+	RetryAfter time.Duration
+
+	// Через сколько корзина пополнится полностью
+	Reset time.Duration
+}
+
+// Store хранилище корзин токенов
+type Store interface {
+	// Take списывает токен из корзины key с ограничением limit
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+
+	// Prune удаляет корзины, не использовавшиеся дольше idle
+	Prune(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// New создает хранилище корзин, выбранное в конфигурации RATE_LIMIT_BACKEND.
+// Для BackendNone возвращается nil: ограничение частоты отключено
+func New(cfg *config.Config, db *gorm.DB) (Store, error) {
+	switch cfg.RateLimitBackend {
+	case BackendNone:
+		return nil, nil
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendPostgres:
+		if db.Dialector.Name() != "postgres" {
+			return nil, fmt.Errorf("RATE_LIMIT_BACKEND=%s требует DB_DRIVER=postgres", BackendPostgres)
+		}
+		return NewPostgresStore(repository.NewRateLimitRepository(db)), nil
+	default:
+		return nil, fmt.Errorf("неизвестный RATE_LIMIT_BACKEND %q, ожидается %s, %s или %s", cfg.RateLimitBackend, BackendNone, BackendMemory, BackendPostgres)
+	}
+}
+
+// newResult вычисляет результат по токенам, оставшимся в корзине после запроса
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	return result
+}
+
+// seconds переводит секунды в time.Duration
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Max(value, 0) * float64(time.Second))
+}
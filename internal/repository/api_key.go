@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository обрабатывает операции с базой данных для ключей API
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository создает новый репозиторий ключей API
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create сохраняет новый ключ API
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetByHash получает ключ API по хешу
+func (r *APIKeyRepository) GetByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List получает все ключи API организации
+func (r *APIKeyRepository) List(tenantID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("tenant_id = ?", tenantID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke отзывает ключ API организации. Если ключ не найден или уже отозван, возвращается gorm.ErrRecordNotFound
+func (r *APIKeyRepository) Revoke(tenantID string, id uint, at time.Time) error {
+	result := r.db.Model(&models.APIKey{}).Where("tenant_id = ? AND id = ? AND revoked_at IS NULL", tenantID, id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed обновляет время последнего использования ключа
+func (r *APIKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository обрабатывает операции с базой данных для журнала аудита.
+// Журнал только пополняется: изменение и удаление записей запрещено и триггером в базе данных.
+// Исключение - обезличивание данных пользователя в AnonymizeUser
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository создает новый репозиторий журнала аудита
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Add добавляет записи в журнал аудита
+func (r *AuditRepository) Add(entries ...models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	// Массовая операция записывает до MaxBulkItems записей, один INSERT превысил бы ограничение
+	// количества параметров запроса
+	return r.db.CreateInBatches(&entries, bulkBatchSize).Error
+}
+
+// List получает записи журнала из области видимости scope, подходящие под фильтр, от новых к старым
+func (r *AuditRepository) List(scope Scope, filter *models.AuditFilter, offset, limit int) ([]models.AuditEntry, error) {
+	query := scope.apply(r.db.Model(&models.AuditEntry{}))
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var entries []models.AuditEntry
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,850 @@
+// Package conformance проверяет, что хранилище подписок ведет себя так, как ожидает сервис подписок.
+// Один набор проверок запускается для каждой реализации repository.Subscriptions из теста
+// пакета этой реализации:
+//
+//	func TestMemoryConformance(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) repository.Subscriptions {
+//			return repository.NewMemorySubscriptionRepository(nil)
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Factory создает пустое хранилище подписок для одной проверки
+type Factory func(t *testing.T) repository.Subscriptions
+
+// Организации и пользователи проверок
+const (
+	tenantA = "tenant-a"
+	tenantB = "tenant-b"
+	userA   = "11111111-1111-1111-1111-111111111111"
+	userB   = "22222222-2222-2222-2222-222222222222"
+)
+
+// errRollback ошибка, которой проверки откатывают транзакцию
+var errRollback = errors.New("откат транзакции")
+
+// Run запускает все проверки хранилища; каждая получает новое хранилище из newRepo
+func Run(t *testing.T, newRepo Factory) {
+	checks := []struct {
+		name string
+		run  func(t *testing.T, repo repository.Subscriptions)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"Scope", testScope},
+		{"Update", testUpdate},
+		{"DeleteAndRestore", testDeleteAndRestore},
+		{"PurgeDeleted", testPurgeDeleted},
+		{"ListFilterAndSort", testListFilterAndSort},
+		{"ListAfterCursor", testListAfterCursor},
+		{"CountAndTotalCost", testCountAndTotalCost},
+		{"Iterate", testIterate},
+		{"Transaction", testTransaction},
+		{"BulkCreate", testBulkCreate},
+		{"BulkUpdate", testBulkUpdate},
+		{"BulkDelete", testBulkDelete},
+		{"AsOf", testAsOf},
+		{"Summaries", testSummaries},
+		{"Related", testRelated},
+		{"Search", testSearch},
+		{"ClaimEndingSoon", testClaimEndingSoon},
+		{"UserData", testUserData},
+	}
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			check.run(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	created := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), ptr(month(2025, 12))))
+	if created.ID == 0 || created.TenantID != tenantA || created.CreatedAt.IsZero() {
+		t.Fatalf("Create не заполнил ID, организацию и время создания: %+v", created)
+	}
+
+	got, err := repo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ServiceName != "Netflix" || got.Price != 990 || got.UserID != userA ||
+		!got.StartDate.Equal(month(2025, 1)) || got.EndDate == nil || !got.EndDate.Equal(month(2025, 12)) ||
+		got.Notes != "семейный тариф" || !slices.Equal(got.Tags, []string{"video", "family"}) {
+		t.Fatalf("GetByID вернул %+v", got)
+	}
+
+	if _, err := repo.GetByID(created.ID + 1000); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID отсутствующей подписки: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func testScope(t *testing.T, repo repository.Subscriptions) {
+	a := repo.WithScope(repository.Scope{TenantID: tenantA})
+	own := create(t, a, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	other := create(t, a, subscription("Spotify", 299, userB, month(2025, 1), nil))
+
+	// Подписки другой организации не видны и не изменяются
+	b := repo.WithScope(repository.Scope{TenantID: tenantB})
+	if _, err := b.GetByID(own.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID чужой организации: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if _, err := b.Update(own.ID, &models.Subscription{Price: 1}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Update чужой организации: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if _, err := b.Delete(own.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Delete чужой организации: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if list := list(t, b, nil, nil); len(list) != 0 {
+		t.Fatalf("List чужой организации вернул %d подписок", len(list))
+	}
+
+	// Подписки каждой организации учитываются только в ее количестве и стоимости
+	foreign := create(t, b, subscription("Okko", 399, userA, month(2025, 1), nil))
+	for _, c := range []struct {
+		repo  repository.Subscriptions
+		ids   []uint
+		total int64
+	}{{a, []uint{own.ID, other.ID}, 990 + 299}, {b, []uint{foreign.ID}, 399}} {
+		if got := ids(list(t, c.repo, nil, []models.SortField{{Field: "id"}})); !slices.Equal(got, c.ids) {
+			t.Fatalf("List вернул %v, ожидалось %v", got, c.ids)
+		}
+		if count, err := c.repo.Count(nil); err != nil || count != int64(len(c.ids)) {
+			t.Fatalf("Count вернул %d, %v, ожидалось %d", count, err, len(c.ids))
+		}
+		if total, err := c.repo.CalculateTotalCost(&models.SubscriptionFilter{}, nil, nil); err != nil || total != c.total {
+			t.Fatalf("CalculateTotalCost вернул %d, %v, ожидалось %d", total, err, c.total)
+		}
+	}
+	if _, err := a.GetByID(foreign.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID подписки другой организации: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+
+	// Пользователь видит только свои подписки
+	user := repo.WithScope(repository.Scope{TenantID: tenantA, UserID: userA})
+	if _, err := user.GetByID(other.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID подписки другого пользователя: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if got := ids(list(t, user, nil, nil)); !slices.Equal(got, []uint{own.ID}) {
+		t.Fatalf("List пользователя вернул %v, ожидалось [%d]", got, own.ID)
+	}
+}
+
+func testUpdate(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	created := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+
+	// Обновляются только заполненные поля
+	change, err := repo.Update(created.ID, &models.Subscription{Price: 1290, EndDate: ptr(month(2025, 6))})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if change.Before.Price != 990 || change.After.Price != 1290 || !change.PriceChanged() {
+		t.Fatalf("Update вернул изменение %+v -> %+v", change.Before, change.After)
+	}
+	if change.After.ServiceName != "Netflix" || change.After.EndDate == nil || !change.After.EndDate.Equal(month(2025, 6)) {
+		t.Fatalf("Update изменил незаполненные поля или не изменил заполненные: %+v", change.After)
+	}
+	if change.After.UpdatedAt.Before(change.Before.UpdatedAt) {
+		t.Fatalf("Update не обновил время изменения")
+	}
+
+	got, err := repo.GetByID(created.ID)
+	if err != nil || got.Price != 1290 {
+		t.Fatalf("GetByID после Update вернул %+v, %v", got, err)
+	}
+
+	// Изменение цены записано в историю
+	history, err := repo.PriceHistory([]uint{created.ID})
+	if err != nil {
+		t.Fatalf("PriceHistory: %v", err)
+	}
+	prices := history[created.ID]
+	if len(prices) != 2 || prices[0].Price != 990 || prices[1].Price != 1290 ||
+		prices[1].OldPrice == nil || *prices[1].OldPrice != 990 {
+		t.Fatalf("PriceHistory вернул %+v", prices)
+	}
+
+	if _, err := repo.Update(created.ID+1000, &models.Subscription{Price: 1}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Update отсутствующей подписки: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func testDeleteAndRestore(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	created := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+
+	deleted, err := repo.Delete(created.ID)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if deleted.ID != created.ID || !deleted.DeletedAt.Valid {
+		t.Fatalf("Delete вернул %+v", deleted)
+	}
+	if _, err := repo.GetByID(created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID удаленной подписки: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if _, err := repo.Delete(created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("повторный Delete: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+
+	// Удаленная подписка в корзине и видна фильтру с IncludeDeleted
+	trash, err := repo.ListDeleted(0, 10)
+	if err != nil || !slices.Equal(ids(trash), []uint{created.ID}) {
+		t.Fatalf("ListDeleted вернул %v, %v", ids(trash), err)
+	}
+	if got := list(t, repo, &models.SubscriptionFilter{IncludeDeleted: true}, nil); len(got) != 1 {
+		t.Fatalf("List с IncludeDeleted вернул %d подписок", len(got))
+	}
+
+	change, err := repo.Restore(created.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if !change.Before.DeletedAt.Valid || change.After.DeletedAt.Valid {
+		t.Fatalf("Restore вернул изменение %+v -> %+v", change.Before.DeletedAt, change.After.DeletedAt)
+	}
+	if _, err := repo.GetByID(created.ID); err != nil {
+		t.Fatalf("GetByID восстановленной подписки: %v", err)
+	}
+	if _, err := repo.Restore(created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Restore подписки не из корзины: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func testPurgeDeleted(t *testing.T, repo repository.Subscriptions) {
+	a := repo.WithScope(repository.Scope{TenantID: tenantA})
+	b := repo.WithScope(repository.Scope{TenantID: tenantB})
+	kept := create(t, a, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	purgedA := create(t, a, subscription("Spotify", 299, userA, month(2025, 1), nil))
+	purgedB := create(t, b, subscription("Spotify", 299, userB, month(2025, 1), nil))
+	for _, deleted := range []struct {
+		repo repository.Subscriptions
+		id   uint
+	}{{a, purgedA.ID}, {b, purgedB.ID}} {
+		if _, err := deleted.repo.Delete(deleted.id); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	// Корзина очищается во всех организациях
+	purged, err := repo.PurgeDeleted(time.Now().Add(time.Hour))
+	if err != nil || purged != 2 {
+		t.Fatalf("PurgeDeleted вернул %d, %v, ожидалось 2", purged, err)
+	}
+	if trash, _ := a.ListDeleted(0, 10); len(trash) != 0 {
+		t.Fatalf("в корзине остались подписки %v", ids(trash))
+	}
+	if _, err := a.Restore(purgedA.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Restore окончательно удаленной подписки: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+	if _, err := a.GetByID(kept.ID); err != nil {
+		t.Fatalf("PurgeDeleted удалил активную подписку: %v", err)
+	}
+}
+
+func testListFilterAndSort(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	netflix := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), ptr(month(2025, 3))))
+	spotify := create(t, repo, subscription("Spotify", 299, userA, month(2025, 2), nil))
+	youtube := create(t, repo, subscription("YouTube Premium", 399, userB, month(2025, 5), nil))
+
+	cases := []struct {
+		name   string
+		filter models.SubscriptionFilter
+		want   []uint
+	}{
+		{"UserIDs", models.SubscriptionFilter{UserIDs: []string{userB}}, []uint{youtube.ID}},
+		{"ServiceNames", models.SubscriptionFilter{ServiceNames: []string{"Netflix", "Spotify"}}, []uint{netflix.ID, spotify.ID}},
+		{"ServiceNameContains", models.SubscriptionFilter{ServiceNameContains: "tube"}, []uint{youtube.ID}},
+		{"ServiceNameContainsIgnoresCase", models.SubscriptionFilter{ServiceNameContains: "NETFL"}, []uint{netflix.ID}},
+		{"ServiceNameContainsEscapesPattern", models.SubscriptionFilter{ServiceNameContains: "%"}, nil},
+		{"Price", models.SubscriptionFilter{PriceMin: ptr(300), PriceMax: ptr(990)}, []uint{netflix.ID, youtube.ID}},
+		{"Start", models.SubscriptionFilter{StartFrom: ptr(month(2025, 2)), StartTo: ptr(month(2025, 4))}, []uint{spotify.ID}},
+		{"End", models.SubscriptionFilter{EndFrom: ptr(month(2025, 1)), EndTo: ptr(month(2025, 12))}, []uint{netflix.ID}},
+		{"ActiveAt", models.SubscriptionFilter{ActiveAt: ptr(month(2025, 4))}, []uint{spotify.ID}},
+		{"OpenEnded", models.SubscriptionFilter{OpenEnded: ptr(true)}, []uint{spotify.ID, youtube.ID}},
+		{"NotOpenEnded", models.SubscriptionFilter{OpenEnded: ptr(false)}, []uint{netflix.ID}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ids(list(t, repo, &c.filter, []models.SortField{{Field: "id"}}))
+			if !slices.Equal(got, c.want) {
+				t.Fatalf("List вернул %v, ожидалось %v", got, c.want)
+			}
+		})
+	}
+
+	// Бессрочные подписки сортируются как подписки с бесконечной датой окончания
+	sorts := []struct {
+		sort string
+		want []uint
+	}{
+		{"price", []uint{spotify.ID, youtube.ID, netflix.ID}},
+		{"-price", []uint{netflix.ID, youtube.ID, spotify.ID}},
+		{"service_name", []uint{netflix.ID, spotify.ID, youtube.ID}},
+		{"end_date,-start_date", []uint{netflix.ID, youtube.ID, spotify.ID}},
+		{"-end_date,id", []uint{spotify.ID, youtube.ID, netflix.ID}},
+		{"user_id,-price", []uint{netflix.ID, spotify.ID, youtube.ID}},
+	}
+	for _, s := range sorts {
+		t.Run("Sort "+s.sort, func(t *testing.T) {
+			if got := ids(list(t, repo, nil, parseSort(t, s.sort))); !slices.Equal(got, s.want) {
+				t.Fatalf("List вернул %v, ожидалось %v", got, s.want)
+			}
+		})
+	}
+
+	// Пагинация по смещению
+	page, err := repo.List(nil, parseSort(t, "price"), 1, 1)
+	if err != nil || !slices.Equal(ids(page), []uint{youtube.ID}) {
+		t.Fatalf("List со смещением вернул %v, %v", ids(page), err)
+	}
+}
+
+func testListAfterCursor(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	for i := range 7 {
+		var end *time.Time
+		if i%3 != 0 {
+			end = ptr(month(2025, time.Month(1+i%2)))
+		}
+		create(t, repo, subscription("Service", 100*(i%3), userA, month(2024, time.Month(1+i%4)), end))
+	}
+
+	// Постраничный обход курсором совпадает со списком целиком для сортировок в одном и разных направлениях
+	for _, value := range []string{"-created_at", "price", "end_date,-price", "-end_date,start_date", "service_name,-id"} {
+		t.Run(value, func(t *testing.T) {
+			sort := parseSort(t, value)
+			want := ids(list(t, repo, nil, sort))
+
+			var got []uint
+			var cursor *repository.SubscriptionCursor
+			for range len(want) + 1 {
+				page, err := repo.ListAfter(nil, sort, cursor, 3)
+				if err != nil {
+					t.Fatalf("ListAfter: %v", err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, ids(page)...)
+
+				// Курсор проходит через клиента в закодированном виде
+				encoded := repository.CursorAfter(&page[len(page)-1], sort).Encode()
+				if cursor, err = repository.DecodeSubscriptionCursor(encoded, sort); err != nil {
+					t.Fatalf("DecodeSubscriptionCursor: %v", err)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("обход курсором вернул %v, ожидалось %v", got, want)
+			}
+		})
+	}
+}
+
+func testCountAndTotalCost(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	create(t, repo, subscription("Spotify", 299, userA, month(2025, 3), nil))
+	create(t, repo, subscription("Netflix", 500, userB, month(2025, 6), nil))
+
+	count, err := repo.Count(&models.SubscriptionFilter{ServiceNames: []string{"Netflix"}})
+	if err != nil || count != 2 {
+		t.Fatalf("Count вернул %d, %v, ожидалось 2", count, err)
+	}
+
+	total, err := repo.CalculateTotalCost(&models.SubscriptionFilter{}, nil, nil)
+	if err != nil || total != 1789 {
+		t.Fatalf("CalculateTotalCost без периода вернул %d, %v, ожидалось 1789", total, err)
+	}
+	total, err = repo.CalculateTotalCost(&models.SubscriptionFilter{UserIDs: []string{userA}}, month(2025, 2), month(2025, 12))
+	if err != nil || total != 299 {
+		t.Fatalf("CalculateTotalCost за период вернул %d, %v, ожидалось 299", total, err)
+	}
+}
+
+func testIterate(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	for i := range 5 {
+		create(t, repo, subscription("Netflix", 100+i, userA, month(2025, 1), nil))
+	}
+
+	sort := parseSort(t, "-price")
+	var got []uint
+	err := repo.Iterate(context.Background(), &models.SubscriptionFilter{PriceMin: ptr(101)}, sort, func(s *models.Subscription) error {
+		got = append(got, s.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Iterate: %v", err)
+	}
+	if want := ids(list(t, repo, &models.SubscriptionFilter{PriceMin: ptr(101)}, sort)); !slices.Equal(got, want) {
+		t.Fatalf("Iterate вернул %v, ожидалось %v", got, want)
+	}
+
+	// Ошибка fn прерывает чтение
+	calls := 0
+	err = repo.Iterate(context.Background(), nil, sort, func(*models.Subscription) error {
+		calls++
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) || calls != 1 {
+		t.Fatalf("Iterate после ошибки fn: %v, вызовов %d", err, calls)
+	}
+}
+
+func testTransaction(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	existing := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+
+	// Ошибка откатывает все изменения транзакции
+	err := repo.Transaction(func(tx repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		if err := tx.Create(subscription("Spotify", 299, userA, month(2025, 1), nil)); err != nil {
+			return err
+		}
+		if _, err := tx.Update(existing.ID, &models.Subscription{Price: 1}); err != nil {
+			return err
+		}
+		if err := outbox.Add(outboxMessage("rollback")); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Transaction вернула %v, ожидалась ошибка fn", err)
+	}
+	if got := list(t, repo, nil, nil); len(got) != 1 || got[0].Price != 990 {
+		t.Fatalf("после отката остались изменения: %+v", got)
+	}
+
+	// Без ошибки изменения сохраняются вместе с outbox и аудитом; ошибка отдельной операции
+	// внутри транзакции не отменяет ее прежние изменения
+	err = repo.Transaction(func(tx repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		if _, err := tx.Update(existing.ID, &models.Subscription{Price: 1290}); err != nil {
+			return err
+		}
+		if _, err := tx.Update(existing.ID+1000, &models.Subscription{Price: 1}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Update отсутствующей подписки в транзакции: %v", err)
+		}
+		if err := outbox.Add(outboxMessage("commit")); err != nil {
+			return err
+		}
+		return audit.Add(models.AuditEntry{
+			TenantID:       tenantA,
+			SubscriptionID: existing.ID,
+			UserID:         userA,
+			Action:         models.AuditActionUpdate,
+			Changes:        []byte(`{}`),
+			CreatedAt:      time.Now(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if got, err := repo.GetByID(existing.ID); err != nil || got.Price != 1290 {
+		t.Fatalf("после фиксации GetByID вернул %+v, %v", got, err)
+	}
+}
+
+func testBulkCreate(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	batch := []*models.Subscription{
+		subscription("Netflix", 990, userA, month(2025, 1), nil),
+		subscription("Spotify", 299, userB, month(2025, 1), nil),
+	}
+
+	errs, err := repo.BulkCreate(batch, false)
+	if err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+	if len(errs) != len(batch) || errs[0] != nil || errs[1] != nil {
+		t.Fatalf("BulkCreate вернул ошибки строк %v", errs)
+	}
+	for _, s := range batch {
+		if s.ID == 0 || s.TenantID != tenantA {
+			t.Fatalf("BulkCreate не заполнил ID и организацию: %+v", s)
+		}
+	}
+	if got := list(t, repo, nil, nil); len(got) != 2 {
+		t.Fatalf("после BulkCreate найдено %d подписок", len(got))
+	}
+}
+
+func testBulkUpdate(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	first := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	second := create(t, repo, subscription("Spotify", 299, userA, month(2025, 1), nil))
+	missing := second.ID + 1000
+
+	// Все или ничего: отсутствующая строка откатывает обновление остальных
+	_, _, err := repo.BulkUpdate([]uint{first.ID, missing}, []*models.Subscription{{Price: 1}, {Price: 2}}, false)
+	var itemErr *repository.BulkItemError
+	if !errors.As(err, &itemErr) || itemErr.Index != 1 || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("BulkUpdate с отсутствующей строкой вернул %v", err)
+	}
+	if got, _ := repo.GetByID(first.ID); got.Price != 990 {
+		t.Fatalf("BulkUpdate не откатил обновление: цена %d", got.Price)
+	}
+
+	// В режиме bestEffort ошибка строки возвращается под ее индексом
+	changes, errs, err := repo.BulkUpdate([]uint{first.ID, missing, second.ID}, []*models.Subscription{{Price: 1}, {Price: 2}, {Price: 3}}, true)
+	if err != nil {
+		t.Fatalf("BulkUpdate bestEffort: %v", err)
+	}
+	if errs[0] != nil || !errors.Is(errs[1], gorm.ErrRecordNotFound) || errs[2] != nil {
+		t.Fatalf("BulkUpdate bestEffort вернул ошибки строк %v", errs)
+	}
+	if changes[0] == nil || changes[0].After.Price != 1 || changes[1] != nil || changes[2] == nil || changes[2].After.Price != 3 {
+		t.Fatalf("BulkUpdate bestEffort вернул изменения %+v", changes)
+	}
+}
+
+func testBulkDelete(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	netflix := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	spotify := create(t, repo, subscription("Spotify", 299, userA, month(2025, 1), nil))
+	youtube := create(t, repo, subscription("YouTube", 399, userB, month(2025, 1), nil))
+	missing := youtube.ID + 1000
+
+	// Все или ничего: отсутствующая подписка откатывает удаление
+	deleted, err := repo.BulkDeleteByIDs([]uint{netflix.ID, missing}, true)
+	if !errors.Is(err, gorm.ErrRecordNotFound) || !slices.Equal(ids(deleted), []uint{netflix.ID}) {
+		t.Fatalf("BulkDeleteByIDs с отсутствующей подпиской вернул %v, %v", ids(deleted), err)
+	}
+	if _, err := repo.GetByID(netflix.ID); err != nil {
+		t.Fatalf("BulkDeleteByIDs не откатил удаление: %v", err)
+	}
+
+	deleted, err = repo.BulkDeleteByIDs([]uint{netflix.ID, missing, netflix.ID}, false)
+	if err != nil || !slices.Equal(ids(deleted), []uint{netflix.ID}) {
+		t.Fatalf("BulkDeleteByIDs вернул %v, %v", ids(deleted), err)
+	}
+
+	// Удаление по фильтру не затрагивает подписки в корзине и удаляет не больше limit подписок с наименьшими ID
+	deleted, err = repo.BulkDeleteByFilter(&models.SubscriptionFilter{UserIDs: []string{userA, userB}, IncludeDeleted: true}, 1)
+	if err != nil || !slices.Equal(ids(deleted), []uint{spotify.ID}) {
+		t.Fatalf("BulkDeleteByFilter вернул %v, %v", ids(deleted), err)
+	}
+	if got := ids(list(t, repo, nil, nil)); !slices.Equal(got, []uint{youtube.ID}) {
+		t.Fatalf("после удаления остались %v", got)
+	}
+}
+
+func testAsOf(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	beforeCreate := tick()
+	created := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	afterCreate := tick()
+	if _, err := repo.Update(created.ID, &models.Subscription{Price: 1290}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	afterUpdate := tick()
+	if _, err := repo.Delete(created.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if got := list(t, repo.AsOf(&beforeCreate), nil, nil); len(got) != 0 {
+		t.Fatalf("до создания видны подписки %v", ids(got))
+	}
+	got, err := repo.AsOf(&afterCreate).GetByID(created.ID)
+	if err != nil || got.Price != 990 {
+		t.Fatalf("GetByID после создания вернул %+v, %v", got, err)
+	}
+	total, err := repo.AsOf(&afterUpdate).CalculateTotalCost(&models.SubscriptionFilter{}, nil, nil)
+	if err != nil || total != 1290 {
+		t.Fatalf("CalculateTotalCost после обновления вернул %d, %v, ожидалось 1290", total, err)
+	}
+
+	// История цен в моменте не содержит более поздних изменений
+	history, err := repo.AsOf(&afterCreate).PriceHistory([]uint{created.ID})
+	if prices := history[created.ID]; err != nil || len(prices) != 1 || prices[0].Price != 990 {
+		t.Fatalf("PriceHistory после создания вернул %+v, %v", prices, err)
+	}
+	history, err = repo.AsOf(&afterUpdate).PriceHistory([]uint{created.ID})
+	if prices := history[created.ID]; err != nil || len(prices) != 2 || prices[1].Price != 1290 {
+		t.Fatalf("PriceHistory после обновления вернул %+v, %v", prices, err)
+	}
+
+	// Удаленная подписка не видна ни сейчас, ни в моменте после удаления
+	now := tick()
+	if _, err := repo.AsOf(&now).GetByID(created.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetByID после удаления: ожидалась gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func testSummaries(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	now := time.Now().UTC()
+	thisMonth := month(now.Year(), now.Month())
+	create(t, repo, subscription("Netflix", 990, userA, thisMonth.AddDate(0, -2, 0), nil))
+	create(t, repo, subscription("Spotify", 299, userA, thisMonth.AddDate(-1, 0, 0), ptr(thisMonth.AddDate(0, -6, 0))))
+	create(t, repo, subscription("Netflix", 500, userB, thisMonth.AddDate(0, -1, 0), nil))
+
+	users, err := repo.UserSummaries([]string{userA, userB, "33333333-3333-3333-3333-333333333333"})
+	if err != nil {
+		t.Fatalf("UserSummaries: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("UserSummaries вернул сводки %+v", users)
+	}
+	if a := users[userA]; a.SubscriptionsCount != 2 || a.ActiveSubscriptions != 1 || a.MonthlyCost != 990 {
+		t.Fatalf("сводка пользователя %+v", a)
+	}
+
+	services, err := repo.ServiceSummaries([]string{"Netflix"})
+	if err != nil {
+		t.Fatalf("ServiceSummaries: %v", err)
+	}
+	if n := services["Netflix"]; len(services) != 1 || n.SubscribersCount != 2 || n.MinPrice != 500 || n.MaxPrice != 990 || n.AvgPrice != 745 {
+		t.Fatalf("сводка сервиса %+v", services)
+	}
+}
+
+func testRelated(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	netflixA := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	spotifyA := create(t, repo, subscription("Spotify", 299, userA, month(2025, 3), nil))
+	netflixB := create(t, repo, subscription("Netflix", 500, userB, month(2025, 6), nil))
+
+	sort := parseSort(t, "-price")
+	byUser, err := repo.SubscriptionsByUsers([]string{userA, userB}, sort, 10)
+	if err != nil {
+		t.Fatalf("SubscriptionsByUsers: %v", err)
+	}
+	if !slices.Equal(ids(byUser[userA]), []uint{netflixA.ID, spotifyA.ID}) || !slices.Equal(ids(byUser[userB]), []uint{netflixB.ID}) {
+		t.Fatalf("SubscriptionsByUsers вернул %v и %v", ids(byUser[userA]), ids(byUser[userB]))
+	}
+
+	// limit ограничивает каждую группу отдельно
+	byUser, err = repo.SubscriptionsByUsers([]string{userA, userB}, parseSort(t, "price"), 1)
+	if err != nil || !slices.Equal(ids(byUser[userA]), []uint{spotifyA.ID}) || !slices.Equal(ids(byUser[userB]), []uint{netflixB.ID}) {
+		t.Fatalf("SubscriptionsByUsers с limit вернул %v и %v, %v", ids(byUser[userA]), ids(byUser[userB]), err)
+	}
+
+	byService, err := repo.SubscriptionsByServices([]string{"Netflix"}, sort, 10)
+	if err != nil || len(byService) != 1 || !slices.Equal(ids(byService["Netflix"]), []uint{netflixA.ID, netflixB.ID}) {
+		t.Fatalf("SubscriptionsByServices вернул %v, %v", byService, err)
+	}
+	byService, err = repo.SubscriptionsByServices([]string{"Netflix"}, sort, 1)
+	if err != nil || !slices.Equal(ids(byService["Netflix"]), []uint{netflixA.ID}) {
+		t.Fatalf("SubscriptionsByServices с limit вернул %v, %v", byService, err)
+	}
+
+	costs, err := repo.TotalCostByUsers([]string{userA, userB}, &models.SubscriptionFilter{ServiceNames: []string{"Netflix"}}, nil, month(2025, 3))
+	if err != nil || len(costs) != 1 || costs[userA] != 990 {
+		t.Fatalf("TotalCostByUsers вернул %v, %v", costs, err)
+	}
+	costs, err = repo.TotalCostByServices([]string{"Netflix", "Spotify"}, &models.SubscriptionFilter{}, month(2025, 2), nil)
+	if err != nil || costs["Netflix"] != 500 || costs["Spotify"] != 299 {
+		t.Fatalf("TotalCostByServices вернул %v, %v", costs, err)
+	}
+
+	// Пользователь не видит историю цен чужих подписок
+	user := repo.WithScope(repository.Scope{TenantID: tenantA, UserID: userB})
+	history, err := user.PriceHistory([]uint{netflixA.ID, netflixB.ID})
+	if err != nil || len(history) != 1 || len(history[netflixB.ID]) != 1 {
+		t.Fatalf("PriceHistory пользователя вернул %v, %v", history, err)
+	}
+}
+
+func testSearch(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	netflix := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	tagged := subscription("Okko", 399, userA, month(2025, 1), nil)
+	tagged.Notes = "кино по выходным"
+	tagged.Tags = []string{"netflix-alternative"}
+	okko := create(t, repo, tagged)
+	create(t, repo, subscription("Spotify", 299, userB, month(2025, 1), nil))
+
+	// Совпадение в названии сервиса ранжируется выше совпадения в тегах
+	hits, err := repo.Search("netflix", nil, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != netflix.ID || hits[1].ID != okko.ID || hits[0].Rank <= hits[1].Rank || hits[1].Rank <= 0 {
+		t.Fatalf("Search вернул %+v", hits)
+	}
+
+	hits, err = repo.Search("netflix", &models.SubscriptionFilter{PriceMax: ptr(500)}, 10)
+	if err != nil || len(hits) != 1 || hits[0].ID != okko.ID {
+		t.Fatalf("Search с фильтром вернул %+v, %v", hits, err)
+	}
+}
+
+func testClaimEndingSoon(t *testing.T, repo repository.Subscriptions) {
+	repo = repo.WithScope(repository.Scope{TenantID: tenantA})
+	first := create(t, repo, subscription("Netflix", 990, userA, month(2025, 1), ptr(month(2025, 3))))
+	second := create(t, repo, subscription("Spotify", 299, userB, month(2025, 1), ptr(month(2025, 2))))
+	create(t, repo, subscription("YouTube", 399, userB, month(2025, 1), ptr(month(2025, 8))))
+	create(t, repo.WithScope(repository.Scope{TenantID: tenantB}), subscription("Okko", 399, userB, month(2025, 1), ptr(month(2025, 2))))
+
+	claimed, err := repo.ClaimEndingSoon(month(2025, 2), month(2025, 3))
+	if err != nil || !slices.Equal(ids(claimed), []uint{second.ID, first.ID}) {
+		t.Fatalf("ClaimEndingSoon вернул %v, %v", ids(claimed), err)
+	}
+
+	// О каждой дате окончания напоминают один раз, перенос даты окончания напоминает снова
+	if claimed, err := repo.ClaimEndingSoon(month(2025, 2), month(2025, 3)); err != nil || len(claimed) != 0 {
+		t.Fatalf("повторный ClaimEndingSoon вернул %v, %v", ids(claimed), err)
+	}
+	if _, err := repo.Update(first.ID, &models.Subscription{EndDate: ptr(month(2025, 2))}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if claimed, err := repo.ClaimEndingSoon(month(2025, 2), month(2025, 3)); err != nil || !slices.Equal(ids(claimed), []uint{first.ID}) {
+		t.Fatalf("ClaimEndingSoon после переноса вернул %v, %v", ids(claimed), err)
+	}
+}
+
+func testUserData(t *testing.T, repo repository.Subscriptions) {
+	a := repo.WithScope(repository.Scope{TenantID: tenantA})
+	own := create(t, a, subscription("Netflix", 990, userA, month(2025, 1), nil))
+	trashed := create(t, a, subscription("Spotify", 299, userA, month(2025, 1), nil))
+	other := create(t, a, subscription("Okko", 399, userB, month(2025, 1), nil))
+	otherTenant := create(t, repo.WithScope(repository.Scope{TenantID: tenantB}), subscription("Netflix", 990, userA, month(2025, 1), nil))
+	if _, err := a.Update(own.ID, &models.Subscription{Price: 1290}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := a.Delete(trashed.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Данные пользователя включают корзину и не включают чужие подписки и другие организации
+	data, err := a.UserData(userA)
+	if err != nil {
+		t.Fatalf("UserData: %v", err)
+	}
+	if got := ids(data.Subscriptions); !slices.Equal(got, []uint{own.ID, trashed.ID}) {
+		t.Fatalf("UserData вернул подписки %v", got)
+	}
+	if len(data.PriceHistory) != 3 || data.PriceHistory[0].SubscriptionID != own.ID || data.PriceHistory[1].Price != 1290 {
+		t.Fatalf("UserData вернул историю цен %+v", data.PriceHistory)
+	}
+	if len(data.Versions) < 3 {
+		t.Fatalf("UserData вернул %d версий", len(data.Versions))
+	}
+	for _, version := range data.Versions {
+		if version.UserID != userA || version.TenantID != tenantA {
+			t.Fatalf("UserData вернул чужую версию %+v", version)
+		}
+	}
+
+	// Обезличивание заменяет ID пользователя и удаляет заметки и метки, не меняя стоимость
+	const pseudonym = "33333333-3333-3333-3333-333333333333"
+	erasure, err := a.AnonymizeUser(userA, pseudonym)
+	if err != nil {
+		t.Fatalf("AnonymizeUser: %v", err)
+	}
+	if erasure.Pseudonym != pseudonym || erasure.Subscriptions != 2 || erasure.Versions != int64(len(data.Versions)) {
+		t.Fatalf("AnonymizeUser вернул %+v", erasure)
+	}
+	if data, err := a.UserData(userA); err != nil || len(data.Subscriptions) != 0 || len(data.Versions) != 0 {
+		t.Fatalf("после обезличивания UserData вернул %+v, %v", data, err)
+	}
+	got, err := a.GetByID(own.ID)
+	if err != nil || got.UserID != pseudonym || got.Notes != "" || len(got.Tags) != 0 || got.Price != 1290 {
+		t.Fatalf("после обезличивания GetByID вернул %+v, %v", got, err)
+	}
+	if total, err := a.CalculateTotalCost(&models.SubscriptionFilter{}, nil, nil); err != nil || total != 1290+399 {
+		t.Fatalf("после обезличивания CalculateTotalCost вернул %d, %v", total, err)
+	}
+	if got, err := a.GetByID(other.ID); err != nil || got.UserID != userB || got.Notes == "" {
+		t.Fatalf("обезличивание изменило подписку другого пользователя: %+v, %v", got, err)
+	}
+	b := repo.WithScope(repository.Scope{TenantID: tenantB})
+	if got, err := b.GetByID(otherTenant.ID); err != nil || got.UserID != userA {
+		t.Fatalf("обезличивание изменило подписку другой организации: %+v, %v", got, err)
+	}
+}
+
+// subscription создает подписку с заметками и тегами
+func subscription(service string, price int, userID string, start time.Time, end *time.Time) *models.Subscription {
+	return &models.Subscription{
+		ServiceName: service,
+		Price:       price,
+		UserID:      userID,
+		StartDate:   start,
+		EndDate:     end,
+		Notes:       "семейный тариф",
+		Tags:        []string{"video", "family"},
+	}
+}
+
+// create сохраняет подписку и завершает проверку при ошибке
+func create(t *testing.T, repo repository.Subscriptions, s *models.Subscription) *models.Subscription {
+	t.Helper()
+	if err := repo.Create(s); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return s
+}
+
+// list получает все подписки, подходящие под фильтр, и завершает проверку при ошибке
+func list(t *testing.T, repo repository.Subscriptions, filter *models.SubscriptionFilter, sort []models.SortField) []models.Subscription {
+	t.Helper()
+	subscriptions, err := repo.List(filter, sort, 0, 1000)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return subscriptions
+}
+
+// parseSort разбирает сортировку и завершает проверку при ошибке
+func parseSort(t *testing.T, value string) []models.SortField {
+	t.Helper()
+	sort, err := models.ParseSort(value)
+	if err != nil {
+		t.Fatalf("ParseSort(%q): %v", value, err)
+	}
+	return sort
+}
+
+// outboxMessage создает сообщение outbox с уникальным ID события
+func outboxMessage(eventID string) models.OutboxMessage {
+	return models.OutboxMessage{
+		EventID:   eventID,
+		TenantID:  tenantA,
+		EventType: models.EventSubscriptionUpdated,
+		Payload:   []byte(`{}`),
+		CreatedAt: time.Now(),
+	}
+}
+
+// ids возвращает ID подписок в порядке списка
+func ids(subscriptions []models.Subscription) []uint {
+	result := make([]uint, len(subscriptions))
+	for i, s := range subscriptions {
+		result[i] = s.ID
+	}
+	return result
+}
+
+// month возвращает первое число месяца в UTC, как даты подписок
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// tick возвращает текущий момент, отделенный от соседних изменений, чтобы версии в моменте были однозначны
+func tick() time.Time {
+	time.Sleep(5 * time.Millisecond)
+	now := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	return now
+}
+
+// ptr возвращает указатель на значение
+func ptr[T any](value T) *T {
+	return &value
+}
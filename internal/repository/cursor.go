@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// ErrInvalidCursor возвращается, если курсор пагинации не удалось разобрать
+var ErrInvalidCursor = errors.New("неверный курсор пагинации")
+
+// SubscriptionCursor позиция в списке подписок для keyset-пагинации.
+// Хранит сортировку, для которой создан, и значения полей сортировки последней строки страницы
+type SubscriptionCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// CursorAfter возвращает курсор, указывающий на подписку в списке с сортировкой sort
+func CursorAfter(subscription *models.Subscription, sort []models.SortField) *SubscriptionCursor {
+	order := orderWithTieBreaker(sort)
+
+	values := make([]string, len(order))
+	for i, field := range order {
+		values[i] = sortColumns[field.Field].value(subscription)
+	}
+
+	return &SubscriptionCursor{Sort: models.FormatSort(order), Values: values}
+}
+
+// Encode кодирует курсор в непрозрачную строку для клиента
+func (c *SubscriptionCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSubscriptionCursor разбирает строку, полученную из Encode.
+// Курсор, созданный для другой сортировки, считается неверным
+func DecodeSubscriptionCursor(value string, sort []models.SortField) (*SubscriptionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor SubscriptionCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	order := orderWithTieBreaker(sort)
+	if cursor.Sort != models.FormatSort(order) || len(cursor.Values) != len(order) {
+		return nil, errors.New("курсор создан для другой сортировки")
+	}
+
+	return &cursor, nil
+}
+
+// args возвращает значения курсора как аргументы запроса
+func (c *SubscriptionCursor) args(order []models.SortField) ([]any, error) {
+	args := make([]any, len(order))
+	for i, field := range order {
+		arg, err := sortColumns[field.Field].arg(c.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
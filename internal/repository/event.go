@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventsChannel канал LISTEN/NOTIFY, в который сообщается о новых событиях журнала
+const EventsChannel = "subscription_events"
+
+// eventPollInterval период чтения журнала событий в базах данных без LISTEN/NOTIFY
+const eventPollInterval = time.Second
+
+// EventRepository обрабатывает операции с базой данных для журнала событий подписок
+type EventRepository struct {
+	db *gorm.DB
+}
+
+// NewEventRepository создает новый репозиторий журнала событий
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// Append добавляет события в журнал и уведомляет слушателей канала EventsChannel.
+// Записи журнала сериализуются блокировкой, поэтому порядковые номера становятся видимыми
+// строго по возрастанию и читатель, запомнивший последний номер, не пропустит события.
+// События, уже записанные в журнал, пропускаются
+func (r *EventRepository) Append(ctx context.Context, events []models.StoredEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !isPostgres(tx) {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(events, bulkBatchSize).Error
+		}
+
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", EventsChannel).Error; err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(events, bulkBatchSize)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		// Уведомление доставляется слушателям после фиксации транзакции
+		return tx.Exec("SELECT pg_notify(?, ?)", EventsChannel, strconv.FormatInt(result.RowsAffected, 10)).Error
+	})
+}
+
+// ListAfter получает до limit событий организации tenantID с номером больше after в порядке возрастания.
+// Если userID задан, возвращаются только события подписок этого пользователя
+func (r *EventRepository) ListAfter(after int64, tenantID, userID string, limit int) ([]models.StoredEvent, error) {
+	var events []models.StoredEvent
+	query := r.db.Where("sequence > ? AND tenant_id = ?", after, tenantID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Order("sequence").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// ListAllAfter получает до limit событий всех организаций с номером больше after в порядке возрастания.
+// Используется только для внутренней раздачи событий получателям, которые сами отбирают события своей организации
+func (r *EventRepository) ListAllAfter(after int64, limit int) ([]models.StoredEvent, error) {
+	var events []models.StoredEvent
+	err := r.db.Where("sequence > ?", after).Order("sequence").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// LastSequence возвращает номер последнего события журнала или 0, если журнал пуст
+func (r *EventRepository) LastSequence() (int64, error) {
+	var last int64
+	err := r.db.Model(&models.StoredEvent{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error
+	return last, err
+}
+
+// DeleteBefore удаляет события, сохраненные раньше before, и возвращает их количество
+func (r *EventRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.StoredEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// Listen подписывается на канал EventsChannel на отдельном соединении и вызывает notify
+// для каждого уведомления. Возвращает управление при отмене ctx или потере соединения.
+// Без PostgreSQL уведомлений нет, и notify вызывается раз в eventPollInterval
+func (r *EventRepository) Listen(ctx context.Context, listening func(), notify func()) error {
+	if !isPostgres(r.db) {
+		listening()
+		ticker := time.NewTicker(eventPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				notify()
+			}
+		}
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("LISTEN не поддерживается драйвером %T", driverConn)
+		}
+		pgConn := stdlibConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+EventsChannel); err != nil {
+			return err
+		}
+		listening()
+
+		for {
+			if _, err := pgConn.WaitForNotification(ctx); err != nil {
+				return err
+			}
+			notify()
+		}
+	})
+}
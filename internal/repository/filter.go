@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// sortColumn описывает поле, по которому разрешена сортировка
+type sortColumn struct {
+	// SQL выражение для ORDER BY и условий курсора
+	expr string
+	// Значение поля подписки для курсора
+	value func(*models.Subscription) string
+	// Аргумент запроса из значения курсора
+	arg func(string) (any, error)
+}
+
+// sortColumns выражения для полей из models.SortableFields.
+// Бессрочные подписки сортируются как подписки с бесконечной датой окончания,
+// это совпадает с порядком NULL в PostgreSQL и позволяет сравнивать значения в курсоре
+var sortColumns = map[string]sortColumn{
+	"id": {
+		expr:  "id",
+		value: func(s *models.Subscription) string { return strconv.FormatUint(uint64(s.ID), 10) },
+		arg:   func(v string) (any, error) { return strconv.ParseUint(v, 10, 64) },
+	},
+	"service_name": {
+		expr:  "service_name",
+		value: func(s *models.Subscription) string { return s.ServiceName },
+		arg:   func(v string) (any, error) { return v, nil },
+	},
+	"price": {
+		expr:  "price",
+		value: func(s *models.Subscription) string { return strconv.Itoa(s.Price) },
+		arg:   func(v string) (any, error) { return strconv.Atoi(v) },
+	},
+	"user_id": {
+		expr:  "user_id",
+		value: func(s *models.Subscription) string { return s.UserID },
+		arg:   func(v string) (any, error) { return v, nil },
+	},
+	"start_date": {
+		expr:  "start_date",
+		value: func(s *models.Subscription) string { return formatCursorTime(s.StartDate) },
+		arg:   parseCursorTime,
+	},
+	"end_date": {
+		expr: "COALESCE(end_date, 'infinity')",
+		value: func(s *models.Subscription) string {
+			if s.EndDate == nil {
+				return "infinity"
+			}
+			return formatCursorTime(*s.EndDate)
+		},
+		arg: func(v string) (any, error) {
+			if v == "infinity" {
+				return v, nil
+			}
+			return parseCursorTime(v)
+		},
+	},
+	"created_at": {
+		expr:  "created_at",
+		value: func(s *models.Subscription) string { return formatCursorTime(s.CreatedAt) },
+		arg:   parseCursorTime,
+	},
+	"updated_at": {
+		expr:  "updated_at",
+		value: func(s *models.Subscription) string { return formatCursorTime(s.UpdatedAt) },
+		arg:   parseCursorTime,
+	},
+}
+
+// applyFilter добавляет условия фильтра в запрос
+func applyFilter(query *gorm.DB, filter *models.SubscriptionFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+
+	// Точные совпадения с одним или несколькими значениями
+	if len(filter.UserIDs) == 1 {
+		query = query.Where("user_id = ?", filter.UserIDs[0])
+	} else if len(filter.UserIDs) > 1 {
+		query = query.Where("user_id IN ?", filter.UserIDs)
+	}
+	if len(filter.ServiceNames) == 1 {
+		query = query.Where("service_name = ?", filter.ServiceNames[0])
+	} else if len(filter.ServiceNames) > 1 {
+		query = query.Where("service_name IN ?", filter.ServiceNames)
+	}
+
+	// Частичное совпадение без учета регистра. В SQLite LIKE и так не учитывает регистр латиницы,
+	// но символ экранирования нужно указать явно
+	if filter.ServiceNameContains != "" {
+		pattern := "%" + escapeLike(filter.ServiceNameContains) + "%"
+		if isPostgres(query) {
+			query = query.Where("service_name ILIKE ?", pattern)
+		} else {
+			query = query.Where(`service_name LIKE ? ESCAPE '\'`, pattern)
+		}
+	}
+
+	// Диапазоны
+	if filter.PriceMin != nil {
+		query = query.Where("price >= ?", *filter.PriceMin)
+	}
+	if filter.PriceMax != nil {
+		query = query.Where("price <= ?", *filter.PriceMax)
+	}
+	if filter.StartFrom != nil {
+		query = query.Where("start_date >= ?", *filter.StartFrom)
+	}
+	if filter.StartTo != nil {
+		query = query.Where("start_date <= ?", *filter.StartTo)
+	}
+	if filter.EndFrom != nil {
+		query = query.Where("end_date >= ?", *filter.EndFrom)
+	}
+	if filter.EndTo != nil {
+		query = query.Where("end_date <= ?", *filter.EndTo)
+	}
+
+	// Подписка действует в месяце: началась не позже его конца и не закончилась до его начала
+	if filter.ActiveAt != nil {
+		monthStart := utils.GetFirstDayOfMonth(*filter.ActiveAt)
+		monthEnd := utils.GetLastDayOfMonth(*filter.ActiveAt)
+		query = query.Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", monthEnd, monthStart)
+	}
+
+	if filter.OpenEnded != nil {
+		if *filter.OpenEnded {
+			query = query.Where("end_date IS NULL")
+		} else {
+			query = query.Where("end_date IS NOT NULL")
+		}
+	}
+
+	// Удаленные подписки исключаются автоматически, пока запрос не снимает это условие
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+
+	return query
+}
+
+// orderWithTieBreaker добавляет в конец сортировки id, чтобы порядок строк был однозначным
+func orderWithTieBreaker(sort []models.SortField) []models.SortField {
+	if len(sort) == 0 {
+		sort = models.DefaultSort
+	}
+	for _, field := range sort {
+		if field.Field == "id" {
+			return sort
+		}
+	}
+
+	order := make([]models.SortField, len(sort), len(sort)+1)
+	copy(order, sort)
+	return append(order, models.SortField{Field: "id", Desc: sort[len(sort)-1].Desc})
+}
+
+// applyOrder добавляет сортировку в запрос
+func applyOrder(query *gorm.DB, order []models.SortField) *gorm.DB {
+	return query.Order(orderClause(order))
+}
+
+// orderClause возвращает выражение ORDER BY для сортировки order
+func orderClause(order []models.SortField) string {
+	exprs := make([]string, len(order))
+	for i, field := range order {
+		exprs[i] = sortColumns[field.Field].expr
+		if field.Desc {
+			exprs[i] += " DESC"
+		}
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// applyKeyset добавляет условие "строка после курсора" для сортировки order
+func applyKeyset(query *gorm.DB, order []models.SortField, values []any) *gorm.DB {
+	// Если все поля сортируются в одном направлении, достаточно сравнения кортежей,
+	// которое PostgreSQL выполняет по составному индексу
+	sameDirection := true
+	for _, field := range order {
+		sameDirection = sameDirection && field.Desc == order[0].Desc
+	}
+
+	columns := make([]string, len(order))
+	for i, field := range order {
+		columns[i] = sortColumns[field.Field].expr
+	}
+
+	if sameDirection {
+		op := ">"
+		if order[0].Desc {
+			op = "<"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return query.Where("("+strings.Join(columns, ", ")+") "+op+" ("+placeholders+")", values...)
+	}
+
+	// Для разных направлений: (a > x) OR (a = x AND b < y) OR ...
+	var conditions []string
+	var args []any
+	for i, field := range order {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if field.Desc {
+			op = " < ?"
+		}
+		parts = append(parts, columns[i]+op)
+		args = append(args, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// formatCursorTime форматирует время для курсора
+func formatCursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseCursorTime разбирает время из курсора
+func parseCursorTime(value string) (any, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
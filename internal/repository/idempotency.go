@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository обрабатывает операции с базой данных для ключей идемпотентности
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository создает новый репозиторий ключей идемпотентности
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve атомарно резервирует ключ. Возвращает false, если ключ уже существует
+func (r *IdempotencyRepository) Reserve(key *models.IdempotencyKey) (bool, error) {
+	// Вставить ключ; при конфликте ничего не делать, чтобы параллельные запросы не выполнились дважды
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// GetByKey получает ключ идемпотентности организации по его значению
+func (r *IdempotencyRepository) GetByKey(tenantID, key string) (*models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+	err := r.db.Where("tenant_id = ? AND key = ?", tenantID, key).First(&idempotencyKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyKey, nil
+}
+
+// Complete сохраняет ответ для зарезервированного ключа организации
+func (r *IdempotencyRepository) Complete(tenantID, key string, statusCode int, contentType string, body []byte) error {
+	return r.db.Model(&models.IdempotencyKey{}).Where("tenant_id = ? AND key = ?", tenantID, key).Updates(map[string]any{
+		"completed":     true,
+		"status_code":   statusCode,
+		"content_type":  contentType,
+		"response_body": body,
+	}).Error
+}
+
+// Delete удаляет ключ идемпотентности организации
+func (r *IdempotencyRepository) Delete(tenantID, key string) error {
+	return r.db.Where("tenant_id = ? AND key = ?", tenantID, key).Delete(&models.IdempotencyKey{}).Error
+}
+
+// DeleteIfExpired удаляет ключ организации, только если срок его хранения истек к моменту now,
+// чтобы ключ можно было зарезервировать заново. Возвращает false, если ключ не удален
+func (r *IdempotencyRepository) DeleteIfExpired(tenantID, key string, now time.Time) (bool, error) {
+	result := r.db.Where("tenant_id = ? AND key = ? AND expires_at < ?", tenantID, key, now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteExpired удаляет ключи, срок хранения которых истек, и возвращает их количество
+func (r *IdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+)
+
+// Один и тот же Idempotency-Key разных организаций - разные ключи
+func TestIdempotencyKeyPerTenant(t *testing.T) {
+	repo := repository.NewIdempotencyRepository(openDB(t, database.DriverMemory))
+	now := time.Now()
+	reserve := func(tenantID, hash string) bool {
+		t.Helper()
+		reserved, err := repo.Reserve(&models.IdempotencyKey{
+			TenantID: tenantID, Key: "key-1", RequestHash: hash, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		return reserved
+	}
+
+	if !reserve("tenant-a", "hash-a") {
+		t.Fatal("новый ключ организации A не зарезервирован")
+	}
+	if !reserve("tenant-b", "hash-b") {
+		t.Fatal("ключ организации B конфликтует с ключом организации A")
+	}
+	if reserve("tenant-a", "hash-a") {
+		t.Fatal("повторный ключ организации A зарезервирован еще раз")
+	}
+
+	if err := repo.Complete("tenant-a", "key-1", 201, "application/json", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	a, err := repo.GetByKey("tenant-a", "key-1")
+	if err != nil || !a.Completed || a.RequestHash != "hash-a" {
+		t.Fatalf("GetByKey организации A вернул %+v, %v", a, err)
+	}
+	b, err := repo.GetByKey("tenant-b", "key-1")
+	if err != nil || b.Completed || b.RequestHash != "hash-b" {
+		t.Fatalf("GetByKey организации B вернул %+v, %v", b, err)
+	}
+
+	// Освобождение ключа одной организации не затрагивает другую
+	if err := repo.Delete("tenant-b", "key-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByKey("tenant-a", "key-1"); err != nil {
+		t.Fatalf("Delete ключа организации B удалил ключ организации A: %v", err)
+	}
+}
@@ -0,0 +1,1117 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// MemorySubscriptionRepository хранилище подписок в памяти процесса для тестов, локального запуска
+// и встраивания сервиса в утилиты командной строки. Хранит подписки, историю цен, версии и напоминания
+// и ведет себя так же, как SubscriptionRepository. Каждое изменение применяется к копии состояния
+// и заменяет его целиком, поэтому изменения атомарны, но их стоимость растет с числом подписок.
+// Данные теряются при остановке процесса
+type MemorySubscriptionRepository struct {
+	store *memoryStore
+	// tx состояние транзакции; пока оно задано, блокировка хранилища уже захвачена
+	tx *memoryData
+	// db база данных для outbox и журнала аудита; без нее они хранятся в памяти вместе с подписками
+	db    *gorm.DB
+	scope Scope
+	asOf  *time.Time
+}
+
+// memoryStore общее состояние хранилища и его блокировка
+type memoryStore struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+// memoryData состояние хранилища в памяти
+type memoryData struct {
+	subscriptions map[uint]models.Subscription
+	priceHistory  []models.PriceChange
+	versions      []models.SubscriptionVersion
+	reminders     map[memoryReminder]struct{}
+	outbox        []models.OutboxMessage
+	audit         []models.AuditEntry
+
+	// Последние выданные ID
+	lastID        uint
+	lastChangeID  uint
+	lastVersionID uint
+	lastOutboxID  uint64
+	lastAuditID   uint
+}
+
+// memoryReminder отправленное напоминание об окончании подписки
+type memoryReminder struct {
+	subscriptionID uint
+	endDate        int64
+}
+
+// NewMemorySubscriptionRepository создает пустое хранилище подписок в памяти. Если db задана,
+// события outbox и записи аудита пишутся в нее в транзакции, которая фиксируется вместе с изменением
+// подписок; иначе они хранятся в памяти и доступны через OutboxMessages и AuditEntries
+func NewMemorySubscriptionRepository(db *gorm.DB) *MemorySubscriptionRepository {
+	return &MemorySubscriptionRepository{
+		store: &memoryStore{data: &memoryData{
+			subscriptions: make(map[uint]models.Subscription),
+			reminders:     make(map[memoryReminder]struct{}),
+		}},
+		db: db,
+	}
+}
+
+// WithScope возвращает хранилище, запросы которого видят только подписки из scope,
+// а новые подписки создаются в организации scope
+func (r *MemorySubscriptionRepository) WithScope(scope Scope) Subscriptions {
+	return &MemorySubscriptionRepository{store: r.store, tx: r.tx, db: r.db, scope: scope, asOf: r.asOf}
+}
+
+// AsOf возвращает хранилище, запросы которого видят подписки в состоянии на момент at по истории версий.
+// Если at равен nil, возвращается хранилище с текущим состоянием
+func (r *MemorySubscriptionRepository) AsOf(at *time.Time) Subscriptions {
+	if at == nil {
+		return r
+	}
+	return &MemorySubscriptionRepository{store: r.store, tx: r.tx, db: r.db, scope: r.scope, asOf: at}
+}
+
+// Transaction выполняет fn над копией состояния и сохраняет ее, только если fn завершилась без ошибки.
+// Outbox и журнал аудита в базе данных пишутся в ее транзакции, которая откатывается при ошибке fn.
+// Другие операции с хранилищем ждут окончания транзакции
+func (r *MemorySubscriptionRepository) Transaction(fn func(repo Subscriptions, outbox OutboxWriter, audit AuditWriter) error) error {
+	return r.write(func(d *memoryData) error {
+		tx := &MemorySubscriptionRepository{store: r.store, tx: d, db: r.db, scope: r.scope}
+		if r.db == nil {
+			return fn(tx, memoryOutbox{d}, memoryAudit{d})
+		}
+		return r.db.Transaction(func(db *gorm.DB) error {
+			tx.db = db
+			return fn(tx, NewOutboxRepository(db), NewAuditRepository(db))
+		})
+	})
+}
+
+// OutboxMessages возвращает события outbox, записанные без базы данных, в порядке записи
+func (r *MemorySubscriptionRepository) OutboxMessages() []models.OutboxMessage {
+	var messages []models.OutboxMessage
+	r.read(func(d *memoryData) error {
+		messages = slices.Clone(d.outbox)
+		return nil
+	})
+	return messages
+}
+
+// AuditEntries возвращает записи аудита, записанные без базы данных, в порядке записи
+func (r *MemorySubscriptionRepository) AuditEntries() []models.AuditEntry {
+	var entries []models.AuditEntry
+	r.read(func(d *memoryData) error {
+		entries = slices.Clone(d.audit)
+		return nil
+	})
+	return entries
+}
+
+// Create создает новую подписку в организации хранилища и записывает начальную цену и первую версию в историю
+func (r *MemorySubscriptionRepository) Create(subscription *models.Subscription) error {
+	subscription.TenantID = r.scope.TenantID
+	return r.write(func(d *memoryData) error {
+		d.create(subscription, memoryNow())
+		return nil
+	})
+}
+
+// GetByID получает подписку по её ID
+func (r *MemorySubscriptionRepository) GetByID(id uint) (*models.Subscription, error) {
+	var subscription *models.Subscription
+	err := r.read(func(d *memoryData) error {
+		for _, s := range r.rows(d) {
+			if s.ID == id && !s.DeletedAt.Valid {
+				subscription = &s
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return subscription, err
+}
+
+// Update обновляет предоставленные поля подписки и возвращает ее состояние до и после обновления
+func (r *MemorySubscriptionRepository) Update(id uint, subscription *models.Subscription) (*SubscriptionChange, error) {
+	var change *SubscriptionChange
+	err := r.write(func(d *memoryData) error {
+		var err error
+		change, err = d.update(r.scope, id, subscription, memoryNow())
+		return err
+	})
+	return change, err
+}
+
+// Delete перемещает подписку в корзину и возвращает удаленную подписку
+func (r *MemorySubscriptionRepository) Delete(id uint) (*models.Subscription, error) {
+	var deleted []models.Subscription
+	err := r.write(func(d *memoryData) error {
+		deleted = d.delete(r.scope, memoryNow(), func(s *models.Subscription) bool { return s.ID == id })
+		if len(deleted) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted[0], nil
+}
+
+// ListDeleted получает подписки из корзины, начиная с удаленных последними
+func (r *MemorySubscriptionRepository) ListDeleted(offset, limit int) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := r.read(func(d *memoryData) error {
+		for _, s := range r.rows(d) {
+			if s.DeletedAt.Valid {
+				subscriptions = append(subscriptions, s)
+			}
+		}
+		return nil
+	})
+	slices.SortFunc(subscriptions, func(a, b models.Subscription) int {
+		return cmp.Or(b.DeletedAt.Time.Compare(a.DeletedAt.Time), cmp.Compare(b.ID, a.ID))
+	})
+	return page(subscriptions, offset, limit), err
+}
+
+// Restore возвращает подписку из корзины и возвращает ее состояние до и после восстановления
+func (r *MemorySubscriptionRepository) Restore(id uint) (*SubscriptionChange, error) {
+	var change *SubscriptionChange
+	err := r.write(func(d *memoryData) error {
+		current, ok := d.subscriptions[id]
+		if !ok || !r.scope.contains(&current) || !current.DeletedAt.Valid {
+			return gorm.ErrRecordNotFound
+		}
+
+		restored := current
+		restored.DeletedAt = gorm.DeletedAt{}
+		restored.UpdatedAt = memoryNow()
+		d.subscriptions[id] = restored
+		d.recordVersions(restored.UpdatedAt, restored)
+
+		change = &SubscriptionChange{Before: copySubscription(current), After: copySubscription(restored)}
+		return nil
+	})
+	return change, err
+}
+
+// PurgeDeleted окончательно удаляет подписки всех организаций, находящиеся в корзине с момента before,
+// вместе с историей цен и напоминаниями. Версии подписок остаются
+func (r *MemorySubscriptionRepository) PurgeDeleted(before time.Time) (int64, error) {
+	var purged int64
+	err := r.write(func(d *memoryData) error {
+		for id, s := range d.subscriptions {
+			if s.DeletedAt.Valid && s.DeletedAt.Time.Before(before) {
+				delete(d.subscriptions, id)
+				purged++
+			}
+		}
+		d.priceHistory = slices.DeleteFunc(d.priceHistory, func(change models.PriceChange) bool {
+			_, ok := d.subscriptions[change.SubscriptionID]
+			return !ok
+		})
+		maps.DeleteFunc(d.reminders, func(reminder memoryReminder, _ struct{}) bool {
+			_, ok := d.subscriptions[reminder.subscriptionID]
+			return !ok
+		})
+		return nil
+	})
+	return purged, err
+}
+
+// List получает подписки, подходящие под фильтр, с сортировкой и пагинацией по смещению
+func (r *MemorySubscriptionRepository) List(filter *models.SubscriptionFilter, sort []models.SortField, offset, limit int) ([]models.Subscription, error) {
+	subscriptions, err := r.find(filter, orderWithTieBreaker(sort), nil)
+	return page(subscriptions, offset, limit), err
+}
+
+// ListAfter получает подписки, подходящие под фильтр, которые следуют за курсором в порядке сортировки
+func (r *MemorySubscriptionRepository) ListAfter(filter *models.SubscriptionFilter, sort []models.SortField, cursor *SubscriptionCursor, limit int) ([]models.Subscription, error) {
+	order := orderWithTieBreaker(sort)
+
+	var after []any
+	if cursor != nil {
+		var err error
+		if after, err = cursor.args(order); err != nil {
+			return nil, err
+		}
+	}
+
+	subscriptions, err := r.find(filter, order, after)
+	return page(subscriptions, 0, limit), err
+}
+
+// Count возвращает количество подписок, подходящих под фильтр
+func (r *MemorySubscriptionRepository) Count(filter *models.SubscriptionFilter) (int64, error) {
+	subscriptions, err := r.find(filter, nil, nil)
+	return int64(len(subscriptions)), err
+}
+
+// CalculateTotalCost вычисляет общую стоимость подписок, подходящих под фильтр,
+// с датой начала в опциональном диапазоне startDate-endDate
+func (r *MemorySubscriptionRepository) CalculateTotalCost(filter *models.SubscriptionFilter, startDate, endDate any) (int64, error) {
+	subscriptions, err := r.find(filter, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, s := range subscriptions {
+		if startsWithin(&s, startDate, endDate) {
+			total += int64(s.Price)
+		}
+	}
+	return total, nil
+}
+
+// Iterate передает в fn по одной подписки, подходящие под фильтр, в порядке сортировки.
+// Подписки читаются заранее, поэтому fn может обращаться к хранилищу
+func (r *MemorySubscriptionRepository) Iterate(ctx context.Context, filter *models.SubscriptionFilter, sort []models.SortField, fn func(*models.Subscription) error) error {
+	subscriptions, err := r.find(filter, orderWithTieBreaker(sort), nil)
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&subscriptions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search ищет подстроку без учета регистра в названии сервиса, заметках и тегах подписок,
+// подходящих под фильтр. Ранг совпадает с SubscriptionRepository без PostgreSQL
+func (r *MemorySubscriptionRepository) Search(query string, filter *models.SubscriptionFilter, limit int) ([]models.SubscriptionSearchHit, error) {
+	subscriptions, err := r.find(filter, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	contains := func(value string) bool { return strings.Contains(strings.ToLower(value), query) }
+
+	var hits []models.SubscriptionSearchHit
+	for _, s := range subscriptions {
+		switch {
+		case contains(s.ServiceName):
+			hits = append(hits, models.SubscriptionSearchHit{Subscription: s, Rank: substringRankServiceName})
+		case contains(s.Notes) || slices.ContainsFunc(s.Tags, contains):
+			hits = append(hits, models.SubscriptionSearchHit{Subscription: s, Rank: substringRankNotesOrTags})
+		}
+	}
+	slices.SortFunc(hits, func(a, b models.SubscriptionSearchHit) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(b.ID, a.ID))
+	})
+	return page(hits, 0, limit), nil
+}
+
+// BulkCreate создает подписки атомарно. В памяти создание строки не может завершиться ошибкой,
+// поэтому срез ошибок всегда пуст
+func (r *MemorySubscriptionRepository) BulkCreate(subscriptions []*models.Subscription, bestEffort bool) ([]error, error) {
+	for _, subscription := range subscriptions {
+		subscription.TenantID = r.scope.TenantID
+	}
+
+	err := r.write(func(d *memoryData) error {
+		now := memoryNow()
+		for _, subscription := range subscriptions {
+			d.create(subscription, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return make([]error, len(subscriptions)), nil
+}
+
+// BulkUpdate обновляет подписки атомарно и возвращает изменения успешно обновленных строк.
+// Для отсутствующих подписок возвращается gorm.ErrRecordNotFound под индексом строки.
+// В режиме bestEffort ошибка отдельной строки не откатывает остальные
+func (r *MemorySubscriptionRepository) BulkUpdate(ids []uint, subscriptions []*models.Subscription, bestEffort bool) ([]*SubscriptionChange, []error, error) {
+	changes := make([]*SubscriptionChange, len(subscriptions))
+	errs := make([]error, len(subscriptions))
+
+	err := r.write(func(d *memoryData) error {
+		now := memoryNow()
+		for i, subscription := range subscriptions {
+			change, err := d.update(r.scope, ids[i], subscription, now)
+			if err == nil {
+				changes[i] = change
+				continue
+			}
+			if !bestEffort {
+				return &BulkItemError{Index: i, Err: err}
+			}
+			errs[i] = err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changes, errs, nil
+}
+
+// BulkDeleteByIDs перемещает подписки по списку ID в корзину атомарно и возвращает удаленные подписки.
+// Если allOrNothing установлен и хотя бы одна подписка не найдена, ничего не удаляется,
+// возвращается gorm.ErrRecordNotFound и подписки, которые были бы удалены
+func (r *MemorySubscriptionRepository) BulkDeleteByIDs(ids []uint, allOrNothing bool) ([]models.Subscription, error) {
+	unique := uniqueIDs(ids)
+
+	var deleted []models.Subscription
+	err := r.write(func(d *memoryData) error {
+		deleted = d.delete(r.scope, memoryNow(), func(s *models.Subscription) bool {
+			_, ok := unique[s.ID]
+			return ok
+		})
+		if allOrNothing && len(deleted) != len(unique) {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		return deleted, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// BulkDeleteByFilter перемещает в корзину не больше limit подписок с наименьшими ID, подходящих под фильтр,
+// и возвращает удаленные подписки
+func (r *MemorySubscriptionRepository) BulkDeleteByFilter(filter *models.SubscriptionFilter, limit int) ([]models.Subscription, error) {
+	active := *filter
+	active.IncludeDeleted = false
+
+	var deleted []models.Subscription
+	err := r.write(func(d *memoryData) error {
+		var matched []uint
+		for _, s := range d.subscriptions {
+			if r.scope.contains(&s) && !s.DeletedAt.Valid && matchesFilter(&s, &active) {
+				matched = append(matched, s.ID)
+			}
+		}
+		slices.Sort(matched)
+		matched = matched[:min(limit, len(matched))]
+
+		deleted = d.delete(r.scope, memoryNow(), func(s *models.Subscription) bool {
+			_, found := slices.BinarySearch(matched, s.ID)
+			return found
+		})
+		return nil
+	})
+	return deleted, err
+}
+
+// UserSummaries получает сводки по подпискам пользователей
+func (r *MemorySubscriptionRepository) UserSummaries(userIDs []string) (map[string]models.UserSummary, error) {
+	subscriptions, err := r.find(&models.SubscriptionFilter{UserIDs: userIDs}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подписка активна, если началась и еще не закончилась
+	now := time.Now()
+	result := make(map[string]models.UserSummary)
+	for _, s := range subscriptions {
+		summary := result[s.UserID]
+		summary.ID = s.UserID
+		summary.SubscriptionsCount++
+		if !s.StartDate.After(now) && (s.EndDate == nil || !s.EndDate.Before(now)) {
+			summary.ActiveSubscriptions++
+			summary.MonthlyCost += int64(s.Price)
+		}
+		result[s.UserID] = summary
+	}
+	return result, nil
+}
+
+// ServiceSummaries получает сводки по подпискам на сервисы
+func (r *MemorySubscriptionRepository) ServiceSummaries(serviceNames []string) (map[string]models.ServiceSummary, error) {
+	subscriptions, err := r.find(&models.SubscriptionFilter{ServiceNames: serviceNames}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := make(map[string][]models.Subscription)
+	for _, s := range subscriptions {
+		byService[s.ServiceName] = append(byService[s.ServiceName], s)
+	}
+
+	result := make(map[string]models.ServiceSummary, len(byService))
+	for name, subscriptions := range byService {
+		summary := models.ServiceSummary{Name: name, MinPrice: subscriptions[0].Price, MaxPrice: subscriptions[0].Price}
+		users := make(map[string]struct{})
+		var total int
+		for _, s := range subscriptions {
+			users[s.UserID] = struct{}{}
+			summary.MinPrice = min(summary.MinPrice, s.Price)
+			summary.MaxPrice = max(summary.MaxPrice, s.Price)
+			total += s.Price
+		}
+		summary.SubscribersCount = int64(len(users))
+		summary.AvgPrice = float64(total) / float64(len(subscriptions))
+		result[name] = summary
+	}
+	return result, nil
+}
+
+// PriceHistory получает историю цен подписок из области видимости в порядке изменения.
+// Для хранилища с моментом времени возвращаются только изменения, сделанные до этого момента
+func (r *MemorySubscriptionRepository) PriceHistory(subscriptionIDs []uint) (map[uint][]models.PriceChange, error) {
+	wanted := uniqueIDs(subscriptionIDs)
+
+	var changes []models.PriceChange
+	err := r.read(func(d *memoryData) error {
+		// Пользователь видит историю только своих подписок
+		var visible map[uint]struct{}
+		if r.scope.UserID != "" {
+			visible = make(map[uint]struct{})
+			for _, s := range r.rows(d) {
+				if !s.DeletedAt.Valid {
+					visible[s.ID] = struct{}{}
+				}
+			}
+		}
+
+		for _, change := range d.priceHistory {
+			if _, ok := wanted[change.SubscriptionID]; !ok || change.TenantID != r.scope.TenantID {
+				continue
+			}
+			if _, ok := visible[change.SubscriptionID]; visible != nil && !ok {
+				continue
+			}
+			if r.asOf != nil && change.ChangedAt.After(*r.asOf) {
+				continue
+			}
+			changes = append(changes, change)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(changes, func(a, b models.PriceChange) int {
+		return cmp.Or(cmp.Compare(a.SubscriptionID, b.SubscriptionID), a.ChangedAt.Compare(b.ChangedAt), cmp.Compare(a.ID, b.ID))
+	})
+	result := make(map[uint][]models.PriceChange, len(subscriptionIDs))
+	for _, change := range changes {
+		result[change.SubscriptionID] = append(result[change.SubscriptionID], change)
+	}
+	return result, nil
+}
+
+// SubscriptionsByUsers получает не больше limit первых подписок каждого пользователя
+func (r *MemorySubscriptionRepository) SubscriptionsByUsers(userIDs []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	subscriptions, err := r.find(&models.SubscriptionFilter{UserIDs: userIDs}, orderWithTieBreaker(sort), nil)
+	if err != nil {
+		return nil, err
+	}
+	return groupSubscriptions(subscriptions, limit, func(s *models.Subscription) string { return s.UserID }), nil
+}
+
+// SubscriptionsByServices получает не больше limit первых подписок на каждый сервис
+func (r *MemorySubscriptionRepository) SubscriptionsByServices(serviceNames []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	subscriptions, err := r.find(&models.SubscriptionFilter{ServiceNames: serviceNames}, orderWithTieBreaker(sort), nil)
+	if err != nil {
+		return nil, err
+	}
+	return groupSubscriptions(subscriptions, limit, func(s *models.Subscription) string { return s.ServiceName }), nil
+}
+
+// groupSubscriptions группирует упорядоченные подписки по ключу, оставляя не больше limit подписок в группе
+func groupSubscriptions(subscriptions []models.Subscription, limit int, key func(*models.Subscription) string) map[string][]models.Subscription {
+	result := make(map[string][]models.Subscription)
+	for _, subscription := range subscriptions {
+		k := key(&subscription)
+		if len(result[k]) < limit {
+			result[k] = append(result[k], subscription)
+		}
+	}
+	return result
+}
+
+// TotalCostByUsers вычисляет общую стоимость подписок, подходящих под фильтр, по каждому пользователю
+func (r *MemorySubscriptionRepository) TotalCostByUsers(userIDs []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error) {
+	return r.totalCostBy(filter, userIDs, func(s *models.Subscription) string { return s.UserID }, startDate, endDate)
+}
+
+// TotalCostByServices вычисляет общую стоимость подписок, подходящих под фильтр, по каждому сервису
+func (r *MemorySubscriptionRepository) TotalCostByServices(serviceNames []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error) {
+	return r.totalCostBy(filter, serviceNames, func(s *models.Subscription) string { return s.ServiceName }, startDate, endDate)
+}
+
+// ClaimEndingSoon отмечает подписки организации хранилища с датой окончания в диапазоне [from, to]
+// как уведомленные и возвращает те из них, о которых еще не напоминали
+func (r *MemorySubscriptionRepository) ClaimEndingSoon(from, to time.Time) ([]models.Subscription, error) {
+	var claimed []models.Subscription
+	err := r.write(func(d *memoryData) error {
+		for _, s := range d.subscriptions {
+			if s.TenantID != r.scope.TenantID || s.DeletedAt.Valid || s.EndDate == nil ||
+				s.EndDate.Before(from) || s.EndDate.After(to) {
+				continue
+			}
+			reminder := memoryReminder{subscriptionID: s.ID, endDate: s.EndDate.UnixMicro()}
+			if _, ok := d.reminders[reminder]; ok {
+				continue
+			}
+			d.reminders[reminder] = struct{}{}
+			claimed = append(claimed, *copySubscription(s))
+		}
+		return nil
+	})
+	slices.SortFunc(claimed, func(a, b models.Subscription) int {
+		return cmp.Or(a.EndDate.Compare(*b.EndDate), cmp.Compare(a.ID, b.ID))
+	})
+	return claimed, err
+}
+
+// UserData получает данные пользователя userID в организации хранилища, включая подписки в корзине.
+// Записи аудита и события читаются из базы данных, а без нее - записи аудита из памяти
+func (r *MemorySubscriptionRepository) UserData(userID string) (*models.UserData, error) {
+	var data models.UserData
+	tenantID := r.scope.TenantID
+
+	err := r.read(func(d *memoryData) error {
+		ids := make(map[uint]bool)
+		for _, s := range d.subscriptions {
+			if s.TenantID == tenantID && s.UserID == userID {
+				data.Subscriptions = append(data.Subscriptions, *copySubscription(s))
+				ids[s.ID] = true
+			}
+		}
+		for _, change := range d.priceHistory {
+			if change.TenantID == tenantID && ids[change.SubscriptionID] {
+				data.PriceHistory = append(data.PriceHistory, change)
+			}
+		}
+		for _, version := range d.versions {
+			if version.TenantID == tenantID && version.UserID == userID {
+				data.Versions = append(data.Versions, version)
+			}
+		}
+
+		if r.db != nil {
+			return collectUserRecords(r.db, tenantID, userID, &data)
+		}
+		for _, entry := range d.audit {
+			if entry.TenantID == tenantID && (entry.UserID == userID || entry.Actor == userID) {
+				data.AuditEntries = append(data.AuditEntries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Порядок совпадает с SubscriptionRepository; история цен и версии записаны в порядке изменений
+	slices.SortFunc(data.Subscriptions, func(a, b models.Subscription) int { return cmp.Compare(a.ID, b.ID) })
+	slices.SortStableFunc(data.PriceHistory, func(a, b models.PriceChange) int { return cmp.Compare(a.SubscriptionID, b.SubscriptionID) })
+	slices.SortStableFunc(data.Versions, func(a, b models.SubscriptionVersion) int {
+		return cmp.Or(cmp.Compare(a.SubscriptionID, b.SubscriptionID), a.ValidFrom.Compare(b.ValidFrom))
+	})
+	return &data, nil
+}
+
+// AnonymizeUser обезличивает данные пользователя userID в организации хранилища: подписки и версии в памяти
+// и записи в базе данных изменяются вместе или не изменяются вовсе. Без базы данных обезличиваются
+// записи аудита и outbox в памяти
+func (r *MemorySubscriptionRepository) AnonymizeUser(userID, pseudonym string) (*models.UserErasure, error) {
+	erasure := &models.UserErasure{Pseudonym: pseudonym}
+	tenantID := r.scope.TenantID
+
+	err := r.write(func(d *memoryData) error {
+		for id, s := range d.subscriptions {
+			if s.TenantID == tenantID && s.UserID == userID {
+				s.Anonymize(userID, pseudonym)
+				d.subscriptions[id] = s
+				erasure.Subscriptions++
+			}
+		}
+		for i, version := range d.versions {
+			if version.TenantID == tenantID && version.UserID == userID {
+				version.UserID, version.Notes, version.Tags = pseudonym, "", nil
+				d.versions[i] = version
+				erasure.Versions++
+			}
+		}
+
+		if r.db != nil {
+			return r.db.Transaction(func(tx *gorm.DB) error {
+				return anonymizeUserRecords(tx, tenantID, userID, erasure)
+			})
+		}
+		return d.anonymizeUserRecords(tenantID, userID, erasure)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return erasure, nil
+}
+
+// read выполняет fn над текущим состоянием хранилища
+func (r *MemorySubscriptionRepository) read(fn func(d *memoryData) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return fn(r.store.data)
+}
+
+// write выполняет fn над копией состояния и заменяет состояние копией, если fn завершилась без ошибки.
+// Внутри транзакции копируется состояние транзакции, поэтому ошибка операции не затрагивает ее прежние изменения
+func (r *MemorySubscriptionRepository) write(fn func(d *memoryData) error) error {
+	return r.read(func(d *memoryData) error {
+		draft := d.clone()
+		if err := fn(draft); err != nil {
+			return err
+		}
+		*d = *draft
+		return nil
+	})
+}
+
+// rows возвращает подписки из области видимости, включая корзину, в порядке ID.
+// Для хранилища с моментом времени подписки восстанавливаются из версий, действовавших в этот момент
+func (r *MemorySubscriptionRepository) rows(d *memoryData) []models.Subscription {
+	var rows []models.Subscription
+	if r.asOf == nil {
+		for _, s := range d.subscriptions {
+			if r.scope.contains(&s) {
+				rows = append(rows, *copySubscription(s))
+			}
+		}
+	} else {
+		at := *r.asOf
+		for _, v := range d.versions {
+			if v.ValidFrom.After(at) || (v.ValidTo != nil && !v.ValidTo.After(at)) {
+				continue
+			}
+			if s := versionSubscription(v); r.scope.contains(&s) {
+				rows = append(rows, s)
+			}
+		}
+	}
+	slices.SortFunc(rows, func(a, b models.Subscription) int { return cmp.Compare(a.ID, b.ID) })
+	return rows
+}
+
+// find возвращает подписки из области видимости, подходящие под фильтр, в порядке order.
+// Если after задан, остаются только подписки после курсора с этими значениями
+func (r *MemorySubscriptionRepository) find(filter *models.SubscriptionFilter, order []models.SortField, after []any) ([]models.Subscription, error) {
+	var found []models.Subscription
+	err := r.read(func(d *memoryData) error {
+		for _, s := range r.rows(d) {
+			if matchesFilter(&s, filter) {
+				found = append(found, s)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(order) == 0 {
+		return found, err
+	}
+
+	// Значения полей сортировки вычисляются так же, как для курсора
+	keys := make(map[uint][]any, len(found))
+	for i := range found {
+		keys[found[i].ID], err = CursorAfter(&found[i], order).args(order)
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(found, func(a, b models.Subscription) int { return compareSortKeys(keys[a.ID], keys[b.ID], order) })
+
+	if after != nil {
+		found = slices.DeleteFunc(found, func(s models.Subscription) bool { return compareSortKeys(keys[s.ID], after, order) <= 0 })
+	}
+	return found, nil
+}
+
+// totalCostBy вычисляет общую стоимость подписок, подходящих под фильтр, с группировкой по ключу key
+func (r *MemorySubscriptionRepository) totalCostBy(filter *models.SubscriptionFilter, values []string, key func(*models.Subscription) string, startDate, endDate any) (map[string]int64, error) {
+	subscriptions, err := r.find(filter, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64)
+	for _, s := range subscriptions {
+		if k := key(&s); slices.Contains(values, k) && startsWithin(&s, startDate, endDate) {
+			result[k] += int64(s.Price)
+		}
+	}
+	return result, nil
+}
+
+// clone копирует состояние. Подписки и версии не изменяются на месте, а заменяются, поэтому их теги
+// можно не копировать
+func (d *memoryData) clone() *memoryData {
+	c := *d
+	c.subscriptions = maps.Clone(d.subscriptions)
+	c.priceHistory = slices.Clone(d.priceHistory)
+	c.versions = slices.Clone(d.versions)
+	c.reminders = maps.Clone(d.reminders)
+	c.outbox = slices.Clone(d.outbox)
+	c.audit = slices.Clone(d.audit)
+	return &c
+}
+
+// create сохраняет новую подписку, ее начальную цену и первую версию
+func (d *memoryData) create(subscription *models.Subscription, now time.Time) {
+	d.lastID++
+	subscription.ID = d.lastID
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = now
+	}
+	if subscription.UpdatedAt.IsZero() {
+		subscription.UpdatedAt = now
+	}
+
+	stored := *copySubscription(*subscription)
+	d.subscriptions[stored.ID] = stored
+	d.recordPrice(stored.TenantID, stored.ID, nil, stored.Price, stored.CreatedAt)
+	d.recordVersions(now, stored)
+}
+
+// update обновляет непустые поля подписки из области видимости scope, кроме организации,
+// как gorm при обновлении структурой, и записывает изменение цены в историю
+func (d *memoryData) update(scope Scope, id uint, subscription *models.Subscription, now time.Time) (*SubscriptionChange, error) {
+	current, ok := d.subscriptions[id]
+	if !ok || !scope.contains(&current) || current.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	updated := *copySubscription(current)
+	if subscription.ServiceName != "" {
+		updated.ServiceName = subscription.ServiceName
+	}
+	if subscription.Price != 0 {
+		updated.Price = subscription.Price
+	}
+	if subscription.UserID != "" {
+		updated.UserID = subscription.UserID
+	}
+	if !subscription.StartDate.IsZero() {
+		updated.StartDate = subscription.StartDate
+	}
+	if subscription.EndDate != nil {
+		endDate := *subscription.EndDate
+		updated.EndDate = &endDate
+	}
+	if subscription.Notes != "" {
+		updated.Notes = subscription.Notes
+	}
+	if subscription.Tags != nil {
+		updated.Tags = slices.Clone(subscription.Tags)
+	}
+	if !subscription.CreatedAt.IsZero() {
+		updated.CreatedAt = subscription.CreatedAt
+	}
+	updated.UpdatedAt = now
+
+	d.subscriptions[id] = updated
+	d.recordVersions(now, updated)
+
+	change := &SubscriptionChange{Before: copySubscription(current), After: copySubscription(updated)}
+	if change.PriceChanged() {
+		d.recordPrice(current.TenantID, id, &current.Price, updated.Price, updated.UpdatedAt)
+	}
+	return change, nil
+}
+
+// delete перемещает в корзину подписки из области видимости scope, для которых match возвращает true,
+// и возвращает их в порядке ID
+func (d *memoryData) delete(scope Scope, now time.Time, match func(*models.Subscription) bool) []models.Subscription {
+	var deleted []models.Subscription
+	for _, s := range d.subscriptions {
+		if scope.contains(&s) && !s.DeletedAt.Valid && match(&s) {
+			s.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			d.subscriptions[s.ID] = s
+			deleted = append(deleted, s)
+		}
+	}
+	slices.SortFunc(deleted, func(a, b models.Subscription) int { return cmp.Compare(a.ID, b.ID) })
+
+	d.recordVersions(now, deleted...)
+	for i := range deleted {
+		deleted[i] = *copySubscription(deleted[i])
+	}
+	return deleted
+}
+
+// recordPrice записывает цену подписки в историю
+func (d *memoryData) recordPrice(tenantID string, subscriptionID uint, oldPrice *int, price int, changedAt time.Time) {
+	d.lastChangeID++
+	var old *int
+	if oldPrice != nil {
+		value := *oldPrice
+		old = &value
+	}
+	d.priceHistory = append(d.priceHistory, models.PriceChange{
+		ID:             d.lastChangeID,
+		TenantID:       tenantID,
+		SubscriptionID: subscriptionID,
+		OldPrice:       old,
+		Price:          price,
+		ChangedAt:      changedAt,
+	})
+}
+
+// recordVersions закрывает текущие версии подписок и сохраняет их новое состояние
+func (d *memoryData) recordVersions(now time.Time, subscriptions ...models.Subscription) {
+	ids := make(map[uint]struct{}, len(subscriptions))
+	for _, s := range subscriptions {
+		ids[s.ID] = struct{}{}
+	}
+	for i, v := range d.versions {
+		if _, ok := ids[v.SubscriptionID]; ok && v.ValidTo == nil {
+			d.versions[i].ValidTo = &now
+		}
+	}
+
+	for _, s := range subscriptions {
+		d.lastVersionID++
+		version := models.NewSubscriptionVersion(&s, now)
+		version.VersionID = d.lastVersionID
+		d.versions = append(d.versions, version)
+	}
+}
+
+// anonymizeUserRecords обезличивает записи аудита и события outbox пользователя, хранящиеся в памяти
+func (d *memoryData) anonymizeUserRecords(tenantID, userID string, erasure *models.UserErasure) error {
+	for i, entry := range d.audit {
+		if entry.TenantID != tenantID || (entry.UserID != userID && entry.Actor != userID) {
+			continue
+		}
+		changes, err := models.AnonymizeAuditChanges(entry.Changes, userID, erasure.Pseudonym)
+		if err != nil {
+			return err
+		}
+		entry.Changes = changes
+		if entry.UserID == userID {
+			entry.UserID = erasure.Pseudonym
+		}
+		if entry.Actor == userID {
+			entry.Actor = erasure.Pseudonym
+		}
+		d.audit[i] = entry
+		erasure.AuditEntries++
+	}
+
+	for i, message := range d.outbox {
+		if message.TenantID != tenantID {
+			continue
+		}
+		var event models.SubscriptionEvent
+		if err := json.Unmarshal(message.Payload, &event); err != nil {
+			return err
+		}
+		if (event.Subscription == nil || event.Subscription.UserID != userID) && (event.Previous == nil || event.Previous.UserID != userID) {
+			continue
+		}
+		payload, err := models.AnonymizeEvent(message.Payload, userID, erasure.Pseudonym)
+		if err != nil {
+			return err
+		}
+		message.Payload = payload
+		d.outbox[i] = message
+		erasure.OutboxMessages++
+	}
+	return nil
+}
+
+// contains сообщает, что подписка входит в область видимости
+func (s Scope) contains(subscription *models.Subscription) bool {
+	return subscription.TenantID == s.TenantID && (s.UserID == "" || subscription.UserID == s.UserID)
+}
+
+// memoryOutbox outbox транзакции хранилища в памяти без базы данных
+type memoryOutbox struct {
+	d *memoryData
+}
+
+// Add записывает сообщения в outbox транзакции
+func (o memoryOutbox) Add(messages ...models.OutboxMessage) error {
+	for _, message := range messages {
+		o.d.lastOutboxID++
+		message.ID = o.d.lastOutboxID
+		o.d.outbox = append(o.d.outbox, message)
+	}
+	return nil
+}
+
+// memoryAudit журнал аудита транзакции хранилища в памяти без базы данных
+type memoryAudit struct {
+	d *memoryData
+}
+
+// Add записывает записи аудита в журнал транзакции
+func (a memoryAudit) Add(entries ...models.AuditEntry) error {
+	for _, entry := range entries {
+		a.d.lastAuditID++
+		entry.ID = a.d.lastAuditID
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = memoryNow()
+		}
+		a.d.audit = append(a.d.audit, entry)
+	}
+	return nil
+}
+
+// matchesFilter сообщает, что подписка подходит под фильтр. Условия совпадают с applyFilter
+func matchesFilter(s *models.Subscription, filter *models.SubscriptionFilter) bool {
+	if filter == nil {
+		return !s.DeletedAt.Valid
+	}
+
+	switch {
+	case s.DeletedAt.Valid && !filter.IncludeDeleted,
+		len(filter.UserIDs) > 0 && !slices.Contains(filter.UserIDs, s.UserID),
+		len(filter.ServiceNames) > 0 && !slices.Contains(filter.ServiceNames, s.ServiceName),
+		filter.ServiceNameContains != "" &&
+			!strings.Contains(strings.ToLower(s.ServiceName), strings.ToLower(filter.ServiceNameContains)),
+		filter.PriceMin != nil && s.Price < *filter.PriceMin,
+		filter.PriceMax != nil && s.Price > *filter.PriceMax,
+		filter.StartFrom != nil && s.StartDate.Before(*filter.StartFrom),
+		filter.StartTo != nil && s.StartDate.After(*filter.StartTo),
+		filter.EndFrom != nil && (s.EndDate == nil || s.EndDate.Before(*filter.EndFrom)),
+		filter.EndTo != nil && (s.EndDate == nil || s.EndDate.After(*filter.EndTo)),
+		filter.OpenEnded != nil && *filter.OpenEnded != (s.EndDate == nil):
+		return false
+	}
+
+	// Подписка действует в месяце: началась не позже его конца и не закончилась до его начала
+	if filter.ActiveAt != nil {
+		monthStart := utils.GetFirstDayOfMonth(*filter.ActiveAt)
+		monthEnd := utils.GetLastDayOfMonth(*filter.ActiveAt)
+		if s.StartDate.After(monthEnd) || (s.EndDate != nil && s.EndDate.Before(monthStart)) {
+			return false
+		}
+	}
+	return true
+}
+
+// startsWithin сообщает, что дата начала подписки входит в опциональный диапазон startDate-endDate
+func startsWithin(s *models.Subscription, startDate, endDate any) bool {
+	if from, ok := startDate.(time.Time); ok && s.StartDate.Before(from) {
+		return false
+	}
+	if to, ok := endDate.(time.Time); ok && s.StartDate.After(to) {
+		return false
+	}
+	return true
+}
+
+// compareSortKeys сравнивает значения полей сортировки двух строк с учетом направления каждого поля
+func compareSortKeys(a, b []any, order []models.SortField) int {
+	for i, field := range order {
+		c := compareSortValues(a[i], b[i])
+		if field.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareSortValues сравнивает значения поля сортировки из курсора.
+// Строка "infinity" вместо даты окончания больше любой даты
+func compareSortValues(a, b any) int {
+	switch a := a.(type) {
+	case uint64:
+		return cmp.Compare(a, b.(uint64))
+	case int:
+		return cmp.Compare(a, b.(int))
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b)
+		}
+		return -1
+	case string:
+		if _, ok := b.(time.Time); ok {
+			return 1
+		}
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+// versionSubscription восстанавливает подписку из ее версии
+func versionSubscription(v models.SubscriptionVersion) models.Subscription {
+	s := models.Subscription{
+		ID:          v.SubscriptionID,
+		TenantID:    v.TenantID,
+		ServiceName: v.ServiceName,
+		Price:       v.Price,
+		UserID:      v.UserID,
+		StartDate:   v.StartDate,
+		EndDate:     v.EndDate,
+		Notes:       v.Notes,
+		Tags:        v.Tags,
+		CreatedAt:   v.CreatedAt,
+		UpdatedAt:   v.UpdatedAt,
+	}
+	if v.DeletedAt != nil {
+		s.DeletedAt = gorm.DeletedAt{Time: *v.DeletedAt, Valid: true}
+	}
+	return *copySubscription(s)
+}
+
+// copySubscription копирует подписку вместе с датой окончания и тегами, чтобы изменения копии
+// не затрагивали хранилище
+func copySubscription(s models.Subscription) *models.Subscription {
+	if s.EndDate != nil {
+		endDate := *s.EndDate
+		s.EndDate = &endDate
+	}
+	s.Tags = slices.Clone(s.Tags)
+	return &s
+}
+
+// page возвращает limit элементов начиная с offset; отрицательный limit означает все элементы
+func page[T any](items []T, offset, limit int) []T {
+	if offset > len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// memoryNow возвращает текущее время с точностью PostgreSQL до микросекунд
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
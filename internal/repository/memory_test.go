@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/repository/conformance"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) repository.Subscriptions {
+		return repository.NewMemorySubscriptionRepository(nil)
+	})
+}
+
+// Так хранилище в памяти создается сервером: outbox и журнал аудита пишутся в базу данных
+func TestMemoryWithDatabaseConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) repository.Subscriptions {
+		return repository.NewMemorySubscriptionRepository(openDB(t, database.DriverMemory))
+	})
+}
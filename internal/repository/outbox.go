@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// outboxLockKey имя блокировки, под которой публикуется outbox; один публикатор сохраняет порядок событий
+const outboxLockKey = "outbox_relay"
+
+// OutboxRepository обрабатывает операции с базой данных для outbox событий
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository создает новый репозиторий outbox
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Add записывает сообщения в outbox. Внутри SubscriptionRepository.Transaction
+// сообщения фиксируются вместе с изменением подписок
+func (r *OutboxRepository) Add(messages ...models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(messages, bulkBatchSize).Error
+}
+
+// Relay передает fn до limit неопубликованных сообщений в порядке записи и отмечает их
+// опубликованными, если fn завершилась без ошибки. Публикация выполняется под блокировкой:
+// если ее держит другой экземпляр, возвращается 0. При ошибке fn сообщения остаются
+// неопубликованными и будут переданы повторно
+func (r *OutboxRepository) Relay(ctx context.Context, limit int, fn func([]models.OutboxMessage) error) (int, error) {
+	// Сервис с SQLite работает в одном экземпляре и публикует outbox по очереди, поэтому блокировка не нужна.
+	// Транзакция на время публикации заняла бы единственное соединение, которое нужно получателям событий
+	if !isPostgres(r.db) {
+		return relayMessages(r.db.WithContext(ctx), limit, fn)
+	}
+
+	var relayed int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", outboxLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var err error
+		relayed, err = relayMessages(tx, limit, fn)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return relayed, nil
+}
+
+// relayMessages передает fn до limit неопубликованных сообщений и отмечает их опубликованными
+func relayMessages(db *gorm.DB, limit int, fn func([]models.OutboxMessage) error) (int, error) {
+	var messages []models.OutboxMessage
+	err := db.Where("published_at IS NULL").Order("id").Limit(limit).Find(&messages).Error
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+	if err := fn(messages); err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint64, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+	err = db.Model(&models.OutboxMessage{}).Where("id IN ?", ids).Update("published_at", time.Now()).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(messages), nil
+}
+
+// DeletePublishedBefore удаляет сообщения, опубликованные раньше before, и возвращает их количество
+func (r *OutboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("published_at < ?", before).Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"os"
+	"testing"
+
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/repository/conformance"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openPostgres подключается к тестовой базе TEST_POSTGRES_DSN, например
+// host=localhost user=postgres password=admin dbname=subscriptions_test sslmode=disable,
+// и применяет миграции. Без TEST_POSTGRES_DSN тест пропускается
+func openPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("подключение к PostgreSQL: %v", err)
+	}
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	return db
+}
+
+// TestPostgresConformance выполняется, только если TEST_POSTGRES_DSN задает тестовую базу данных.
+// Проверки очищают таблицы подписок этой базы
+func TestPostgresConformance(t *testing.T) {
+	db := openPostgres(t)
+
+	conformance.Run(t, func(t *testing.T) repository.Subscriptions {
+		err := db.Exec("TRUNCATE subscriptions, subscription_price_history, subscription_versions, " +
+			"subscription_reminders, outbox, audit_entries RESTART IDENTITY CASCADE").Error
+		if err != nil {
+			t.Fatalf("очистка таблиц: %v", err)
+		}
+		return repository.NewSubscriptionRepository(db)
+	})
+}
+
+// В PostgreSQL поиск допускает опечатки через pg_trgm
+func TestPostgresSearchToleratesTypos(t *testing.T) {
+	db := openPostgres(t)
+	if err := db.Exec("TRUNCATE subscriptions RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatalf("очистка таблиц: %v", err)
+	}
+	repo := seedSearch(t, repository.NewSubscriptionRepository(db))
+
+	for _, query := range []string{"netflx", "NETFLIX", "netflix"} {
+		hits, err := repo.Search(query, nil, 10)
+		if err != nil || len(hits) != 1 || hits[0].ServiceName != "Netflix" {
+			t.Fatalf("Search(%q) = %+v, %v; ожидался Netflix", query, hits, err)
+		}
+	}
+}
+
+// Триггер журнала аудита запрещает изменение и удаление записей; изменение разрешено только
+// в транзакции, включившей AuditErasureSetting
+func TestPostgresAuditAppendOnly(t *testing.T) {
+	db := openPostgres(t)
+	// TRUNCATE не вызывает построчный триггер
+	truncate := func() {
+		if err := db.Exec("TRUNCATE audit_entries RESTART IDENTITY").Error; err != nil {
+			t.Fatalf("очистка журнала: %v", err)
+		}
+	}
+	truncate()
+	t.Cleanup(truncate)
+
+	entry := models.AuditEntry{
+		TenantID:       models.DefaultTenantID,
+		SubscriptionID: 1,
+		UserID:         "550e8400-e29b-41d4-a716-446655440000",
+		Action:         models.AuditActionCreate,
+		Changes:        []byte(`{"price":{"before":null,"after":990}}`),
+	}
+	if err := repository.NewAuditRepository(db).Add(entry); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if err := db.Exec("UPDATE audit_entries SET action = ?", models.AuditActionDelete).Error; err == nil {
+		t.Fatal("изменение записи журнала не запрещено")
+	}
+	if err := db.Exec("DELETE FROM audit_entries").Error; err == nil {
+		t.Fatal("удаление записи журнала не запрещено")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config(?, 'on', true)", repository.AuditErasureSetting).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE audit_entries SET actor = ?", "erased").Error
+	})
+	if err != nil {
+		t.Fatalf("изменение при включенном %s: %v", repository.AuditErasureSetting, err)
+	}
+
+	// Настройка действует только до конца транзакции и не разрешает удаление
+	if err := db.Exec("UPDATE audit_entries SET actor = ''").Error; err == nil {
+		t.Fatal("изменение разрешено после окончания транзакции")
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config(?, 'on', true)", repository.AuditErasureSetting).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM audit_entries").Error
+	})
+	if err == nil {
+		t.Fatal("удаление записи журнала разрешено")
+	}
+
+	var actors []string
+	if err := db.Model(&models.AuditEntry{}).Pluck("actor", &actors).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(actors) != 1 || actors[0] != "erased" {
+		t.Fatalf("участники записей %v, ожидалась одна запись erased", actors)
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// refilledTokens количество токенов в корзине к текущему моменту с учетом пополнения, но не больше емкости
+const refilledTokens = `LEAST(CAST(@burst AS double precision), b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * CAST(@rate AS double precision))`
+
+// takeTokenQuery списывает токен из корзины одним запросом, поэтому одновременные запросы
+// разных экземпляров не могут потратить один и тот же токен. Время берется из базы данных,
+// чтобы расхождение часов экземпляров не влияло на пополнение
+var takeTokenQuery = fmt.Sprintf(`
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@burst AS double precision) - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END,
+	allowed = %[1]s >= 1,
+	updated_at = now()
+RETURNING tokens, allowed`, refilledTokens)
+
+// RateLimitRepository хранит корзины токенов ограничения частоты запросов
+type RateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitRepository создает новый репозиторий корзин токенов
+func NewRateLimitRepository(db *gorm.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// Take пополняет корзину key со скоростью rate токенов в секунду до емкости burst и списывает один токен,
+// если он есть. Возвращает оставшиеся токены и признак того, что токен списан
+func (r *RateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	var bucket models.RateLimitBucket
+	err := r.db.WithContext(ctx).Raw(takeTokenQuery, map[string]any{
+		"key":   key,
+		"rate":  rate,
+		"burst": burst,
+	}).Scan(&bucket).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return bucket.Tokens, bucket.Allowed, nil
+}
+
+// DeleteIdle удаляет корзины, которые не использовались с момента before
+func (r *RateLimitRepository) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("updated_at < ?", before).Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
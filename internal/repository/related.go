@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// UserSummaries получает сводки по подпискам пользователей одним запросом
+func (r *SubscriptionRepository) UserSummaries(userIDs []string) (map[string]models.UserSummary, error) {
+	var summaries []models.UserSummary
+
+	// Подписка активна, если началась и еще не закончилась
+	now := time.Now()
+	err := r.subscriptions().
+		Select(`user_id AS id,
+			COUNT(*) AS subscriptions_count,
+			COUNT(*) FILTER (WHERE start_date <= ? AND (end_date IS NULL OR end_date >= ?)) AS active_subscriptions,
+			COALESCE(SUM(price) FILTER (WHERE start_date <= ? AND (end_date IS NULL OR end_date >= ?)), 0) AS monthly_cost`,
+			now, now, now, now).
+		Where("user_id IN ?", userIDs).
+		Group("user_id").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.UserSummary, len(summaries))
+	for _, summary := range summaries {
+		result[summary.ID] = summary
+	}
+	return result, nil
+}
+
+// ServiceSummaries получает сводки по подпискам на сервисы одним запросом
+func (r *SubscriptionRepository) ServiceSummaries(serviceNames []string) (map[string]models.ServiceSummary, error) {
+	var summaries []models.ServiceSummary
+
+	err := r.subscriptions().
+		Select(`service_name AS name,
+			COUNT(DISTINCT user_id) AS subscribers_count,
+			MIN(price) AS min_price,
+			MAX(price) AS max_price,
+			CAST(AVG(price) AS DOUBLE PRECISION) AS avg_price`).
+		Where("service_name IN ?", serviceNames).
+		Group("service_name").
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.ServiceSummary, len(summaries))
+	for _, summary := range summaries {
+		result[summary.Name] = summary
+	}
+	return result, nil
+}
+
+// PriceHistory получает историю цен подписок из области видимости одним запросом, в порядке изменения.
+// Для репозитория с моментом времени возвращаются только изменения, сделанные до этого момента
+func (r *SubscriptionRepository) PriceHistory(subscriptionIDs []uint) (map[uint][]models.PriceChange, error) {
+	var changes []models.PriceChange
+
+	query := r.db.Where("tenant_id = ? AND subscription_id IN ?", r.scope.TenantID, subscriptionIDs)
+	if r.scope.UserID != "" {
+		query = query.Where("subscription_id IN (?)", r.subscriptions().Select("id"))
+	}
+	if r.asOf != nil {
+		query = query.Where("changed_at <= ?", *r.asOf)
+	}
+	err := query.Order("subscription_id, changed_at, id").
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint][]models.PriceChange, len(subscriptionIDs))
+	for _, change := range changes {
+		result[change.SubscriptionID] = append(result[change.SubscriptionID], change)
+	}
+	return result, nil
+}
+
+// SubscriptionsByUsers получает не больше limit первых подписок каждого пользователя одним запросом
+func (r *SubscriptionRepository) SubscriptionsByUsers(userIDs []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	subscriptions, err := r.subscriptionsIn("user_id", userIDs, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]models.Subscription, len(userIDs))
+	for _, subscription := range subscriptions {
+		result[subscription.UserID] = append(result[subscription.UserID], subscription)
+	}
+	return result, nil
+}
+
+// SubscriptionsByServices получает не больше limit первых подписок на каждый сервис одним запросом
+func (r *SubscriptionRepository) SubscriptionsByServices(serviceNames []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	subscriptions, err := r.subscriptionsIn("service_name", serviceNames, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]models.Subscription, len(serviceNames))
+	for _, subscription := range subscriptions {
+		result[subscription.ServiceName] = append(result[subscription.ServiceName], subscription)
+	}
+	return result, nil
+}
+
+// TotalCostByUsers вычисляет общую стоимость подписок, подходящих под фильтр, по каждому пользователю одним запросом
+func (r *SubscriptionRepository) TotalCostByUsers(userIDs []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error) {
+	return r.totalCostBy("user_id", userIDs, filter, startDate, endDate)
+}
+
+// TotalCostByServices вычисляет общую стоимость подписок, подходящих под фильтр, по каждому сервису одним запросом
+func (r *SubscriptionRepository) TotalCostByServices(serviceNames []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error) {
+	return r.totalCostBy("service_name", serviceNames, filter, startDate, endDate)
+}
+
+// subscriptionsIn получает не больше limit первых подписок для каждого значения колонки из values
+// в порядке сортировки. Подписки нумеруются оконной функцией внутри групп по значению колонки
+func (r *SubscriptionRepository) subscriptionsIn(column string, values []string, sort []models.SortField, limit int) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+
+	order := orderWithTieBreaker(sort)
+	ranked := r.subscriptions().
+		Select("subscriptions.*, ROW_NUMBER() OVER (PARTITION BY "+column+" ORDER BY "+orderClause(order)+") AS group_row").
+		Where(column+" IN ?", values)
+	query := r.db.Table("(?) AS subscriptions", ranked).Where("group_row <= ?", limit)
+	err := applyOrder(query, order).Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// totalCostBy вычисляет общую стоимость подписок с группировкой по колонке.
+// Условия по датам совпадают с CalculateTotalCost
+func (r *SubscriptionRepository) totalCostBy(column string, values []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error) {
+	var rows []struct {
+		Key       string
+		TotalCost int64
+	}
+
+	// Построить запрос с фильтрами
+	query := applyFilter(r.subscriptions(), filter).
+		Select(column+" AS key, COALESCE(SUM(price), 0) AS total_cost").
+		Where(column+" IN ?", values).
+		Group(column)
+
+	// Применить фильтры по диапазону дат, если они предоставлены
+	if startDate != nil {
+		query = query.Where("start_date >= ?", startDate)
+	}
+	if endDate != nil {
+		query = query.Where("start_date <= ?", endDate)
+	}
+
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.Key] = row.TotalCost
+	}
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ClaimEndingSoon отмечает подписки организации репозитория с датой окончания в диапазоне [from, to]
+// как уведомленные и возвращает те из них, о которых еще не напоминали. Напоминание отправляется один раз
+// для каждой даты окончания: если дату окончания перенесут, подписка попадет в выборку снова
+func (r *SubscriptionRepository) ClaimEndingSoon(from, to time.Time) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Raw(`
+			INSERT INTO subscription_reminders (subscription_id, end_date, tenant_id, sent_at)
+			SELECT id, end_date, tenant_id, ? FROM subscriptions
+			WHERE tenant_id = ? AND deleted_at IS NULL AND end_date BETWEEN ? AND ?
+			ON CONFLICT DO NOTHING
+			RETURNING subscription_id`,
+			time.Now(), r.scope.TenantID, from, to,
+		).Scan(&ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Order("end_date, id").Find(&subscriptions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Scope ограничивает подписки, видимые репозиторию
+type Scope struct {
+	// TenantID оставляет только подписки организации. Условие применяется всегда:
+	// репозиторий без организации не видит ни одной подписки
+	TenantID string
+
+	// UserID оставляет только подписки пользователя; пустое значение - все подписки организации
+	UserID string
+}
+
+// apply добавляет к запросу условия области видимости
+func (s Scope) apply(query *gorm.DB) *gorm.DB {
+	query = query.Where("tenant_id = ?", s.TenantID)
+	if s.UserID == "" {
+		return query
+	}
+	return query.Where("user_id = ?", s.UserID)
+}
+
+// WithScope возвращает репозиторий, запросы которого видят только подписки из scope,
+// а новые подписки создаются в организации scope.
+// Подписки вне области видимости ведут себя как отсутствующие
+func (r *SubscriptionRepository) WithScope(scope Scope) Subscriptions {
+	return &SubscriptionRepository{db: r.db, scope: scope, asOf: r.asOf}
+}
+
+// subscriptions начинает запрос к подпискам из области видимости репозитория.
+// Для репозитория с моментом времени подписки читаются из версий, действовавших в этот момент
+func (r *SubscriptionRepository) subscriptions() *gorm.DB {
+	query := r.db.Model(&models.Subscription{})
+	if r.asOf != nil {
+		query = query.Table("(?) AS subscriptions", versionsAt(r.db, *r.asOf))
+	}
+	return r.scope.apply(query)
+}
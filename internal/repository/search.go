@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"effective-mobile-subscription/internal/models"
+)
+
+// searchDocument документ полнотекстового поиска подписки.
+// Выражение совпадает с индексом idx_subscriptions_search_document из миграций
+const searchDocument = `to_tsvector('simple', service_name || ' ' || COALESCE(notes, '') || ' ' || COALESCE(tags::text, ''))`
+
+// searchRank оценка совпадения: лучшая из триграммной схожести по названию, заметкам и тегам
+// и ранга полнотекстового поиска. GREATEST пропускает NULL для пустых заметок и тегов
+const searchRank = `GREATEST(
+	similarity(service_name, ?),
+	word_similarity(?, service_name),
+	word_similarity(?, notes),
+	word_similarity(?, tags::text),
+	ts_rank(` + searchDocument + `, websearch_to_tsquery('simple', ?))
+)`
+
+// searchCondition условие поиска с допуском опечаток через операторы pg_trgm,
+// которые используют триграммные индексы
+const searchCondition = `(service_name % ? OR ? <% service_name OR ? <% notes OR ? <% tags::text OR ` +
+	searchDocument + ` @@ websearch_to_tsquery('simple', ?))`
+
+// Оценки совпадения подстроки для баз данных без pg_trgm: совпадение в названии сервиса
+// важнее совпадения в заметках или тегах
+const (
+	substringRankServiceName = 1.0
+	substringRankNotesOrTags = 0.5
+)
+
+// substringRank оценка совпадения подстроки без учета регистра
+const substringRank = `CASE WHEN service_name LIKE ? ESCAPE '\' THEN ? ELSE ? END`
+
+// substringCondition условие совпадения подстроки в названии, заметках или тегах
+const substringCondition = `(service_name LIKE ? ESCAPE '\' OR notes LIKE ? ESCAPE '\' OR tags LIKE ? ESCAPE '\')`
+
+// Search ищет подписки по названию сервиса, заметкам и тегам с допуском опечаток.
+// Результаты, подходящие под фильтр, упорядочены по убыванию релевантности.
+// Без PostgreSQL ищется подстрока без учета регистра, опечатки не допускаются
+func (r *SubscriptionRepository) Search(query string, filter *models.SubscriptionFilter, limit int) ([]models.SubscriptionSearchHit, error) {
+	var hits []models.SubscriptionSearchHit
+
+	// Построить запрос с оценкой релевантности и фильтрами
+	db := r.subscriptions()
+	if isPostgres(db) {
+		db = db.Select("subscriptions.*, "+searchRank+" AS rank", query, query, query, query, query).
+			Where(searchCondition, query, query, query, query, query)
+	} else {
+		pattern := "%" + escapeLike(query) + "%"
+		db = db.Select("subscriptions.*, "+substringRank+" AS rank", pattern, substringRankServiceName, substringRankNotesOrTags).
+			Where(substringCondition, pattern, pattern, pattern)
+	}
+	db = applyFilter(db, filter)
+
+	err := db.Order("rank DESC, id DESC").Limit(limit).Find(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,68 @@
+package repository_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+)
+
+// seedSearch создает подписки Netflix и Okko с тегом 100%-кино в организации по умолчанию
+func seedSearch(t *testing.T, repo repository.Subscriptions) repository.Subscriptions {
+	t.Helper()
+	repo = repo.WithScope(repository.Scope{TenantID: models.DefaultTenantID})
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []*models.Subscription{
+		{ServiceName: "Netflix", Price: 990, UserID: "550e8400-e29b-41d4-a716-446655440000", StartDate: start},
+		{ServiceName: "Okko", Price: 399, UserID: "550e8400-e29b-41d4-a716-446655440000", StartDate: start, Tags: []string{"100%-кино"}},
+	} {
+		if err := repo.Create(s); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	return repo
+}
+
+// Без PostgreSQL поиск находит подстроку без учета регистра и не допускает опечаток
+func TestSearchWithoutPostgresMatchesSubstring(t *testing.T) {
+	stores := map[string]func(t *testing.T) repository.Subscriptions{
+		"sqlite": func(t *testing.T) repository.Subscriptions {
+			return repository.NewSubscriptionRepository(openDB(t, database.DriverMemory))
+		},
+		"memory": func(t *testing.T) repository.Subscriptions {
+			return repository.NewMemorySubscriptionRepository(nil)
+		},
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"NETFL", []string{"Netflix"}},
+		{"ix", []string{"Netflix"}},
+		{"netflx", nil},
+		{"100%", []string{"Okko"}},
+		{"%", []string{"Okko"}},
+		{"_", nil},
+	}
+	for name, newRepo := range stores {
+		t.Run(name, func(t *testing.T) {
+			repo := seedSearch(t, newRepo(t))
+			for _, tt := range tests {
+				hits, err := repo.Search(tt.query, nil, 10)
+				if err != nil {
+					t.Fatalf("Search(%q): %v", tt.query, err)
+				}
+				got := make([]string, len(hits))
+				for i, hit := range hits {
+					got[i] = hit.ServiceName
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Fatalf("Search(%q) = %v, ожидалось %v", tt.query, got, tt.want)
+				}
+			}
+		})
+	}
+}
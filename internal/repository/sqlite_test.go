@@ -0,0 +1,37 @@
+package repository_test
+
+import (
+	"testing"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/repository/conformance"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLiteConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) repository.Subscriptions {
+		return repository.NewSubscriptionRepository(openDB(t, database.DriverMemory))
+	})
+}
+
+// openDB открывает новую базу данных SQLite в памяти со схемой по моделям
+func openDB(t *testing.T, driver string) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(&config.Config{DBDriver: driver}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("открытие базы данных: %v", err)
+	}
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Subscriptions хранилище подписок. Реализации: SubscriptionRepository поверх PostgreSQL или SQLite
+// и MemorySubscriptionRepository в памяти процесса. Отсутствующие подписки и подписки вне области
+// видимости возвращают gorm.ErrRecordNotFound во всех реализациях. Общие требования к реализациям
+// проверяются пакетом repository/conformance
+type Subscriptions interface {
+	// WithScope возвращает хранилище, видящее только подписки из scope
+	WithScope(scope Scope) Subscriptions
+	// AsOf возвращает хранилище только для чтения с состоянием подписок на момент at; nil - текущее состояние
+	AsOf(at *time.Time) Subscriptions
+	// Transaction выполняет fn атомарно вместе с записью событий в outbox и записей в журнал аудита
+	Transaction(fn func(repo Subscriptions, outbox OutboxWriter, audit AuditWriter) error) error
+
+	Create(subscription *models.Subscription) error
+	GetByID(id uint) (*models.Subscription, error)
+	Update(id uint, subscription *models.Subscription) (*SubscriptionChange, error)
+	Delete(id uint) (*models.Subscription, error)
+	ListDeleted(offset, limit int) ([]models.Subscription, error)
+	Restore(id uint) (*SubscriptionChange, error)
+	PurgeDeleted(before time.Time) (int64, error)
+
+	List(filter *models.SubscriptionFilter, sort []models.SortField, offset, limit int) ([]models.Subscription, error)
+	ListAfter(filter *models.SubscriptionFilter, sort []models.SortField, cursor *SubscriptionCursor, limit int) ([]models.Subscription, error)
+	Count(filter *models.SubscriptionFilter) (int64, error)
+	CalculateTotalCost(filter *models.SubscriptionFilter, startDate, endDate any) (int64, error)
+	Iterate(ctx context.Context, filter *models.SubscriptionFilter, sort []models.SortField, fn func(*models.Subscription) error) error
+	Search(query string, filter *models.SubscriptionFilter, limit int) ([]models.SubscriptionSearchHit, error)
+
+	BulkCreate(subscriptions []*models.Subscription, bestEffort bool) ([]error, error)
+	BulkUpdate(ids []uint, subscriptions []*models.Subscription, bestEffort bool) ([]*SubscriptionChange, []error, error)
+	BulkDeleteByIDs(ids []uint, allOrNothing bool) ([]models.Subscription, error)
+	BulkDeleteByFilter(filter *models.SubscriptionFilter, limit int) ([]models.Subscription, error)
+
+	UserSummaries(userIDs []string) (map[string]models.UserSummary, error)
+	ServiceSummaries(serviceNames []string) (map[string]models.ServiceSummary, error)
+	PriceHistory(subscriptionIDs []uint) (map[uint][]models.PriceChange, error)
+	// SubscriptionsByUsers и SubscriptionsByServices возвращают не больше limit первых подписок каждого ключа
+	SubscriptionsByUsers(userIDs []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error)
+	SubscriptionsByServices(serviceNames []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error)
+	TotalCostByUsers(userIDs []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error)
+	TotalCostByServices(serviceNames []string, filter *models.SubscriptionFilter, startDate, endDate any) (map[string]int64, error)
+
+	ClaimEndingSoon(from, to time.Time) ([]models.Subscription, error)
+
+	// UserData и AnonymizeUser работают со всеми данными пользователя в организации хранилища,
+	// включая журнал аудита и события, вне зависимости от пользователя в области видимости
+	UserData(userID string) (*models.UserData, error)
+	AnonymizeUser(userID, pseudonym string) (*models.UserErasure, error)
+}
+
+// OutboxWriter записывает события в outbox транзакции хранилища подписок
+type OutboxWriter interface {
+	Add(messages ...models.OutboxMessage) error
+}
+
+// AuditWriter записывает изменения в журнал аудита транзакции хранилища подписок
+type AuditWriter interface {
+	Add(entries ...models.AuditEntry) error
+}
+
+// isPostgres сообщает, что запросы выполняются в PostgreSQL. В SQLite нет триграммного поиска,
+// блокировок строк, рекомендательных блокировок и LISTEN/NOTIFY, поэтому для нее запросы упрощаются:
+// SQLite и так допускает только одну пишущую транзакцию
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+var (
+	_ Subscriptions = (*SubscriptionRepository)(nil)
+	_ Subscriptions = (*MemorySubscriptionRepository)(nil)
+)
@@ -1,14 +1,21 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"effective-mobile-subscription/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SubscriptionRepository обрабатывает операции с базой данных для подписок
 type SubscriptionRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	scope Scope
+	// asOf момент времени, состояние на который видят запросы; nil - текущее состояние
+	asOf *time.Time
 }
 
 // NewSubscriptionRepository создает новый репозиторий подписок
@@ -16,49 +23,124 @@ func NewSubscriptionRepository(db *gorm.DB) *SubscriptionRepository {
 	return &SubscriptionRepository{db: db}
 }
 
-// Create создает новую подписку в базе данных
+// Transaction выполняет fn в одной транзакции. Репозитории, переданные в fn, работают внутри нее,
+// поэтому изменения подписок, записанные в outbox события и записи аудита фиксируются или откатываются вместе
+func (r *SubscriptionRepository) Transaction(fn func(repo Subscriptions, outbox OutboxWriter, audit AuditWriter) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&SubscriptionRepository{db: tx, scope: r.scope}, NewOutboxRepository(tx), NewAuditRepository(tx))
+	})
+}
+
+// Create создает новую подписку в организации репозитория и записывает начальную цену и первую версию в историю
 func (r *SubscriptionRepository) Create(subscription *models.Subscription) error {
-	return r.db.Create(subscription).Error
+	subscription.TenantID = r.scope.TenantID
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(subscription).Error; err != nil {
+			return err
+		}
+		if err := recordInitialPrices(tx, subscription); err != nil {
+			return err
+		}
+		return recordVersions(tx, subscription)
+	})
 }
 
 // GetByID получает подписку по её ID
 func (r *SubscriptionRepository) GetByID(id uint) (*models.Subscription, error) {
 	var subscription models.Subscription
-	err := r.db.First(&subscription, id).Error
+	err := r.subscriptions().First(&subscription, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
-// Update обновляет существующую подписку
-func (r *SubscriptionRepository) Update(id uint, subscription *models.Subscription) error {
-	// Обновить только предоставленные поля
-	return r.db.Model(&models.Subscription{}).Where("id = ?", id).Updates(subscription).Error
+// Update обновляет существующую подписку и возвращает ее состояние до и после обновления.
+// Если подписка не найдена, возвращается gorm.ErrRecordNotFound
+func (r *SubscriptionRepository) Update(id uint, subscription *models.Subscription) (*SubscriptionChange, error) {
+	var change *SubscriptionChange
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, err = updateSubscription(tx, r.scope, id, subscription)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
 }
 
-// Delete удаляет подписку по её ID
-func (r *SubscriptionRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Subscription{}, id).Error
+// Delete перемещает подписку в корзину и возвращает удаленную подписку.
+// Если подписка не найдена или уже удалена, возвращается gorm.ErrRecordNotFound
+func (r *SubscriptionRepository) Delete(id uint) (*models.Subscription, error) {
+	var deleted []models.Subscription
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.scope.apply(tx.Clauses(clause.Returning{})).Where("id = ?", id).Delete(&deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordDeletedVersions(tx, deleted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deleted[0], nil
 }
 
-// List получает подписки с опциональными фильтрами и пагинацией
-func (r *SubscriptionRepository) List(offset, limit int, userID, serviceName string) ([]models.Subscription, error) {
+// ListDeleted получает подписки из корзины, начиная с удаленных последними
+func (r *SubscriptionRepository) ListDeleted(offset, limit int) ([]models.Subscription, error) {
 	var subscriptions []models.Subscription
+	err := r.subscriptions().Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&subscriptions).Error
+	return subscriptions, err
+}
 
-	// Построить запрос с фильтрами
-	query := r.db.Model(&models.Subscription{})
+// Restore возвращает подписку из корзины и возвращает ее состояние до и после восстановления.
+// Если подписки нет в корзине, возвращается gorm.ErrRecordNotFound
+func (r *SubscriptionRepository) Restore(id uint) (*SubscriptionChange, error) {
+	change := &SubscriptionChange{Before: &models.Subscription{}, After: &models.Subscription{}}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Заблокировать строку и получить состояние в корзине
+		err := r.scope.apply(tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"})).
+			Where("deleted_at IS NOT NULL").First(change.Before, id).Error
+		if err != nil {
+			return err
+		}
 
-	// Применить фильтры
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-	if serviceName != "" {
-		query = query.Where("service_name = ?", serviceName)
+		err = tx.Unscoped().Model(&models.Subscription{}).Where("id = ?", id).Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		if err := r.scope.apply(tx).First(change.After, id).Error; err != nil {
+			return err
+		}
+		return recordVersions(tx, change.After)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return change, nil
+}
+
+// PurgeDeleted окончательно удаляет подписки всех организаций, находящиеся в корзине с момента before,
+// вместе с историей цен. Версии подписок остаются, чтобы прошлые отчеты можно было воспроизвести
+func (r *SubscriptionRepository) PurgeDeleted(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at < ?", before).Delete(&models.Subscription{})
+	return result.RowsAffected, result.Error
+}
+
+// List получает подписки, подходящие под фильтр, с сортировкой и пагинацией по смещению
+func (r *SubscriptionRepository) List(filter *models.SubscriptionFilter, sort []models.SortField, offset, limit int) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+
+	// Построить запрос с фильтрами и сортировкой
+	query := applyFilter(r.subscriptions(), filter)
+	query = applyOrder(query, orderWithTieBreaker(sort))
 
 	// Получить результаты с пагинацией
-	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&subscriptions).Error
+	err := query.Offset(offset).Limit(limit).Find(&subscriptions).Error
 	if err != nil {
 		return nil, err
 	}
@@ -66,21 +148,39 @@ func (r *SubscriptionRepository) List(offset, limit int, userID, serviceName str
 	return subscriptions, nil
 }
 
-// Count возвращает общее количество подписок с опциональными фильтрами
-func (r *SubscriptionRepository) Count(userID, serviceName string) (int64, error) {
-	var total int64
+// ListAfter получает подписки, подходящие под фильтр, которые следуют за курсором в порядке сортировки.
+// Без курсора возвращается начало списка
+func (r *SubscriptionRepository) ListAfter(filter *models.SubscriptionFilter, sort []models.SortField, cursor *SubscriptionCursor, limit int) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
 
 	// Построить запрос с фильтрами
-	query := r.db.Model(&models.Subscription{})
+	order := orderWithTieBreaker(sort)
+	query := applyFilter(r.subscriptions(), filter)
 
-	// Применить фильтры
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
+	// Продолжить со строки после курсора
+	if cursor != nil {
+		args, err := cursor.args(order)
+		if err != nil {
+			return nil, err
+		}
+		query = applyKeyset(query, order, args)
 	}
-	if serviceName != "" {
-		query = query.Where("service_name = ?", serviceName)
+
+	err := applyOrder(query, order).Limit(limit).Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
 	}
 
+	return subscriptions, nil
+}
+
+// Count возвращает количество подписок, подходящих под фильтр
+func (r *SubscriptionRepository) Count(filter *models.SubscriptionFilter) (int64, error) {
+	var total int64
+
+	// Построить запрос с фильтрами
+	query := applyFilter(r.subscriptions(), filter)
+
 	// Получить количество
 	err := query.Count(&total).Error
 	if err != nil {
@@ -90,20 +190,13 @@ func (r *SubscriptionRepository) Count(userID, serviceName string) (int64, error
 	return total, nil
 }
 
-// CalculateTotalCost вычисляет общую стоимость подписок с опциональными фильтрами
-func (r *SubscriptionRepository) CalculateTotalCost(userID, serviceName string, startDate, endDate any) (int64, error) {
+// CalculateTotalCost вычисляет общую стоимость подписок, подходящих под фильтр,
+// с датой начала в опциональном диапазоне startDate-endDate
+func (r *SubscriptionRepository) CalculateTotalCost(filter *models.SubscriptionFilter, startDate, endDate any) (int64, error) {
 	var totalCost int64
 
-	// Построить запрос
-	query := r.db.Model(&models.Subscription{}).Select("SUM(price)")
-
-	// Применить фильтры
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-	if serviceName != "" {
-		query = query.Where("service_name = ?", serviceName)
-	}
+	// Построить запрос с фильтрами
+	query := applyFilter(r.subscriptions().Select("COALESCE(SUM(price), 0)"), filter)
 
 	// Применить фильтры по диапазону дат, если они предоставлены
 	if startDate != nil {
@@ -122,3 +215,277 @@ func (r *SubscriptionRepository) CalculateTotalCost(userID, serviceName string,
 
 	return totalCost, nil
 }
+
+// Iterate читает подписки, подходящие под фильтр, курсором базы данных и передает их по одной в fn.
+// Все подходящие строки читаются без пагинации; ошибка fn прерывает чтение
+func (r *SubscriptionRepository) Iterate(ctx context.Context, filter *models.SubscriptionFilter, sort []models.SortField, fn func(*models.Subscription) error) error {
+	// Построить запрос с фильтрами и сортировкой
+	query := applyFilter(r.subscriptions().WithContext(ctx), filter)
+	query = applyOrder(query, orderWithTieBreaker(sort))
+
+	// Открыть курсор
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Прочитать строки по одной
+	for rows.Next() {
+		var subscription models.Subscription
+		if err := r.db.ScanRows(rows, &subscription); err != nil {
+			return err
+		}
+		if err := fn(&subscription); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// bulkBatchSize количество строк в одном INSERT при массовом создании
+const bulkBatchSize = 500
+
+// BulkCreate создает подписки пакетными вставками в одной транзакции.
+// В режиме bestEffort ошибка отдельной строки не откатывает остальные, а возвращается
+// в срезе ошибок под индексом этой строки; иначе любая ошибка откатывает всю транзакцию
+func (r *SubscriptionRepository) BulkCreate(subscriptions []*models.Subscription, bestEffort bool) ([]error, error) {
+	errs := make([]error, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscription.TenantID = r.scope.TenantID
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(subscriptions); start += bulkBatchSize {
+			end := min(start+bulkBatchSize, len(subscriptions))
+			batch := subscriptions[start:end]
+
+			// Все или ничего: ошибка пакета откатывает транзакцию
+			if !bestEffort {
+				if err := createBatch(tx, batch); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Попробовать вставить пакет целиком
+			if err := tx.SavePoint("bulk_batch").Error; err != nil {
+				return err
+			}
+			if err := createBatch(tx, batch); err == nil {
+				continue
+			}
+			if err := tx.RollbackTo("bulk_batch").Error; err != nil {
+				return err
+			}
+
+			// Пакет не вставился, вставить строки по одной, чтобы найти ошибочные
+			for i, subscription := range batch {
+				subscription.ID = 0
+				if err := tx.SavePoint("bulk_row").Error; err != nil {
+					return err
+				}
+				if err := createBatch(tx, []*models.Subscription{subscription}); err != nil {
+					errs[start+i] = err
+					if err := tx.RollbackTo("bulk_row").Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// BulkUpdate обновляет подписки в одной транзакции и возвращает изменения успешно обновленных строк.
+// Для отсутствующих подписок возвращается gorm.ErrRecordNotFound под индексом строки.
+// В режиме bestEffort ошибка отдельной строки не откатывает остальные
+func (r *SubscriptionRepository) BulkUpdate(ids []uint, subscriptions []*models.Subscription, bestEffort bool) ([]*SubscriptionChange, []error, error) {
+	changes := make([]*SubscriptionChange, len(subscriptions))
+	errs := make([]error, len(subscriptions))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, subscription := range subscriptions {
+			if bestEffort {
+				if err := tx.SavePoint("bulk_row").Error; err != nil {
+					return err
+				}
+			}
+
+			change, err := updateSubscription(tx, r.scope, ids[i], subscription)
+			if err == nil {
+				changes[i] = change
+				continue
+			}
+
+			if !bestEffort {
+				return &BulkItemError{Index: i, Err: err}
+			}
+			errs[i] = err
+			if err := tx.RollbackTo("bulk_row").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return changes, errs, nil
+}
+
+// BulkDeleteByIDs перемещает подписки по списку ID в корзину в одной транзакции и возвращает удаленные подписки.
+// Если allOrNothing установлен и хотя бы одна подписка не найдена, транзакция откатывается,
+// возвращается gorm.ErrRecordNotFound и подписки, которые были бы удалены
+func (r *SubscriptionRepository) BulkDeleteByIDs(ids []uint, allOrNothing bool) ([]models.Subscription, error) {
+	var deleted []models.Subscription
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.scope.apply(tx.Clauses(clause.Returning{})).Where("id IN ?", ids).Delete(&deleted).Error; err != nil {
+			return err
+		}
+		if allOrNothing && len(deleted) != len(uniqueIDs(ids)) {
+			return gorm.ErrRecordNotFound
+		}
+		return recordDeletedVersions(tx, deleted)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return deleted, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// BulkDeleteByFilter перемещает в корзину не больше limit подписок с наименьшими ID, подходящих под фильтр,
+// и возвращает удаленные подписки
+func (r *SubscriptionRepository) BulkDeleteByFilter(filter *models.SubscriptionFilter, limit int) ([]models.Subscription, error) {
+	var deleted []models.Subscription
+
+	// Построить запрос с фильтрами; подписки из корзины не удаляются повторно,
+	// иначе запрос без условия мягкого удаления удалил бы их окончательно
+	active := *filter
+	active.IncludeDeleted = false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		matched := applyFilter(r.scope.apply(tx.Model(&models.Subscription{})), &active).Select("id").Order("id").Limit(limit)
+		query := tx.Clauses(clause.Returning{}).Where("id IN (?)", matched)
+		if err := query.Delete(&deleted).Error; err != nil {
+			return err
+		}
+		return recordDeletedVersions(tx, deleted)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// createBatch вставляет пакет подписок одним INSERT и записывает их начальные цены и первые версии в историю
+func createBatch(tx *gorm.DB, subscriptions []*models.Subscription) error {
+	if err := tx.Create(subscriptions).Error; err != nil {
+		return err
+	}
+	if err := recordInitialPrices(tx, subscriptions...); err != nil {
+		return err
+	}
+	return recordVersions(tx, subscriptions...)
+}
+
+// updateSubscription обновляет предоставленные поля подписки из области видимости scope
+// в транзакции tx и записывает изменение цены в историю
+func updateSubscription(tx *gorm.DB, scope Scope, id uint, subscription *models.Subscription) (*SubscriptionChange, error) {
+	change := &SubscriptionChange{Before: &models.Subscription{}, After: &models.Subscription{}}
+
+	// Заблокировать строку и получить текущее состояние
+	err := scope.apply(tx.Clauses(clause.Locking{Strength: "UPDATE"})).First(change.Before, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Обновить только предоставленные поля; организация подписки не изменяется
+	if err := tx.Model(&models.Subscription{}).Where("id = ?", id).Omit("tenant_id").Updates(subscription).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.First(change.After, id).Error; err != nil {
+		return nil, err
+	}
+	if err := recordVersions(tx, change.After); err != nil {
+		return nil, err
+	}
+
+	// Записать новую цену в историю, если она изменилась
+	if !change.PriceChanged() {
+		return change, nil
+	}
+	err = tx.Create(&models.PriceChange{
+		TenantID:       change.Before.TenantID,
+		SubscriptionID: id,
+		OldPrice:       &change.Before.Price,
+		Price:          change.After.Price,
+		ChangedAt:      change.After.UpdatedAt,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// recordInitialPrices записывает начальные цены созданных подписок в историю
+func recordInitialPrices(tx *gorm.DB, subscriptions ...*models.Subscription) error {
+	changes := make([]models.PriceChange, len(subscriptions))
+	for i, subscription := range subscriptions {
+		changes[i] = models.PriceChange{
+			TenantID:       subscription.TenantID,
+			SubscriptionID: subscription.ID,
+			Price:          subscription.Price,
+			ChangedAt:      subscription.CreatedAt,
+		}
+	}
+	return tx.Create(&changes).Error
+}
+
+// SubscriptionChange состояние подписки до и после обновления
+type SubscriptionChange struct {
+	Before *models.Subscription
+	After  *models.Subscription
+}
+
+// PriceChanged сообщает, что обновление изменило цену подписки
+func (c *SubscriptionChange) PriceChanged() bool {
+	return c.Before.Price != c.After.Price
+}
+
+// BulkItemError описывает ошибку строки, из-за которой откатилась массовая операция
+type BulkItemError struct {
+	Index int
+	Err   error
+}
+
+// Error возвращает текст ошибки строки
+func (e *BulkItemError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap возвращает исходную ошибку строки
+func (e *BulkItemError) Unwrap() error {
+	return e.Err
+}
+
+// uniqueIDs возвращает ID без повторов
+func uniqueIDs(ids []uint) map[uint]struct{} {
+	unique := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+	return unique
+}
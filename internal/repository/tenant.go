@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TenantRepository обрабатывает операции с базой данных для организаций
+type TenantRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantRepository создает новый репозиторий организаций
+func NewTenantRepository(db *gorm.DB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+// Create сохраняет новую организацию
+func (r *TenantRepository) Create(tenant *models.Tenant) error {
+	return r.db.Create(tenant).Error
+}
+
+// GetByID получает организацию по ID
+func (r *TenantRepository) GetByID(id string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := r.db.Where("id = ?", id).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// List получает все организации
+func (r *TenantRepository) List() ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.Order("id").Find(&tenants).Error
+	return tenants, err
+}
+
+// UpdateSettings обновляет валюту и часовой пояс организации.
+// Если организация не найдена, возвращается gorm.ErrRecordNotFound
+func (r *TenantRepository) UpdateSettings(tenant *models.Tenant) error {
+	tenant.UpdatedAt = time.Now()
+	result := r.db.Model(tenant).Where("id = ?", tenant.ID).
+		Select("currency", "timezone", "updated_at").
+		Updates(tenant)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
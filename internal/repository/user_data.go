@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditErasureSetting параметр сеанса, разрешающий триггеру журнала аудита обезличивание записей
+// в текущей транзакции. Остальные изменения журнала по-прежнему запрещены
+const AuditErasureSetting = "app.audit_erasure"
+
+// eventPayloadOfUser условие на событие в формате JSON, относящееся к подписке пользователя
+const eventPayloadOfUser = "(payload->'subscription'->>'user_id' = ? OR payload->'previous'->>'user_id' = ?)"
+
+// UserData получает все данные пользователя userID в организации репозитория, включая подписки в корзине
+func (r *SubscriptionRepository) UserData(userID string) (*models.UserData, error) {
+	var data models.UserData
+	tenantID := r.scope.TenantID
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+			Order("id").Find(&data.Subscriptions).Error
+		if err != nil {
+			return err
+		}
+
+		ids := tx.Unscoped().Model(&models.Subscription{}).Select("id").Where("tenant_id = ? AND user_id = ?", tenantID, userID)
+		err = tx.Where("tenant_id = ? AND subscription_id IN (?)", tenantID, ids).
+			Order("subscription_id, changed_at, id").Find(&data.PriceHistory).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+			Order("subscription_id, valid_from").Find(&data.Versions).Error
+		if err != nil {
+			return err
+		}
+
+		return collectUserRecords(tx, tenantID, userID, &data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// AnonymizeUser обезличивает данные пользователя userID в организации репозитория в одной транзакции:
+// ID пользователя заменяется на pseudonym, заметки и метки удаляются. Сервис, цена и даты подписок
+// остаются, поэтому расчеты стоимости по организации и сервисам не меняются
+func (r *SubscriptionRepository) AnonymizeUser(userID, pseudonym string) (*models.UserErasure, error) {
+	erasure := &models.UserErasure{Pseudonym: pseudonym}
+	tenantID := r.scope.TenantID
+	personal := map[string]any{"user_id": pseudonym, "notes": "", "tags": nil}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Подписки, включая корзину, и их версии
+		result := tx.Unscoped().Model(&models.Subscription{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID).UpdateColumns(personal)
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Subscriptions = result.RowsAffected
+
+		result = tx.Model(&models.SubscriptionVersion{}).
+			Where("tenant_id = ? AND user_id = ?", tenantID, userID).UpdateColumns(personal)
+		if result.Error != nil {
+			return result.Error
+		}
+		erasure.Versions = result.RowsAffected
+
+		return anonymizeUserRecords(tx, tenantID, userID, erasure)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return erasure, nil
+}
+
+// collectUserRecords получает записи журнала аудита и события пользователя: они хранятся в базе данных
+// при любом хранилище подписок
+func collectUserRecords(db *gorm.DB, tenantID, userID string, data *models.UserData) error {
+	err := db.Where("tenant_id = ? AND (user_id = ? OR actor = ?)", tenantID, userID, userID).
+		Order("id").Find(&data.AuditEntries).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Order("sequence").Find(&data.Events).Error
+}
+
+// anonymizeUserRecords обезличивает данные пользователя вне таблиц подписок в транзакции tx
+// и записывает количество обезличенных записей в erasure
+func anonymizeUserRecords(tx *gorm.DB, tenantID, userID string, erasure *models.UserErasure) error {
+	pseudonym := erasure.Pseudonym
+
+	// Журнал аудита
+	var err error
+	if erasure.AuditEntries, err = anonymizeAuditEntries(tx, tenantID, userID, pseudonym); err != nil {
+		return err
+	}
+
+	// Журнал событий, outbox и доставки вебхуков хранят события с состоянием подписки
+	err = tx.Model(&models.StoredEvent{}).Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		UpdateColumn("user_id", pseudonym).Error
+	if err != nil {
+		return err
+	}
+	if erasure.Events, err = anonymizeEventPayloads(tx, "subscription_events", "sequence", tenantID, userID, pseudonym); err != nil {
+		return err
+	}
+	if erasure.OutboxMessages, err = anonymizeEventPayloads(tx, "outbox", "id", tenantID, userID, pseudonym); err != nil {
+		return err
+	}
+	if erasure.WebhookDeliveries, err = anonymizeEventPayloads(tx, "webhook_deliveries", "id", tenantID, userID, pseudonym); err != nil {
+		return err
+	}
+
+	// Сохраненные ответы на запросы с Idempotency-Key содержат подписки пользователя и повторялись бы
+	// до истечения срока ключа, поэтому такие ключи удаляются
+	erasure.IdempotencyKeys, err = deleteIdempotencyResponses(tx, tenantID, userID)
+	return err
+}
+
+// deleteIdempotencyResponses удаляет ключи идемпотентности организации, сохраненный ответ которых
+// упоминает пользователя userID. Ключи хранятся недолго, поэтому ответы проверяются без индекса
+func deleteIdempotencyResponses(tx *gorm.DB, tenantID, userID string) (int64, error) {
+	var keys []models.IdempotencyKey
+	err := tx.Select("key", "response_body").Where("tenant_id = ? AND completed = ?", tenantID, true).Find(&keys).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var matched []string
+	for _, key := range keys {
+		if bytes.Contains(key.ResponseBody, []byte(userID)) {
+			matched = append(matched, key.Key)
+		}
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	result := tx.Where("tenant_id = ? AND key IN ?", tenantID, matched).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// anonymizeAuditEntries обезличивает записи журнала аудита о подписках пользователя и о его изменениях
+func anonymizeAuditEntries(tx *gorm.DB, tenantID, userID, pseudonym string) (int64, error) {
+	// Разрешить триггеру журнала изменение записей до конца транзакции; триггер есть только в PostgreSQL
+	if isPostgres(tx) {
+		if err := tx.Exec("SELECT set_config(?, 'on', true)", AuditErasureSetting).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	var entries []models.AuditEntry
+	err := tx.Where("tenant_id = ? AND (user_id = ? OR actor = ?)", tenantID, userID, userID).Find(&entries).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		changes, err := models.AnonymizeAuditChanges(entry.Changes, userID, pseudonym)
+		if err != nil {
+			return 0, err
+		}
+		columns := map[string]any{"changes": changes}
+		if entry.UserID == userID {
+			columns["user_id"] = pseudonym
+		}
+		if entry.Actor == userID {
+			columns["actor"] = pseudonym
+		}
+		if err := tx.Model(&models.AuditEntry{}).Where("id = ?", entry.ID).UpdateColumns(columns).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(entries)), nil
+}
+
+// anonymizeEventPayloads обезличивает события подписок пользователя в столбце payload таблицы table
+// с первичным ключом key
+func anonymizeEventPayloads(tx *gorm.DB, table, key, tenantID, userID, pseudonym string) (int64, error) {
+	var rows []struct {
+		ID      int64
+		Payload json.RawMessage
+	}
+	err := tx.Table(table).Select(key+" AS id, payload").
+		Where("tenant_id = ? AND "+eventPayloadOfUser, tenantID, userID, userID).Find(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		payload, err := models.AnonymizeEvent(row.Payload, userID, pseudonym)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Table(table).Where(key+" = ?", row.ID).Update("payload", payload).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(rows)), nil
+}
@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Обезличивание удаляет сохраненные ответы на запросы с Idempotency-Key, содержащие данные пользователя,
+// в хранилище подписок в базе данных и в памяти
+func TestAnonymizeUserDeletesIdempotencyResponses(t *testing.T) {
+	const (
+		userA     = "11111111-1111-1111-1111-111111111111"
+		userB     = "22222222-2222-2222-2222-222222222222"
+		pseudonym = "33333333-3333-3333-3333-333333333333"
+	)
+	stores := map[string]func(db *gorm.DB) repository.Subscriptions{
+		"database": func(db *gorm.DB) repository.Subscriptions { return repository.NewSubscriptionRepository(db) },
+		"memory":   func(db *gorm.DB) repository.Subscriptions { return repository.NewMemorySubscriptionRepository(db) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			db := openDB(t, database.DriverMemory)
+			idempotency := repository.NewIdempotencyRepository(db)
+			repo := newStore(db).WithScope(repository.Scope{TenantID: "tenant-a"})
+
+			keys := []struct {
+				tenantID, key, userID string
+			}{
+				{"tenant-a", "own", userA},
+				{"tenant-a", "other-user", userB},
+				{"tenant-b", "other-tenant", userA},
+			}
+			for _, k := range keys {
+				now := time.Now()
+				reserved, err := idempotency.Reserve(&models.IdempotencyKey{
+					TenantID: k.tenantID, Key: k.key, RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+				})
+				if err != nil || !reserved {
+					t.Fatalf("Reserve: %v, %v", reserved, err)
+				}
+				body := fmt.Appendf(nil, `{"id":1,"service_name":"Netflix","user_id":%q}`, k.userID)
+				if err := idempotency.Complete(k.tenantID, k.key, 201, "application/json", body); err != nil {
+					t.Fatalf("Complete: %v", err)
+				}
+			}
+			// Незавершенный запрос не содержит ответа и не удаляется
+			now := time.Now()
+			if _, err := idempotency.Reserve(&models.IdempotencyKey{
+				TenantID: "tenant-a", Key: "in-progress", RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+			}); err != nil {
+				t.Fatalf("Reserve: %v", err)
+			}
+
+			erasure, err := repo.AnonymizeUser(userA, pseudonym)
+			if err != nil {
+				t.Fatalf("AnonymizeUser: %v", err)
+			}
+			if erasure.IdempotencyKeys != 1 {
+				t.Fatalf("AnonymizeUser удалил %d ключей идемпотентности, ожидался 1", erasure.IdempotencyKeys)
+			}
+			if _, err := idempotency.GetByKey("tenant-a", "own"); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("ответ с данными пользователя не удален: %v", err)
+			}
+			for _, k := range []struct{ tenantID, key string }{{"tenant-a", "other-user"}, {"tenant-b", "other-tenant"}, {"tenant-a", "in-progress"}} {
+				if _, err := idempotency.GetByKey(k.tenantID, k.key); err != nil {
+					t.Fatalf("ключ %s организации %s удален: %v", k.key, k.tenantID, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// versionColumns столбцы версии, образующие строку подписки
+const versionColumns = "subscription_id AS id, tenant_id, service_name, price, user_id, start_date, end_date, notes, tags, created_at, updated_at, deleted_at"
+
+// AsOf возвращает репозиторий, запросы которого видят подписки в состоянии на момент at по истории версий.
+// Подписки, удаленные к этому моменту, не видны так же, как и текущие удаленные. Если at равен nil,
+// возвращается репозиторий с текущим состоянием. Репозиторий с моментом времени предназначен только для чтения
+func (r *SubscriptionRepository) AsOf(at *time.Time) Subscriptions {
+	if at == nil {
+		return r
+	}
+	return &SubscriptionRepository{db: r.db, scope: r.scope, asOf: at}
+}
+
+// versionsAt начинает запрос к версиям подписок, действовавшим в момент at, в виде строк подписок
+func versionsAt(db *gorm.DB, at time.Time) *gorm.DB {
+	return db.Model(&models.SubscriptionVersion{}).Select(versionColumns).
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", at, at)
+}
+
+// recordVersions закрывает текущие версии подписок и сохраняет их новое состояние в транзакции tx
+func recordVersions(tx *gorm.DB, subscriptions ...*models.Subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	ids := make([]uint, len(subscriptions))
+	versions := make([]models.SubscriptionVersion, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.ID
+		versions[i] = models.NewSubscriptionVersion(subscription, now)
+	}
+
+	err := tx.Model(&models.SubscriptionVersion{}).
+		Where("subscription_id IN ? AND valid_to IS NULL", ids).
+		Update("valid_to", now).Error
+	if err != nil {
+		return err
+	}
+	return tx.CreateInBatches(versions, bulkBatchSize).Error
+}
+
+// recordDeletedVersions сохраняет версии удаленных подписок
+func recordDeletedVersions(tx *gorm.DB, deleted []models.Subscription) error {
+	subscriptions := make([]*models.Subscription, len(deleted))
+	for i := range deleted {
+		subscriptions[i] = &deleted[i]
+	}
+	return recordVersions(tx, subscriptions...)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository обрабатывает операции с базой данных для вебхуков и их доставок
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository создает новый репозиторий вебхуков
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create создает новый вебхук
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// GetByID получает вебхук организации по его ID
+func (r *WebhookRepository) GetByID(tenantID string, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.Where("tenant_id = ?", tenantID).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// List получает все вебхуки организации
+func (r *WebhookRepository) List(tenantID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.Where("tenant_id = ?", tenantID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListActiveFor получает активные вебхуки организаций tenantIDs, подписанные хотя бы на один
+// из типов событий. Вебхук без типов событий получает все события
+func (r *WebhookRepository) ListActiveFor(ctx context.Context, tenantIDs, eventTypes []string) ([]models.Webhook, error) {
+	// Типы событий хранятся массивом JSON; в SQLite он разбирается функцией json_each
+	subscribed := "event_types = '[]'::jsonb OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(event_types) AS event_type WHERE event_type IN ?)"
+	if !isPostgres(r.db) {
+		subscribed = "json_array_length(event_types) = 0 OR EXISTS (SELECT 1 FROM json_each(event_types) WHERE json_each.value IN ?)"
+	}
+
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("active = ? AND tenant_id IN ?", true, tenantIDs).
+		Where(subscribed, eventTypes).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Delete удаляет вебхук организации вместе с его доставками.
+// Если вебхук не найден, возвращается gorm.ErrRecordNotFound
+func (r *WebhookRepository) Delete(tenantID string, id uint) error {
+	result := r.db.Where("tenant_id = ?", tenantID).Delete(&models.Webhook{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateDeliveries ставит доставки в очередь пакетными вставками.
+// Доставки события, уже поставленного в очередь этому вебхуку, пропускаются
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(deliveries, bulkBatchSize).Error
+}
+
+// ClaimDueDeliveries захватывает до limit доставок, время попытки которых наступило.
+// Время следующей попытки сдвигается на lease, поэтому другие экземпляры не получат
+// те же доставки, а доставки упавшего экземпляра будут повторены после истечения lease
+func (r *WebhookRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	// SQLite не блокирует строки, но и не выполняет две пишущие транзакции одновременно
+	var locking string
+	if isPostgres(r.db) {
+		locking = "FOR UPDATE SKIP LOCKED"
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := r.db.Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			`+locking+`
+		)
+		RETURNING *`,
+		now.Add(lease), now, models.DeliveryStatusPending, now, limit,
+	).Scan(&deliveries).Error
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Загрузить вебхуки захваченных доставок
+	ids := make([]uint, len(deliveries))
+	for i := range deliveries {
+		ids[i] = deliveries[i].WebhookID
+	}
+	var webhooks []models.Webhook
+	if err := r.db.Where("id IN ?", ids).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+	for i := range deliveries {
+		deliveries[i].Webhook = byID[deliveries[i].WebhookID]
+	}
+
+	return deliveries, nil
+}
+
+// SaveAttempt сохраняет результат попытки доставки
+func (r *WebhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error").
+		Updates(delivery).Error
+}
+
+// ListDeliveries получает доставки вебхука организации, начиная с последних
+func (r *WebhookRepository) ListDeliveries(tenantID string, webhookID uint, status string, offset, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.db.Where("tenant_id = ? AND webhook_id = ?", tenantID, webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
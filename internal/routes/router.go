@@ -3,30 +3,87 @@ package routes
 import (
 	"net/http"
 
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/graph"
 	"effective-mobile-subscription/internal/handlers"
+	"effective-mobile-subscription/internal/ratelimit"
 	"effective-mobile-subscription/internal/repository"
 	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+	"effective-mobile-subscription/pkg/middleware"
+	"effective-mobile-subscription/pkg/utils"
 
+	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
+// Services сервисы, создаваемые вызывающим кодом: они используются также gRPC сервером
+// и фоновыми задачами
+type Services struct {
+	Subscriptions *services.SubscriptionService
+	Webhooks      *services.WebhookService
+	Events        *services.EventStream
+	APIKeys       *services.APIKeyService
+	Tenants       *services.TenantService
+	UserData      *services.UserDataService
+	Idempotency   *services.IdempotencyService
+
+	// RateLimits равно nil, если ограничение частоты запросов отключено
+	RateLimits ratelimit.Store
+
+	// Authenticator равен nil, если аутентификация отключена
+	Authenticator *auth.Authenticator
+}
+
 // SetupRoutes настраивает все маршруты для приложения
-func SetupRoutes(db *gorm.DB) *mux.Router {
+func SetupRoutes(db *gorm.DB, cfg *config.Config, logger *utils.Logger, svc Services) *mux.Router {
 	// Создать маршрутизатор
 	router := mux.NewRouter()
 
+	// Присвоить запросу ID, по которому его можно найти в журнале аудита
+	router.Use(middleware.RequestIDMiddleware())
+
+	// Ограничить частоту запросов с одного IP адреса до аутентификации, чтобы подбор учетных данных
+	// и их проверка в базе данных тоже ограничивались
+	policy := ratelimit.NewPolicy(cfg)
+	if svc.RateLimits != nil && policy.IP.Requests > 0 {
+		router.Use(ratelimit.IPMiddleware(svc.RateLimits, policy.IP, cfg.RateLimitIPHeader, cfg.AuthPublicPaths))
+	}
+
+	// Проверять учетные данные всех запросов, кроме открытых путей.
+	// Без аутентификации вебхуки доступны без ограничений, как и остальные маршруты
+	adminOnly := func(next http.Handler) http.Handler { return next }
+	if svc.Authenticator != nil {
+		router.Use(auth.Middleware(svc.Authenticator, cfg.AuthPublicPaths))
+		adminOnly = auth.RequireRole(auth.RoleAdmin)
+	}
+
+	// Определить организацию запроса: по учетным данным, а без аутентификации - по заголовку.
+	// Все запросы к данным выполняются только в пределах этой организации
+	router.Use(tenant.Middleware(tenant.NewResolver(svc.Tenants), cfg.TenantHeader, cfg.AuthPublicPaths))
+
+	// Ограничить частоту запросов клиента; клиент уже определен аутентификацией
+	if svc.RateLimits != nil {
+		router.Use(ratelimit.Middleware(svc.RateLimits, policy, cfg.RateLimitIPHeader, cfg.AuthPublicPaths))
+	}
+
 	// Создать репозитории
-	subscriptionRepo := repository.NewSubscriptionRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
 
 	// Создать сервисы
-	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
+	auditService := services.NewAuditService(auditRepo)
 
 	// Создать обработчики
-	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
-
-	// Настроить маршруты
-	setupSubscriptionRoutes(router, subscriptionHandler)
+	subscriptionHandler := handlers.NewSubscriptionHandler(svc.Subscriptions)
+	webhookHandler := handlers.NewWebhookHandler(svc.Webhooks)
+	eventStreamHandler := handlers.NewEventStreamHandler(svc.Events)
+	apiKeyHandler := handlers.NewAPIKeyHandler(svc.APIKeys)
+	tenantHandler := handlers.NewTenantHandler(svc.Tenants)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	userDataHandler := handlers.NewUserDataHandler(svc.UserData)
+	idempotency := handlers.IdempotencyMiddleware(svc.Idempotency)
 
 	// Проверка состояния
 	// swagger:operation GET /health health healthCheck
@@ -47,22 +104,71 @@ func SetupRoutes(db *gorm.DB) *mux.Router {
 	//           example: ok
 	router.HandleFunc("/health", healthCheck).Methods("GET")
 
+	// GraphQL для отчетов: один запрос вместо нескольких вызовов REST API
+	router.Handle("/graphql", graph.NewHandler(svc.Subscriptions, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity, logger)).Methods("GET", "POST", "OPTIONS")
+	router.Handle("/graphql/playground", playground.Handler("GraphQL", "/graphql")).Methods("GET")
+
+	// Версии API монтируются под собственными префиксами и существуют одновременно
+	v1 := router.PathPrefix(v1Prefix).Subrouter()
+	setupV1Routes(v1, subscriptionHandler, webhookHandler, eventStreamHandler, tenantHandler, auditHandler, userDataHandler, idempotency, adminOnly)
+	setupAdminRoutes(v1.PathPrefix("/admin").Subrouter(), apiKeyHandler)
+
+	// Маршруты без префикса версии остаются на переходный период и помечаются устаревшими
+	if cfg.LegacyRoutesEnabled {
+		legacy := router.NewRoute().Subrouter()
+		legacy.Use(middleware.DeprecationMiddleware(cfg.LegacyRoutesDeprecatedAt, cfg.LegacyRoutesSunset, v1Prefix))
+		setupSubscriptionRoutes(legacy, subscriptionHandler, idempotency)
+	}
+
 	return router
 }
 
 // setupSubscriptionRoutes настраивает маршруты для управления подписками
-func setupSubscriptionRoutes(router *mux.Router, handler *handlers.SubscriptionHandler) {
-	// Операции CRUDL
-	router.HandleFunc("/subscriptions", handler.CreateSubscription).Methods("POST")
+func setupSubscriptionRoutes(router *mux.Router, handler *handlers.SubscriptionHandler, idempotency func(http.Handler) http.Handler) {
+	// Операции CRUDL; создание поддерживает заголовок Idempotency-Key
+	router.Handle("/subscriptions", idempotency(http.HandlerFunc(handler.CreateSubscription))).Methods("POST")
 	router.HandleFunc("/subscriptions/{id:[0-9]+}", handler.GetSubscription).Methods("GET")
 	router.HandleFunc("/subscriptions/{id:[0-9]+}", handler.UpdateSubscription).Methods("PUT")
 	router.HandleFunc("/subscriptions/{id:[0-9]+}", handler.DeleteSubscription).Methods("DELETE")
 	router.HandleFunc("/subscriptions", handler.ListSubscriptions).Methods("GET")
 
+	// Массовые операции
+	router.HandleFunc("/subscriptions/bulk", handler.BulkCreateSubscriptions).Methods("POST")
+	router.HandleFunc("/subscriptions/bulk", handler.BulkUpdateSubscriptions).Methods("PATCH")
+	router.HandleFunc("/subscriptions/bulk", handler.BulkDeleteSubscriptions).Methods("DELETE")
+
+	// Импорт
+	router.HandleFunc("/subscriptions/import", handler.ImportSubscriptions).Methods("POST")
+
+	// Выгрузка
+	router.HandleFunc("/subscriptions/export", handler.ExportSubscriptions).Methods("GET")
+
+	// Поиск
+	router.HandleFunc("/subscriptions/search", handler.SearchSubscriptions).Methods("GET")
+
 	// Расчет стоимости
 	router.HandleFunc("/subscriptions/cost", handler.CalculateTotalCost).Methods("GET")
 }
 
+// setupWebhookRoutes настраивает маршруты для управления вебхуками под префиксом /webhooks.
+// Вебхуки получают события подписок всех пользователей, поэтому доступны только администратору
+func setupWebhookRoutes(router *mux.Router, handler *handlers.WebhookHandler, adminOnly func(http.Handler) http.Handler) {
+	router.Use(adminOnly)
+	router.HandleFunc("", handler.CreateWebhook).Methods("POST")
+	router.HandleFunc("", handler.ListWebhooks).Methods("GET")
+	router.HandleFunc("/{id:[0-9]+}", handler.GetWebhook).Methods("GET")
+	router.HandleFunc("/{id:[0-9]+}", handler.DeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/{id:[0-9]+}/deliveries", handler.ListDeliveries).Methods("GET")
+}
+
+// setupAdminRoutes настраивает маршруты администрирования; они доступны только роли admin
+func setupAdminRoutes(router *mux.Router, apiKeys *handlers.APIKeyHandler) {
+	router.Use(auth.RequireRole(auth.RoleAdmin))
+	router.HandleFunc("/api-keys", apiKeys.CreateAPIKey).Methods("POST")
+	router.HandleFunc("/api-keys", apiKeys.ListAPIKeys).Methods("GET")
+	router.HandleFunc("/api-keys/{id:[0-9]+}", apiKeys.RevokeAPIKey).Methods("DELETE")
+}
+
 // healthCheck - проверка состояния
 //
 //	@Summary		Health check
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+
+	"effective-mobile-subscription/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+//	@title			Subscription Management API
+//	@version		1.0
+//	@description	This is a subscription management service API.
+//	@termsOfService	http://swagger.io/terms/
+
+//	@contact.name	API Support
+//	@contact.url	http://www.swagger.io/support
+//	@contact.email	support@swagger.io
+
+//	@license.name	Apache 2.0
+//	@license.url	http://www.apache.org/licenses/LICENSE-2.0.html
+
+//	@host		localhost:8080
+//	@BasePath	/api/v1
+
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer <API key or JWT>". All routes except /health and /swagger/ require authentication unless AUTH_ENABLED=false. Data is isolated per tenant: the tenant comes from the credentials, or from the X-Tenant-ID header when authentication is disabled
+
+// v1Prefix префикс маршрутов API версии 1
+const v1Prefix = "/api/v1"
+
+// setupV1Routes настраивает маршруты API версии 1.
+// Следующая версия добавляется отдельной функцией с собственными обработчиками и префиксом.
+// Вебхуки, поток событий, корзина, настройки организации, журнал аудита и данные пользователей
+// появились после введения версий и доступны только под префиксом
+func setupV1Routes(router *mux.Router, handler *handlers.SubscriptionHandler, webhooks *handlers.WebhookHandler, events *handlers.EventStreamHandler, tenants *handlers.TenantHandler, audit *handlers.AuditHandler, users *handlers.UserDataHandler, idempotency, adminOnly func(http.Handler) http.Handler) {
+	setupSubscriptionRoutes(router, handler, idempotency)
+	router.HandleFunc("/subscriptions/events", events.StreamEvents).Methods("GET")
+	router.HandleFunc("/subscriptions/trash", handler.ListTrash).Methods("GET")
+	router.HandleFunc("/subscriptions/{id:[0-9]+}/restore", handler.RestoreSubscription).Methods("POST")
+	router.HandleFunc("/subscriptions/{id:[0-9]+}/history", audit.GetSubscriptionHistory).Methods("GET")
+	router.HandleFunc("/audit", audit.ListAuditEntries).Methods("GET")
+	router.HandleFunc("/users/{user_id}/data-export", users.ExportUserData).Methods("GET")
+	router.HandleFunc("/users/{user_id}/data", users.EraseUserData).Methods("DELETE")
+	setupWebhookRoutes(router.PathPrefix("/webhooks").Subrouter(), webhooks, adminOnly)
+	router.HandleFunc("/tenant", tenants.GetTenant).Methods("GET")
+	router.Handle("/tenant", adminOnly(http.HandlerFunc(tenants.UpdateTenant))).Methods("PUT")
+	router.HandleFunc("/health", healthCheck).Methods("GET")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+// ErrForbidden возвращается, если участнику запрещена операция.
+// Подписки других пользователей для обычного пользователя не видны и возвращают gorm.ErrRecordNotFound
+var ErrForbidden = errors.New("недостаточно прав для операции")
+
+// access права участника запроса на подписки
+type access struct {
+	// tenantID организация запроса; без нее подписки не видны и не могут быть изменены
+	tenantID string
+	// userID ограничивает доступ подписками одного пользователя; пустое значение - все подписки
+	userID string
+	// readOnly запрещает изменения
+	readOnly bool
+}
+
+// accessFrom определяет права участника из контекста запроса. Все права ограничены организацией запроса.
+// Администратор видит и изменяет все подписки организации, аналитик только читает их, обычный
+// пользователь работает только со своими подписками. Без участника (аутентификация отключена
+// или фоновая задача) доступны все подписки организации
+func accessFrom(ctx context.Context) access {
+	tenantID := tenant.IDFrom(ctx)
+	principal, ok := auth.PrincipalFrom(ctx)
+	switch {
+	case !ok || principal.HasRole(auth.RoleAdmin):
+		return access{tenantID: tenantID}
+	case principal.HasRole(auth.RoleAnalyst):
+		return access{tenantID: tenantID, readOnly: true}
+	default:
+		return access{tenantID: tenantID, userID: principal.Subject}
+	}
+}
+
+// scope возвращает область видимости репозитория
+func (a access) scope() repository.Scope {
+	return repository.Scope{TenantID: a.tenantID, UserID: a.userID}
+}
+
+// checkWrite проверяет, что участник может изменять подписки
+func (a access) checkWrite() error {
+	if a.tenantID == "" {
+		return tenant.ErrUnknownTenant
+	}
+	if a.readOnly {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkOwner проверяет, что участник может изменять подписки пользователя userID
+func (a access) checkOwner(userID string) error {
+	if a.userID != "" && userID != a.userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// reader возвращает репозиторий, ограниченный подписками, которые видит участник из ctx
+func (s *SubscriptionService) reader(ctx context.Context) repository.Subscriptions {
+	return s.repo.WithScope(accessFrom(ctx).scope())
+}
+
+// writer возвращает репозиторий, ограниченный подписками участника из ctx, если ему разрешены изменения
+func (s *SubscriptionService) writer(ctx context.Context) (repository.Subscriptions, access, error) {
+	access := accessFrom(ctx)
+	if err := access.checkWrite(); err != nil {
+		return nil, access, err
+	}
+	return s.repo.WithScope(access.scope()), access, nil
+}
@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ownerID = "550e8400-e29b-41d4-a716-446655440000"
+	otherID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+)
+
+// asPrincipal контекст запроса участника с ролями roles в организации по умолчанию
+func asPrincipal(subject string, roles ...string) context.Context {
+	return auth.WithPrincipal(tenantContext(), &auth.Principal{
+		Subject: subject, Roles: roles, TenantID: models.DefaultTenantID, Method: auth.MethodJWT,
+	})
+}
+
+// seedAccess создает две подписки ownerID и одну otherID в организации по умолчанию
+// и одну подписку ownerID в другой организации. Возвращает ID подписок ownerID и otherID
+func seedAccess(t *testing.T, service *services.SubscriptionService) (own, foreign uint) {
+	t.Helper()
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	create := func(ctx context.Context, userID, name string, price int) uint {
+		subscription := &models.Subscription{ServiceName: name, Price: price, UserID: userID, StartDate: start}
+		if err := service.CreateSubscription(ctx, subscription); err != nil {
+			t.Fatalf("создание подписки %s: %v", name, err)
+		}
+		return subscription.ID
+	}
+	own = create(tenantContext(), ownerID, "Netflix", 100)
+	create(tenantContext(), ownerID, "Spotify", 200)
+	foreign = create(tenantContext(), otherID, "Netflix", 400)
+	create(tenant.WithTenant(context.Background(), &models.Tenant{ID: "acme"}), ownerID, "Netflix", 800)
+	return own, foreign
+}
+
+func TestAccessScopeReads(t *testing.T) {
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t)))
+	seedAccess(t, service)
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		count int64
+		cost  int
+	}{
+		{"без участника", tenantContext(), 3, 700},
+		{"администратор", asPrincipal(otherID, auth.RoleAdmin), 3, 700},
+		{"аналитик", asPrincipal(otherID, auth.RoleAnalyst), 3, 700},
+		{"пользователь", asPrincipal(ownerID), 2, 300},
+		{"пользователь без подписок", asPrincipal("7c9e6679-7425-40de-944b-e07fc1f90ae7"), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.ListSubscriptions(tt.ctx, services.ListParams{Page: 1, Limit: 10, IncludeTotal: true})
+			if err != nil {
+				t.Fatalf("ListSubscriptions: %v", err)
+			}
+			if int64(len(result.Subscriptions)) != tt.count || result.Total == nil || *result.Total != tt.count {
+				t.Fatalf("список: %d подписок, total %v; ожидалось %d", len(result.Subscriptions), result.Total, tt.count)
+			}
+
+			cost, err := service.CalculateTotalCost(tt.ctx, models.SubscriptionFilter{}, "01-2025", "01-2025")
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+			if cost != tt.cost {
+				t.Fatalf("стоимость = %d, ожидалось %d", cost, tt.cost)
+			}
+		})
+	}
+}
+
+// Чужая подписка для обычного пользователя не существует (404), а запрещенное изменение
+// видимой подписки или изменение аналитиком отклоняется с ErrForbidden (403)
+func TestAccessScopeWrites(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		write func(ctx context.Context, service *services.SubscriptionService, own, foreign uint) error
+		err   error
+	}{
+		{"пользователь изменяет свою подписку", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.UpdateSubscription(ctx, own, &models.Subscription{Price: 150})
+			}, nil},
+		{"пользователь читает чужую подписку", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, _, foreign uint) error {
+				_, err := s.GetSubscription(ctx, foreign)
+				return err
+			}, gorm.ErrRecordNotFound},
+		{"пользователь изменяет чужую подписку", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, _, foreign uint) error {
+				return s.UpdateSubscription(ctx, foreign, &models.Subscription{Price: 150})
+			}, gorm.ErrRecordNotFound},
+		{"пользователь удаляет чужую подписку", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, _, foreign uint) error {
+				return s.DeleteSubscription(ctx, foreign)
+			}, gorm.ErrRecordNotFound},
+		{"пользователь передает свою подписку другому", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.UpdateSubscription(ctx, own, &models.Subscription{UserID: otherID})
+			}, services.ErrForbidden},
+		{"пользователь создает подписку другому", asPrincipal(ownerID),
+			func(ctx context.Context, s *services.SubscriptionService, _, _ uint) error {
+				return s.CreateSubscription(ctx, &models.Subscription{
+					ServiceName: "Netflix", Price: 100, UserID: otherID, StartDate: time.Now(),
+				})
+			}, services.ErrForbidden},
+		{"аналитик изменяет подписку", asPrincipal(otherID, auth.RoleAnalyst),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.UpdateSubscription(ctx, own, &models.Subscription{Price: 150})
+			}, services.ErrForbidden},
+		{"аналитик удаляет подписку", asPrincipal(otherID, auth.RoleAnalyst),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.DeleteSubscription(ctx, own)
+			}, services.ErrForbidden},
+		{"аналитик создает подписку", asPrincipal(otherID, auth.RoleAnalyst),
+			func(ctx context.Context, s *services.SubscriptionService, _, _ uint) error {
+				return s.CreateSubscription(ctx, &models.Subscription{
+					ServiceName: "Netflix", Price: 100, UserID: otherID, StartDate: time.Now(),
+				})
+			}, services.ErrForbidden},
+		{"администратор изменяет чужую подписку", asPrincipal(otherID, auth.RoleAdmin),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.UpdateSubscription(ctx, own, &models.Subscription{UserID: otherID})
+			}, nil},
+		{"администратор удаляет чужую подписку", asPrincipal(otherID, auth.RoleAdmin),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.DeleteSubscription(ctx, own)
+			}, nil},
+		{"без организации", context.Background(),
+			func(ctx context.Context, s *services.SubscriptionService, own, _ uint) error {
+				return s.DeleteSubscription(ctx, own)
+			}, tenant.ErrUnknownTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t)))
+			own, foreign := seedAccess(t, service)
+
+			err := tt.write(tt.ctx, service, own, foreign)
+			if tt.err == nil && err != nil {
+				t.Fatalf("ошибка %v, ожидался успех", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval минимальный интервал обновления времени последнего использования ключа
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService управляет ключами API и проверяет их
+type APIKeyService struct {
+	repo *repository.APIKeyRepository
+}
+
+// NewAPIKeyService создает новый сервис ключей API
+func NewAPIKeyService(repo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateAPIKey создает ключ API организации из ctx и возвращает его вместе с самим ключом.
+// Ключ не хранится и не может быть получен повторно
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name, subject string, roles []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	tenantID := tenant.IDFrom(ctx)
+	if tenantID == "" {
+		return nil, "", tenant.ErrUnknownTenant
+	}
+	if name == "" {
+		return nil, "", &ValidationError{Field: "name", Message: "обязательное поле"}
+	}
+	if subject == "" {
+		return nil, "", &ValidationError{Field: "subject", Message: "обязательное поле"}
+	}
+	for _, role := range roles {
+		if !slices.Contains(auth.Roles, role) {
+			return nil, "", &ValidationError{Field: "roles", Message: fmt.Sprintf("неизвестная роль %q", role)}
+		}
+	}
+	if roles == nil {
+		roles = []string{}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", &ValidationError{Field: "expires_at", Message: "срок действия уже истек"}
+	}
+
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	key := auth.APIKeyPrefix + hex.EncodeToString(secret)
+
+	apiKey := &models.APIKey{
+		TenantID:  tenantID,
+		Name:      name,
+		Prefix:    key[:len(auth.APIKeyPrefix)+8],
+		KeyHash:   hashAPIKey(key),
+		Subject:   subject,
+		Roles:     roles,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(apiKey); err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+// ListAPIKeys получает все ключи API организации из ctx без самих ключей
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.List(tenant.IDFrom(ctx))
+}
+
+// RevokeAPIKey отзывает ключ API организации из ctx
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uint) error {
+	return s.repo.Revoke(tenant.IDFrom(ctx), id, time.Now())
+}
+
+// VerifyAPIKey проверяет ключ API. Для неизвестного, отозванного или истекшего ключа
+// возвращается auth.ErrInvalidCredentials
+func (s *APIKeyService) VerifyAPIKey(key string) (*models.APIKey, error) {
+	apiKey, err := s.repo.GetByHash(hashAPIKey(key))
+	if err == gorm.ErrRecordNotFound {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !apiKey.Usable(now) {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	// Время использования обновляется не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.repo.TouchLastUsed(apiKey.ID, now); err != nil {
+			slog.Warn("Не удалось обновить время использования ключа API", "ключ", apiKey.ID, "ошибка", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// hashAPIKey возвращает SHA-256 хеш ключа. Ключ содержит 256 случайных бит,
+// поэтому медленное хеширование, как для паролей, не требуется
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+)
+
+func TestAPIKeyHashAndLookup(t *testing.T) {
+	repo := repository.NewAPIKeyRepository(openDB(t))
+	service := services.NewAPIKeyService(repo)
+
+	apiKey, key, err := service.CreateAPIKey(tenantContext(), "ci", "service-account", []string{auth.RoleAnalyst}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(key, auth.APIKeyPrefix) || !strings.HasPrefix(key, apiKey.Prefix) {
+		t.Fatalf("ключ %q, префикс %q", key, apiKey.Prefix)
+	}
+
+	// Хранится только SHA-256 хеш ключа
+	hash := sha256.Sum256([]byte(key))
+	stored, err := repo.GetByHash(hex.EncodeToString(hash[:]))
+	if err != nil || stored.ID != apiKey.ID {
+		t.Fatalf("ключ по хешу: %+v, %v", stored, err)
+	}
+	if strings.Contains(stored.KeyHash, key[len(auth.APIKeyPrefix):]) {
+		t.Fatal("ключ хранится открытым текстом")
+	}
+
+	verified, err := service.VerifyAPIKey(key)
+	if err != nil || verified.ID != apiKey.ID || verified.Subject != "service-account" {
+		t.Fatalf("VerifyAPIKey: %+v, %v", verified, err)
+	}
+	if stored, err = repo.GetByHash(stored.KeyHash); err != nil || stored.LastUsedAt == nil {
+		t.Fatalf("время использования ключа не обновлено: %+v, %v", stored, err)
+	}
+}
+
+func TestVerifyAPIKeyRejects(t *testing.T) {
+	repo := repository.NewAPIKeyRepository(openDB(t))
+	service := services.NewAPIKeyService(repo)
+
+	_, key, err := service.CreateAPIKey(tenantContext(), "revoked", "service-account", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := service.ListAPIKeys(tenantContext())
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ListAPIKeys: %v, %v", keys, err)
+	}
+	if err := service.RevokeAPIKey(tenantContext(), keys[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Истекший ключ создается напрямую: сервис не принимает срок действия в прошлом
+	expiredKey := auth.APIKeyPrefix + "expired"
+	hash := sha256.Sum256([]byte(expiredKey))
+	expiresAt := time.Now().Add(-time.Minute)
+	err = repo.Create(&models.APIKey{
+		TenantID: models.DefaultTenantID, Name: "expired", Prefix: expiredKey[:8], KeyHash: hex.EncodeToString(hash[:]),
+		Subject: "service-account", Roles: []string{}, ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"неизвестный ключ", auth.APIKeyPrefix + "unknown"},
+		{"ключ с лишним символом", key + "0"},
+		{"отозванный ключ", key},
+		{"истекший ключ", expiredKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.VerifyAPIKey(tt.key); !errors.Is(err, auth.ErrInvalidCredentials) {
+				t.Fatalf("VerifyAPIKey: %v, ожидалась ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/pkg/middleware"
+
+	"gorm.io/gorm"
+)
+
+// MaxAuditLimit максимальное количество записей журнала аудита в одном ответе
+const MaxAuditLimit = 500
+
+// auditIgnoredFields поля подписки, изменения которых не записываются в аудит:
+// время обновления меняется при каждом изменении и не несет информации
+var auditIgnoredFields = []string{"updated_at"}
+
+// auditActions действия аудита для событий подписок; остальные события не являются изменениями
+var auditActions = map[string]string{
+	models.EventSubscriptionCreated: models.AuditActionCreate,
+	models.EventSubscriptionUpdated: models.AuditActionUpdate,
+	models.EventSubscriptionDeleted: models.AuditActionDelete,
+}
+
+// record записывает события изменений в outbox и соответствующие записи в журнал аудита текущей транзакции
+func record(ctx context.Context, outbox repository.OutboxWriter, audit repository.AuditWriter, events ...models.SubscriptionEvent) error {
+	if err := addEvents(outbox, events...); err != nil {
+		return err
+	}
+
+	var actor string
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		actor = principal.Subject
+	}
+	requestID := middleware.RequestIDFrom(ctx)
+
+	entries := make([]models.AuditEntry, 0, len(events))
+	for _, event := range events {
+		action, ok := auditActions[event.Type]
+		if !ok {
+			continue
+		}
+
+		before, after := event.Previous, event.Subscription
+		if action == models.AuditActionDelete {
+			before, after = event.Subscription, nil
+		}
+		changes, err := diffSubscriptions(before, after)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, models.AuditEntry{
+			TenantID:       event.TenantID,
+			SubscriptionID: event.Subscription.ID,
+			UserID:         event.Subscription.UserID,
+			Action:         action,
+			Actor:          actor,
+			RequestID:      requestID,
+			Changes:        changes,
+			CreatedAt:      event.OccurredAt,
+		})
+	}
+	return audit.Add(entries...)
+}
+
+// diffSubscriptions возвращает JSON с полями, отличающимися в before и after, и их значениями.
+// Пустой before означает создание, пустой after - удаление
+func diffSubscriptions(before, after *models.Subscription) (json.RawMessage, error) {
+	beforeFields, err := subscriptionFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := subscriptionFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for _, fields := range []map[string]any{beforeFields, afterFields} {
+		for name := range fields {
+			if slices.Contains(auditIgnoredFields, name) {
+				continue
+			}
+			if !reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+				changes[name] = models.AuditChange{Before: beforeFields[name], After: afterFields[name]}
+			}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+// subscriptionFields возвращает поля подписки в JSON представлении
+func subscriptionFields(subscription *models.Subscription) (map[string]any, error) {
+	if subscription == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// AuditService предоставляет журнал аудита изменений подписок
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// SubscriptionHistory получает историю изменений подписки от новых к старым, включая удаленную подписку.
+// Обычный пользователь видит только историю своих подписок. Если записей нет, возвращается gorm.ErrRecordNotFound
+func (s *AuditService) SubscriptionHistory(ctx context.Context, id uint) ([]models.AuditEntry, error) {
+	entries, err := s.repo.List(accessFrom(ctx).scope(), &models.AuditFilter{SubscriptionID: id}, 0, MaxAuditLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return entries, nil
+}
+
+// ListAuditEntries получает страницу page записей журнала аудита, подходящих под фильтр, от новых к старым.
+// Обычный пользователь видит только записи о своих подписках
+func (s *AuditService) ListAuditEntries(ctx context.Context, filter models.AuditFilter, page, limit int) ([]models.AuditEntry, error) {
+	if filter.Action != "" && !slices.Contains(models.AuditActions, filter.Action) {
+		return nil, &ValidationError{Field: "action", Message: "ожидается create, update или delete"}
+	}
+	if filter.UserID != "" && !uuidPattern.MatchString(filter.UserID) {
+		return nil, &ValidationError{Field: "user_id", Message: "ожидается UUID"}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, &ValidationError{Field: "to", Message: "должно быть позже from"}
+	}
+	if limit > MaxAuditLimit {
+		return nil, &ValidationError{Field: "limit", Message: fmt.Sprintf("не больше %d", MaxAuditLimit)}
+	}
+
+	return s.repo.List(accessFrom(ctx).scope(), &filter, (page-1)*limit, limit)
+}
@@ -0,0 +1,144 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/middleware"
+
+	"gorm.io/gorm"
+)
+
+// changesOf разбирает изменения записи журнала аудита
+func changesOf(t *testing.T, entry models.AuditEntry) map[string]models.AuditChange {
+	t.Helper()
+	var changes map[string]models.AuditChange
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		t.Fatalf("разбор изменений %s: %v", entry.Changes, err)
+	}
+	return changes
+}
+
+func TestAuditSubscriptionHistory(t *testing.T) {
+	db := openDB(t)
+	subscriptions := services.NewSubscriptionService(repository.NewSubscriptionRepository(db))
+	audit := services.NewAuditService(repository.NewAuditRepository(db))
+	ctx := middleware.WithRequestID(asPrincipal(ownerID), "req-1")
+
+	subscription := &models.Subscription{
+		ServiceName: "Netflix", Price: 990, UserID: ownerID,
+		StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), Tags: []string{"video"},
+	}
+	if err := subscriptions.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatal(err)
+	}
+	if err := subscriptions.UpdateSubscription(ctx, subscription.ID, &models.Subscription{Price: 1290}); err != nil {
+		t.Fatal(err)
+	}
+	// Изменение без новых значений не меняет ни одного поля, кроме времени обновления
+	if err := subscriptions.UpdateSubscription(ctx, subscription.ID, &models.Subscription{Price: 1290}); err != nil {
+		t.Fatal(err)
+	}
+	if err := subscriptions.DeleteSubscription(ctx, subscription.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := audit.SubscriptionHistory(ctx, subscription.ID)
+	if err != nil {
+		t.Fatalf("SubscriptionHistory: %v", err)
+	}
+	actions := make([]string, len(history))
+	for i, entry := range history {
+		actions[i] = entry.Action
+		if entry.Actor != ownerID || entry.RequestID != "req-1" || entry.UserID != ownerID {
+			t.Fatalf("запись %+v: ожидались участник, ID запроса и владелец", entry)
+		}
+	}
+	want := []string{models.AuditActionDelete, models.AuditActionUpdate, models.AuditActionUpdate, models.AuditActionCreate}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("действия %v, ожидались от новых к старым %v", actions, want)
+	}
+
+	// Создание: все поля со значением только после изменения, кроме времени обновления
+	created := changesOf(t, history[3])
+	if change, ok := created["service_name"]; !ok || change.Before != nil || change.After != "Netflix" {
+		t.Fatalf("создание service_name: %+v", created["service_name"])
+	}
+	if _, ok := created["updated_at"]; ok {
+		t.Fatal("время обновления записано в аудит")
+	}
+
+	// Изменение: только измененные поля
+	updated := changesOf(t, history[2])
+	if len(updated) != 1 || updated["price"].Before != float64(990) || updated["price"].After != float64(1290) {
+		t.Fatalf("изменение: %+v, ожидалась только цена 990 -> 1290", updated)
+	}
+	if unchanged := changesOf(t, history[1]); len(unchanged) != 0 {
+		t.Fatalf("изменение без новых значений: %+v", unchanged)
+	}
+
+	// Удаление: значения только до изменения
+	deleted := changesOf(t, history[0])
+	if change := deleted["price"]; change.Before != float64(1290) || change.After != nil {
+		t.Fatalf("удаление price: %+v", change)
+	}
+
+	// История чужой подписки для обычного пользователя не существует
+	if _, err := audit.SubscriptionHistory(asPrincipal(otherID), subscription.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("чужая история: %v, ожидалась gorm.ErrRecordNotFound", err)
+	}
+	if entries, err := audit.SubscriptionHistory(asPrincipal(otherID, auth.RoleAnalyst), subscription.ID); err != nil || len(entries) != 4 {
+		t.Fatalf("история для аналитика: %d записей, %v", len(entries), err)
+	}
+}
+
+func TestAuditListEntries(t *testing.T) {
+	db := openDB(t)
+	subscriptions := services.NewSubscriptionService(repository.NewSubscriptionRepository(db))
+	audit := services.NewAuditService(repository.NewAuditRepository(db))
+	seedAccess(t, subscriptions)
+
+	from, to := time.Now().Add(time.Hour), time.Now()
+	tests := []struct {
+		name   string
+		filter models.AuditFilter
+		count  int
+		valid  bool
+	}{
+		{"все записи", models.AuditFilter{}, 3, true},
+		{"по пользователю", models.AuditFilter{UserID: otherID}, 1, true},
+		{"по действию", models.AuditFilter{Action: models.AuditActionDelete}, 0, true},
+		{"с момента в будущем", models.AuditFilter{From: &from}, 0, true},
+		{"неизвестное действие", models.AuditFilter{Action: "purge"}, 0, false},
+		{"неверный user_id", models.AuditFilter{UserID: "123"}, 0, false},
+		{"to раньше from", models.AuditFilter{From: &from, To: &to}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := audit.ListAuditEntries(tenantContext(), tt.filter, 1, 50)
+			if !tt.valid {
+				if !services.IsValidationError(err) {
+					t.Fatalf("ListAuditEntries: %v, ожидалась ошибка проверки", err)
+				}
+				return
+			}
+			if err != nil || len(entries) != tt.count {
+				t.Fatalf("ListAuditEntries: %d записей, %v; ожидалось %d", len(entries), err, tt.count)
+			}
+		})
+	}
+
+	if _, err := audit.ListAuditEntries(tenantContext(), models.AuditFilter{}, 1, services.MaxAuditLimit+1); !services.IsValidationError(err) {
+		t.Fatalf("limit больше максимального: %v", err)
+	}
+	if entries, err := audit.ListAuditEntries(asPrincipal(otherID), models.AuditFilter{}, 1, 50); err != nil || len(entries) != 1 {
+		t.Fatalf("записи обычного пользователя: %d, %v; ожидалась 1", len(entries), err)
+	}
+}
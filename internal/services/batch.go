@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// Методы для пакетной загрузки связанных данных. Каждый выполняет один запрос
+// для всех ключей сразу и используется загрузчиками GraphQL, чтобы избежать N+1 запросов.
+// Сводки и стоимость учитывают только подписки, которые видит участник из ctx
+
+// UserSummaries получает сводки по подпискам пользователей
+func (s *SubscriptionService) UserSummaries(ctx context.Context, userIDs []string) (map[string]models.UserSummary, error) {
+	return s.reader(ctx).UserSummaries(userIDs)
+}
+
+// ServiceSummaries получает сводки по подпискам на сервисы
+func (s *SubscriptionService) ServiceSummaries(ctx context.Context, serviceNames []string) (map[string]models.ServiceSummary, error) {
+	return s.reader(ctx).ServiceSummaries(serviceNames)
+}
+
+// PriceHistory получает историю цен подписок
+func (s *SubscriptionService) PriceHistory(ctx context.Context, subscriptionIDs []uint) (map[uint][]models.PriceChange, error) {
+	return s.reader(ctx).PriceHistory(subscriptionIDs)
+}
+
+// SubscriptionsByUsers получает не больше limit первых подписок каждого пользователя, сгруппированные по пользователю
+func (s *SubscriptionService) SubscriptionsByUsers(ctx context.Context, userIDs []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	return s.reader(ctx).SubscriptionsByUsers(userIDs, sort, limit)
+}
+
+// SubscriptionsByServices получает не больше limit первых подписок на каждый сервис, сгруппированные по названию сервиса
+func (s *SubscriptionService) SubscriptionsByServices(ctx context.Context, serviceNames []string, sort []models.SortField, limit int) (map[string][]models.Subscription, error) {
+	return s.reader(ctx).SubscriptionsByServices(serviceNames, sort, limit)
+}
+
+// TotalCostByUsers вычисляет общую стоимость подписок по каждому пользователю
+// с теми же фильтрами и периодом from-to, что и CalculateTotalCost
+func (s *SubscriptionService) TotalCostByUsers(ctx context.Context, userIDs []string, filter models.SubscriptionFilter, from, to string) (map[string]int64, error) {
+	startDate, endDate, err := costRange(&filter, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader(ctx).TotalCostByUsers(userIDs, &filter, startDate, endDate)
+}
+
+// TotalCostByServices вычисляет общую стоимость подписок по каждому сервису
+// с теми же фильтрами и периодом from-to, что и CalculateTotalCost
+func (s *SubscriptionService) TotalCostByServices(ctx context.Context, serviceNames []string, filter models.SubscriptionFilter, from, to string) (map[string]int64, error) {
+	startDate, endDate, err := costRange(&filter, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.reader(ctx).TotalCostByServices(serviceNames, &filter, startDate, endDate)
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// MaxBulkItems максимальное количество элементов в одном массовом запросе
+const MaxBulkItems = 10000
+
+// BulkMode определяет поведение массовой операции при ошибках
+type BulkMode string
+
+const (
+	// BulkModeAtomic выполняет все элементы или ни одного
+	BulkModeAtomic BulkMode = "atomic"
+
+	// BulkModeBestEffort выполняет все корректные элементы, пропуская ошибочные
+	BulkModeBestEffort BulkMode = "best_effort"
+)
+
+// Статусы элементов массовой операции
+const (
+	BulkStatusCreated = "created"
+	BulkStatusUpdated = "updated"
+	BulkStatusDeleted = "deleted"
+	BulkStatusFailed  = "failed"
+	BulkStatusSkipped = "skipped"
+	BulkStatusValid   = "valid"
+)
+
+var (
+	// ErrBulkTooManyItems возвращается, если в запросе слишком много элементов
+	ErrBulkTooManyItems = fmt.Errorf("слишком много элементов, максимум %d", MaxBulkItems)
+
+	// ErrBulkEmpty возвращается, если в запросе нет элементов
+	ErrBulkEmpty = errors.New("нет элементов для обработки")
+
+	// ErrBulkInvalidMode возвращается для неизвестного режима массовой операции
+	ErrBulkInvalidMode = errors.New("неизвестный режим, ожидается atomic или best_effort")
+
+	// ErrBulkEmptyFilter возвращается при удалении по фильтру без условий
+	ErrBulkEmptyFilter = errors.New("фильтр удаления должен содержать хотя бы одно условие")
+)
+
+// BulkCreateItem элемент массового создания. Err содержит ошибку разбора элемента, если она была
+type BulkCreateItem struct {
+	Subscription *models.Subscription
+	Err          error
+}
+
+// BulkUpdateItem элемент массового обновления. Err содержит ошибку разбора элемента, если она была
+type BulkUpdateItem struct {
+	ID           uint
+	Subscription *models.Subscription
+	Err          error
+}
+
+// BulkItemResult результат обработки одного элемента массовой операции
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	ID     uint   `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResult результат массовой операции
+type BulkResult struct {
+	Mode      BulkMode         `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+
+	// Remaining количество подписок, которые подходят под фильтр удаления, но не поместились в запрос
+	Remaining int64 `json:"remaining,omitempty"`
+}
+
+// Applied сообщает, были ли изменения сохранены. В режиме atomic при ошибках ничего не сохраняется
+func (r *BulkResult) Applied() bool {
+	return r.Mode == BulkModeBestEffort || r.Failed == 0
+}
+
+// ParseBulkMode разбирает режим массовой операции; по умолчанию используется atomic
+func ParseBulkMode(mode string) (BulkMode, error) {
+	switch BulkMode(mode) {
+	case "", BulkModeAtomic:
+		return BulkModeAtomic, nil
+	case BulkModeBestEffort:
+		return BulkModeBestEffort, nil
+	default:
+		return "", ErrBulkInvalidMode
+	}
+}
+
+// BulkCreateSubscriptions создает подписки в одной транзакции
+func (s *SubscriptionService) BulkCreateSubscriptions(ctx context.Context, items []BulkCreateItem, mode BulkMode) (*BulkResult, error) {
+	if err := checkBulkSize(len(items)); err != nil {
+		return nil, err
+	}
+	repo, access, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newBulkResult(mode, len(items))
+
+	// Проверить элементы до обращения к базе данных
+	valid, validIndexes := validateBulkCreate(items, access, result)
+
+	// В режиме atomic ошибочные элементы отменяют всю операцию
+	if mode == BulkModeAtomic && result.Failed > 0 {
+		result.skipPending()
+		return result, nil
+	}
+
+	// Вставить корректные элементы пакетами и записать события созданных подписок
+	var errs []error
+	err = repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		var err error
+		if errs, err = repo.BulkCreate(valid, mode == BulkModeBestEffort); err != nil {
+			return err
+		}
+
+		events := make([]models.SubscriptionEvent, 0, len(valid))
+		for i, subscription := range valid {
+			if errs[i] == nil {
+				events = append(events, newEvent(models.EventSubscriptionCreated, subscription, nil))
+			}
+		}
+		return record(ctx, outbox, audit, events...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, subscription := range valid {
+		index := validIndexes[i]
+		if errs[i] != nil {
+			result.fail(index, errs[i])
+			continue
+		}
+		result.succeed(index, subscription.ID, BulkStatusCreated)
+	}
+
+	return result, nil
+}
+
+// ValidateSubscriptions проверяет элементы так же, как BulkCreateSubscriptions, но ничего не сохраняет.
+// Корректные элементы получают статус valid
+func (s *SubscriptionService) ValidateSubscriptions(ctx context.Context, items []BulkCreateItem) (*BulkResult, error) {
+	if err := checkBulkSize(len(items)); err != nil {
+		return nil, err
+	}
+
+	result := newBulkResult(BulkModeBestEffort, len(items))
+	_, validIndexes := validateBulkCreate(items, accessFrom(ctx), result)
+	for _, index := range validIndexes {
+		result.succeed(index, 0, BulkStatusValid)
+	}
+
+	return result, nil
+}
+
+// validateBulkCreate нормализует и проверяет элементы массового создания, в том числе право участника
+// создавать подписки их пользователей. Ошибочные элементы отмечаются в результате, корректные
+// возвращаются вместе с их индексами
+func validateBulkCreate(items []BulkCreateItem, access access, result *BulkResult) ([]*models.Subscription, []int) {
+	valid := make([]*models.Subscription, 0, len(items))
+	validIndexes := make([]int, 0, len(items))
+	for i, item := range items {
+		err := item.Err
+		if err == nil {
+			normalizeDates(item.Subscription)
+			err = validateSubscription(item.Subscription)
+		}
+		if err == nil {
+			err = access.checkOwner(item.Subscription.UserID)
+		}
+		if err != nil {
+			result.fail(i, err)
+			continue
+		}
+		valid = append(valid, item.Subscription)
+		validIndexes = append(validIndexes, i)
+	}
+	return valid, validIndexes
+}
+
+// BulkUpdateSubscriptions обновляет подписки в одной транзакции
+func (s *SubscriptionService) BulkUpdateSubscriptions(ctx context.Context, items []BulkUpdateItem, mode BulkMode) (*BulkResult, error) {
+	if err := checkBulkSize(len(items)); err != nil {
+		return nil, err
+	}
+	repo, access, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newBulkResult(mode, len(items))
+
+	// Проверить элементы до обращения к базе данных
+	ids := make([]uint, 0, len(items))
+	valid := make([]*models.Subscription, 0, len(items))
+	validIndexes := make([]int, 0, len(items))
+	for i, item := range items {
+		err := item.Err
+		if err == nil && item.ID == 0 {
+			err = &ValidationError{Field: "id", Message: "обязательное поле"}
+		}
+		if err == nil {
+			normalizeDates(item.Subscription)
+			err = validateSubscriptionUpdate(item.Subscription)
+		}
+		if err == nil && item.Subscription.UserID != "" {
+			err = access.checkOwner(item.Subscription.UserID)
+		}
+		if err != nil {
+			result.fail(i, err)
+			continue
+		}
+		ids = append(ids, item.ID)
+		valid = append(valid, item.Subscription)
+		validIndexes = append(validIndexes, i)
+	}
+
+	// В режиме atomic ошибочные элементы отменяют всю операцию
+	if mode == BulkModeAtomic && result.Failed > 0 {
+		result.skipPending()
+		return result, nil
+	}
+
+	// Обновить подписки и записать события обновленных
+	var errs []error
+	err = repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		changes, itemErrs, err := repo.BulkUpdate(ids, valid, mode == BulkModeBestEffort)
+		if err != nil {
+			return err
+		}
+		errs = itemErrs
+
+		var events []models.SubscriptionEvent
+		for i, change := range changes {
+			if itemErrs[i] == nil {
+				events = append(events, changeEvents(change)...)
+			}
+		}
+		return record(ctx, outbox, audit, events...)
+	})
+
+	// Ошибка элемента в режиме atomic откатила транзакцию
+	var itemErr *repository.BulkItemError
+	if errors.As(err, &itemErr) {
+		result.fail(validIndexes[itemErr.Index], itemErr.Err)
+		result.skipPending()
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		index := validIndexes[i]
+		if errs[i] != nil {
+			result.fail(index, errs[i])
+			continue
+		}
+		result.succeed(index, id, BulkStatusUpdated)
+	}
+
+	return result, nil
+}
+
+// BulkDeleteSubscriptions удаляет подписки по списку ID в одной транзакции.
+// Подписки, которые участник не видит, считаются не найденными
+func (s *SubscriptionService) BulkDeleteSubscriptions(ctx context.Context, ids []uint, mode BulkMode) (*BulkResult, error) {
+	if err := checkBulkSize(len(ids)); err != nil {
+		return nil, err
+	}
+	repo, _, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newBulkResult(mode, len(ids))
+
+	// В режиме atomic репозиторий возвращает подписки, которые были бы удалены, даже при откате
+	var deletedSubscriptions []models.Subscription
+	err = repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		var err error
+		if deletedSubscriptions, err = repo.BulkDeleteByIDs(ids, mode == BulkModeAtomic); err != nil {
+			return err
+		}
+		return record(ctx, outbox, audit, deletedEvents(deletedSubscriptions)...)
+	})
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	// Отметить удаленные подписки; остальные не найдены
+	deleted := make(map[uint]bool, len(deletedSubscriptions))
+	for _, subscription := range deletedSubscriptions {
+		deleted[subscription.ID] = true
+	}
+	for i, id := range ids {
+		if deleted[id] {
+			result.succeed(i, id, BulkStatusDeleted)
+			continue
+		}
+		result.fail(i, gorm.ErrRecordNotFound)
+		result.Results[i].ID = id
+	}
+
+	// В режиме atomic транзакция откатилась, если хотя бы одна подписка не найдена
+	if err == gorm.ErrRecordNotFound {
+		result.skipPending()
+	}
+
+	return result, nil
+}
+
+// BulkDeleteSubscriptionsByFilter удаляет подписки, подходящие под фильтр, из тех, что видит участник.
+// За один запрос удаляется не больше MaxBulkItems подписок: в режиме atomic запрос, под фильтр которого
+// подходит больше подписок, отклоняется с ErrBulkTooManyItems, а в режиме best_effort удаляются
+// MaxBulkItems подписок с наименьшими ID, и Remaining сообщает, сколько подписок осталось
+func (s *SubscriptionService) BulkDeleteSubscriptionsByFilter(ctx context.Context, filter models.SubscriptionFilter, mode BulkMode) (*BulkResult, error) {
+	if filter.IsEmpty() {
+		return nil, ErrBulkEmptyFilter
+	}
+	if err := validateFilter(&filter); err != nil {
+		return nil, err
+	}
+	if filter.AsOf != nil {
+		return nil, &ValidationError{Field: "as_of", Message: "не поддерживается при удалении"}
+	}
+	repo, _, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подписки из корзины повторно не удаляются и не учитываются
+	filter.IncludeDeleted = false
+
+	var deleted []models.Subscription
+	var remaining int64
+	err = repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		matched, err := repo.Count(&filter)
+		if err != nil {
+			return err
+		}
+		if matched > MaxBulkItems && mode == BulkModeAtomic {
+			return fmt.Errorf("%w: под фильтр подходит %d подписок, удалите их по частям в режиме best_effort", ErrBulkTooManyItems, matched)
+		}
+
+		if deleted, err = repo.BulkDeleteByFilter(&filter, MaxBulkItems); err != nil {
+			return err
+		}
+		remaining = matched - int64(len(deleted))
+		return record(ctx, outbox, audit, deletedEvents(deleted)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := newBulkResult(mode, len(deleted))
+	result.Remaining = remaining
+	for i, subscription := range deleted {
+		result.succeed(i, subscription.ID, BulkStatusDeleted)
+	}
+
+	return result, nil
+}
+
+// checkBulkSize проверяет количество элементов массовой операции
+func checkBulkSize(n int) error {
+	if n == 0 {
+		return ErrBulkEmpty
+	}
+	if n > MaxBulkItems {
+		return ErrBulkTooManyItems
+	}
+	return nil
+}
+
+// newBulkResult создает результат массовой операции на n элементов
+func newBulkResult(mode BulkMode, n int) *BulkResult {
+	results := make([]BulkItemResult, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return &BulkResult{Mode: mode, Results: results}
+}
+
+// succeed отмечает элемент как успешно обработанный
+func (r *BulkResult) succeed(index int, id uint, status string) {
+	r.Results[index].ID = id
+	r.Results[index].Status = status
+	r.Succeeded++
+}
+
+// fail отмечает элемент как ошибочный
+func (r *BulkResult) fail(index int, err error) {
+	message := err.Error()
+	if err == gorm.ErrRecordNotFound {
+		message = "подписка не найдена"
+	}
+	r.Results[index].Status = BulkStatusFailed
+	r.Results[index].Error = message
+	r.Failed++
+}
+
+// skipPending отмечает элементы без ошибок как пропущенные после отката операции
+func (r *BulkResult) skipPending() {
+	for i := range r.Results {
+		if r.Results[i].Status != BulkStatusFailed {
+			if r.Results[i].Status != "" {
+				r.Succeeded--
+			}
+			r.Results[i].Status = BulkStatusSkipped
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+// EventPublisher получает события жизненного цикла подписок из outbox.
+// Доставка выполняется не менее одного раза: после сбоя события могут прийти повторно,
+// получатели исключают повторы по ID события
+type EventPublisher interface {
+	Publish(ctx context.Context, events []models.SubscriptionEvent) error
+}
+
+// addEvents записывает события в outbox текущей транзакции
+func addEvents(outbox repository.OutboxWriter, events ...models.SubscriptionEvent) error {
+	messages := make([]models.OutboxMessage, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = models.OutboxMessage{
+			EventID:   event.ID,
+			TenantID:  event.TenantID,
+			EventType: event.Type,
+			Payload:   payload,
+			CreatedAt: event.OccurredAt,
+		}
+	}
+	return outbox.Add(messages...)
+}
+
+// NotifyEndingSoon публикует события subscription.ending_soon для подписок организации из ctx,
+// заканчивающихся в течение window, и возвращает их количество. Текущая дата определяется
+// в часовом поясе организации. О каждой дате окончания уведомляется один раз
+func (s *SubscriptionService) NotifyEndingSoon(ctx context.Context, window time.Duration) (int, error) {
+	current, ok := tenant.From(ctx)
+	if !ok {
+		return 0, tenant.ErrUnknownTenant
+	}
+
+	// Даты подписок хранятся как полночь UTC, поэтому местная дата организации переводится в UTC
+	now := time.Now().In(current.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var count int
+	err := s.repo.WithScope(repository.Scope{TenantID: current.ID}).Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, _ repository.AuditWriter) error {
+		subscriptions, err := repo.ClaimEndingSoon(today, today.Add(window))
+		if err != nil {
+			return err
+		}
+
+		events := make([]models.SubscriptionEvent, len(subscriptions))
+		for i := range subscriptions {
+			events[i] = newEvent(models.EventSubscriptionEndingSoon, &subscriptions[i], nil)
+		}
+		count = len(events)
+		return addEvents(outbox, events...)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// newEvent создает событие подписки
+func newEvent(eventType string, subscription, previous *models.Subscription) models.SubscriptionEvent {
+	return models.SubscriptionEvent{
+		ID:           newEventID(),
+		TenantID:     subscription.TenantID,
+		Type:         eventType,
+		OccurredAt:   time.Now().UTC(),
+		Subscription: subscription,
+		Previous:     previous,
+	}
+}
+
+// changeEvents создает события обновления подписки: updated и price_changed, если изменилась цена
+func changeEvents(change *repository.SubscriptionChange) []models.SubscriptionEvent {
+	events := []models.SubscriptionEvent{newEvent(models.EventSubscriptionUpdated, change.After, change.Before)}
+	if change.PriceChanged() {
+		events = append(events, newEvent(models.EventSubscriptionPriceChanged, change.After, change.Before))
+	}
+	return events
+}
+
+// deletedEvents создает события удаления подписок
+func deletedEvents(subscriptions []models.Subscription) []models.SubscriptionEvent {
+	events := make([]models.SubscriptionEvent, len(subscriptions))
+	for i := range subscriptions {
+		events[i] = newEvent(models.EventSubscriptionDeleted, &subscriptions[i], nil)
+	}
+	return events
+}
+
+// newEventID создает случайный ID события
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
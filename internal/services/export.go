@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// ExportRow строка выгрузки: подписка и ее стоимость за выбранный период
+type ExportRow struct {
+	Subscription *models.Subscription
+	Cost         int
+}
+
+// ExportSubscriptions передает в fn все подписки, подходящие под фильтр, в порядке сортировки вместе со
+// стоимостью за период from-to в формате ММ-ГГГГ. Без периода стоимость считается за весь срок подписки
+func (s *SubscriptionService) ExportSubscriptions(ctx context.Context, filter models.SubscriptionFilter, sort []models.SortField, from, to string, fn func(ExportRow) error) error {
+	if err := validateFilter(&filter); err != nil {
+		return err
+	}
+
+	// Разобрать период для расчета стоимости
+	fromDate, toDate, err := parseMonthRange(from, to)
+	if err != nil {
+		return &ValidationError{Field: "from/to", Message: err.Error()}
+	}
+
+	// Прочитать подписки курсором и вычислить стоимость каждой
+	return s.reader(ctx).AsOf(filter.AsOf).Iterate(ctx, &filter, sort, func(subscription *models.Subscription) error {
+		months := utils.MonthsInRange(subscription.StartDate, subscription.EndDate, fromDate, toDate)
+		return fn(ExportRow{Subscription: subscription, Cost: months * subscription.Price})
+	})
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIdempotencyKeyReused возвращается, если ключ повторно используется с другим телом запроса
+	ErrIdempotencyKeyReused = errors.New("ключ идемпотентности уже использован с другим запросом")
+
+	// ErrIdempotencyKeyInProgress возвращается, если запрос с этим ключом еще выполняется
+	ErrIdempotencyKeyInProgress = errors.New("запрос с этим ключом идемпотентности еще выполняется")
+)
+
+// IdempotencyService обрабатывает бизнес-логику для ключей идемпотентности
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService создает новый сервис ключей идемпотентности
+func NewIdempotencyService(repo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin резервирует ключ для нового запроса.
+// Если ключ уже завершен с тем же запросом, возвращается сохраненный ответ для повтора.
+// Если между резервированием и чтением ключ освободили, или срок хранения существующего ключа
+// истек, резервирование повторяется один раз
+func (s *IdempotencyService) Begin(tenantID, key, requestHash string) (*models.IdempotencyKey, error) {
+	for attempt := 0; ; attempt++ {
+		now := time.Now()
+		reserved, err := s.repo.Reserve(&models.IdempotencyKey{
+			Key:         key,
+			TenantID:    tenantID,
+			RequestHash: requestHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Ключ новый, запрос нужно выполнить
+		if reserved {
+			return nil, nil
+		}
+
+		// Ключ уже существует, проверить, что запрос совпадает
+		existing, err := s.repo.GetByKey(tenantID, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Просроченный ключ ждет периодической очистки, но уже не защищает от повтора
+		if existing.ExpiresAt.Before(now) && attempt == 0 {
+			if _, err := s.repo.DeleteIfExpired(tenantID, key, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if !existing.Completed {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		return existing, nil
+	}
+}
+
+// Complete сохраняет ответ для повторных запросов с тем же ключом
+func (s *IdempotencyService) Complete(tenantID, key string, statusCode int, contentType string, body []byte) error {
+	return s.repo.Complete(tenantID, key, statusCode, contentType, body)
+}
+
+// Release освобождает ключ, чтобы клиент мог повторить запрос после ошибки сервера
+func (s *IdempotencyService) Release(tenantID, key string) error {
+	return s.repo.Delete(tenantID, key)
+}
+
+// PruneExpired удаляет ключи, срок хранения которых истек
+func (s *IdempotencyService) PruneExpired() (int64, error) {
+	return s.repo.DeleteExpired(time.Now())
+}
@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+)
+
+func newIdempotencyRepository(t *testing.T) *repository.IdempotencyRepository {
+	t.Helper()
+	return repository.NewIdempotencyRepository(openDB(t))
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	repo := newIdempotencyRepository(t)
+	service := services.NewIdempotencyService(repo, time.Hour)
+
+	if existing, err := service.Begin("default", "key-1", "hash-1"); existing != nil || err != nil {
+		t.Fatalf("новый ключ: %+v, %v", existing, err)
+	}
+	if _, err := service.Begin("default", "key-1", "hash-1"); !errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("ключ в работе: %v, ожидалась ErrIdempotencyKeyInProgress", err)
+	}
+	if _, err := service.Begin("default", "key-1", "hash-2"); !errors.Is(err, services.ErrIdempotencyKeyReused) {
+		t.Fatalf("другой запрос: %v, ожидалась ErrIdempotencyKeyReused", err)
+	}
+
+	if err := service.Complete("default", "key-1", 201, "application/json", []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	existing, err := service.Begin("default", "key-1", "hash-1")
+	if err != nil || existing == nil || string(existing.ResponseBody) != `{"id":1}` {
+		t.Fatalf("повтор завершенного запроса: %+v, %v", existing, err)
+	}
+}
+
+// Просроченный ключ резервируется заново до периодической очистки
+func TestIdempotencyBeginReusesExpiredKey(t *testing.T) {
+	repo := newIdempotencyRepository(t)
+	service := services.NewIdempotencyService(repo, time.Hour)
+
+	past := time.Now().Add(-2 * time.Hour)
+	if _, err := repo.Reserve(&models.IdempotencyKey{
+		TenantID: "default", Key: "key-1", RequestHash: "old", CreatedAt: past, ExpiresAt: past.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	if existing, err := service.Begin("default", "key-1", "new"); existing != nil || err != nil {
+		t.Fatalf("просроченный ключ: %+v, %v", existing, err)
+	}
+	reserved, err := repo.GetByKey("default", "key-1")
+	if err != nil || reserved.RequestHash != "new" {
+		t.Fatalf("ключ не зарезервирован заново: %+v, %v", reserved, err)
+	}
+
+	pruned, err := service.PruneExpired()
+	if err != nil || pruned != 0 {
+		t.Fatalf("PruneExpired удалил %d действующих ключей: %v", pruned, err)
+	}
+}
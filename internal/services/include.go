@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+// LoadIncludes загружает связанные ресурсы для подписок. Каждый ресурс загружается
+// одним запросом для всех подписок сразу, чтобы избежать N+1 запросов.
+// Если asOf задан, ресурсы загружаются в состоянии на этот момент
+func (s *SubscriptionService) LoadIncludes(ctx context.Context, subscriptions []models.Subscription, include []string, asOf *time.Time) (*models.SubscriptionIncludes, error) {
+	includes := &models.SubscriptionIncludes{}
+	if len(subscriptions) == 0 {
+		return includes, nil
+	}
+
+	repo := s.reader(ctx).AsOf(asOf)
+	for _, resource := range include {
+		var err error
+		switch resource {
+		case models.IncludeUser:
+			includes.Users, err = repo.UserSummaries(uniqueValues(subscriptions, func(s *models.Subscription) string { return s.UserID }))
+		case models.IncludeService:
+			includes.Services, err = repo.ServiceSummaries(uniqueValues(subscriptions, func(s *models.Subscription) string { return s.ServiceName }))
+		case models.IncludePriceHistory:
+			ids := make([]uint, len(subscriptions))
+			for i := range subscriptions {
+				ids[i] = subscriptions[i].ID
+			}
+			includes.PriceHistory, err = repo.PriceHistory(ids)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return includes, nil
+}
+
+// uniqueValues возвращает значения поля подписок без повторов
+func uniqueValues(subscriptions []models.Subscription, field func(*models.Subscription) string) []string {
+	seen := make(map[string]bool, len(subscriptions))
+	values := make([]string, 0, len(subscriptions))
+	for i := range subscriptions {
+		value := field(&subscriptions[i])
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
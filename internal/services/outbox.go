@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+)
+
+// outboxBatchSize количество сообщений outbox, публикуемых за один проход
+const outboxBatchSize = 500
+
+// OutboxRelay публикует события из outbox всем получателям.
+// Сообщения отмечаются опубликованными, только если все получатели приняли пакет
+type OutboxRelay struct {
+	repo       *repository.OutboxRepository
+	publishers []EventPublisher
+}
+
+// NewOutboxRelay создает публикатор outbox
+func NewOutboxRelay(repo *repository.OutboxRepository, publishers ...EventPublisher) *OutboxRelay {
+	return &OutboxRelay{repo: repo, publishers: publishers}
+}
+
+// RelayPending публикует все неопубликованные события и возвращает их количество
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := r.repo.Relay(ctx, outboxBatchSize, func(messages []models.OutboxMessage) error {
+			events := make([]models.SubscriptionEvent, len(messages))
+			for i, message := range messages {
+				if err := json.Unmarshal(message.Payload, &events[i]); err != nil {
+					return fmt.Errorf("сообщение outbox %d: %w", message.ID, err)
+				}
+			}
+			for _, publisher := range r.publishers {
+				if err := publisher.Publish(ctx, events); err != nil {
+					return fmt.Errorf("публикация в %T: %w", publisher, err)
+				}
+			}
+			return nil
+		})
+		total += n
+		if err != nil || n < outboxBatchSize {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// Prune удаляет сообщения, опубликованные раньше чем retention назад
+func (r *OutboxRelay) Prune(retention time.Duration) (int64, error) {
+	return r.repo.DeletePublishedBefore(time.Now().Add(-retention))
+}
@@ -0,0 +1,124 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// flakyPublisher отклоняет первые failures пакетов и запоминает принятые события
+type flakyPublisher struct {
+	failures int
+	calls    int
+	events   []models.SubscriptionEvent
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, events []models.SubscriptionEvent) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("получатель недоступен")
+	}
+	p.events = append(p.events, events...)
+	return nil
+}
+
+// seedOutbox создает две подписки, события которых записываются в outbox
+func seedOutbox(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(db))
+	for _, name := range []string{"Netflix", "Spotify"} {
+		subscription := &models.Subscription{ServiceName: name, Price: 100, UserID: ownerID, StartDate: time.Now()}
+		if err := service.CreateSubscription(tenantContext(), subscription); err != nil {
+			t.Fatalf("создание подписки: %v", err)
+		}
+	}
+}
+
+// pendingOutbox возвращает количество неопубликованных сообщений
+func pendingOutbox(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.OutboxMessage{}).Where("published_at IS NULL").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// Неудачная публикация оставляет сообщения неопубликованными, следующий проход публикует их всем получателям
+func TestOutboxRelayRetriesFailedPublish(t *testing.T) {
+	db := openDB(t)
+	seedOutbox(t, db)
+
+	reliable := &flakyPublisher{}
+	flaky := &flakyPublisher{failures: 1}
+	relay := services.NewOutboxRelay(repository.NewOutboxRepository(db), reliable, flaky)
+
+	if n, err := relay.RelayPending(context.Background()); err == nil || n != 0 {
+		t.Fatalf("RelayPending с недоступным получателем: %d, %v", n, err)
+	}
+	if pending := pendingOutbox(t, db); pending != 2 {
+		t.Fatalf("неопубликованных сообщений %d, ожидалось 2", pending)
+	}
+
+	n, err := relay.RelayPending(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("повторная публикация: %d, %v", n, err)
+	}
+	if pending := pendingOutbox(t, db); pending != 0 {
+		t.Fatalf("неопубликованных сообщений %d, ожидалось 0", pending)
+	}
+
+	// Доставка не менее одного раза: получатель, принявший первый пакет, получает его снова
+	if len(reliable.events) != 4 || len(flaky.events) != 2 {
+		t.Fatalf("получено событий %d и %d, ожидалось 4 и 2", len(reliable.events), len(flaky.events))
+	}
+	if flaky.events[0].Type != models.EventSubscriptionCreated || flaky.events[0].Subscription.ServiceName != "Netflix" {
+		t.Fatalf("первое событие %+v", flaky.events[0])
+	}
+
+	// Опубликованные сообщения не передаются повторно
+	if n, err := relay.RelayPending(context.Background()); err != nil || n != 0 {
+		t.Fatalf("публикация без новых сообщений: %d, %v", n, err)
+	}
+}
+
+// Prune удаляет только сообщения, опубликованные раньше срока хранения
+func TestOutboxRelayPrune(t *testing.T) {
+	db := openDB(t)
+	seedOutbox(t, db)
+	relay := services.NewOutboxRelay(repository.NewOutboxRepository(db), &flakyPublisher{})
+	if _, err := relay.RelayPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	seedOutbox(t, db)
+
+	// Первое опубликованное сообщение старше срока хранения
+	var oldest models.OutboxMessage
+	if err := db.Order("id").First(&oldest).Error; err != nil {
+		t.Fatal(err)
+	}
+	err := db.Model(&oldest).Update("published_at", time.Now().Add(-48*time.Hour)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := relay.Prune(24 * time.Hour)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Prune: удалено %d, %v; ожидалось 1", deleted, err)
+	}
+
+	var remaining int64
+	if err := db.Model(&models.OutboxMessage{}).Count(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 3 || pendingOutbox(t, db) != 2 {
+		t.Fatalf("осталось %d сообщений, из них %d неопубликованных; ожидалось 3 и 2", remaining, pendingOutbox(t, db))
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+const (
+	// DefaultSearchLimit количество результатов поиска по умолчанию
+	DefaultSearchLimit = 20
+
+	// MaxSearchLimit максимальное количество результатов поиска
+	MaxSearchLimit = 100
+
+	// maxSearchQueryLength максимальная длина поискового запроса в символах
+	maxSearchQueryLength = 200
+)
+
+// SearchSubscriptions ищет подписки по названию сервиса, заметкам и тегам с допуском опечаток
+// и возвращает их по убыванию релевантности. Опечатки допускаются только в PostgreSQL,
+// в остальных хранилищах ищется подстрока без учета регистра
+func (s *SubscriptionService) SearchSubscriptions(ctx context.Context, query string, filter models.SubscriptionFilter, limit int) ([]models.SubscriptionSearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &ValidationError{Field: "q", Message: "обязательное поле"}
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, &ValidationError{Field: "q", Message: "слишком длинный запрос"}
+	}
+	if err := validateFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	// Ограничить количество результатов
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	return s.reader(ctx).AsOf(filter.AsOf).Search(query, &filter, limit)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+const (
+	// streamBufferSize количество событий, которое может накопиться у медленного получателя
+	streamBufferSize = 256
+
+	// streamBatchSize количество событий, читаемых из журнала за один запрос
+	streamBatchSize = 500
+
+	// streamMaxReconnectDelay максимальная задержка переподключения к каналу уведомлений
+	streamMaxReconnectDelay = 30 * time.Second
+)
+
+// streamedEventTypes типы событий, передаваемые в поток изменений подписок
+var streamedEventTypes = []string{
+	models.EventSubscriptionCreated,
+	models.EventSubscriptionUpdated,
+	models.EventSubscriptionDeleted,
+}
+
+// EventStream сохраняет изменения подписок в журнал событий и раздает их подключенным получателям.
+// Экземпляры сервиса узнают о новых событиях через LISTEN/NOTIFY, поэтому каждый получатель
+// видит изменения, сделанные любым экземпляром
+type EventStream struct {
+	repo *repository.EventRepository
+
+	mu          sync.Mutex
+	last        int64
+	closed      bool
+	subscribers map[*StreamSubscription]struct{}
+}
+
+// StreamSubscription подписка получателя на поток событий
+type StreamSubscription struct {
+	stream   *EventStream
+	tenantID string
+	userID   string
+	events   chan models.StoredEvent
+}
+
+// NewEventStream создает новый поток событий подписок
+func NewEventStream(repo *repository.EventRepository) *EventStream {
+	return &EventStream{
+		repo:        repo,
+		subscribers: make(map[*StreamSubscription]struct{}),
+	}
+}
+
+// Publish сохраняет события создания, обновления и удаления подписок в журнал
+func (s *EventStream) Publish(ctx context.Context, events []models.SubscriptionEvent) error {
+	stored := make([]models.StoredEvent, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(streamedEventTypes, event.Type) {
+			continue
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, models.StoredEvent{
+			EventID:  event.ID,
+			TenantID: event.TenantID,
+			Type:     event.Type,
+			UserID:   event.Subscription.UserID,
+			Payload:  payload,
+		})
+	}
+	return s.repo.Append(ctx, stored)
+}
+
+// Subscribe подключает получателя к потоку событий организации из ctx. Если userID задан, получатель
+// видит только события подписок этого пользователя; обычный пользователь из ctx всегда получает только свои события.
+// Канал событий закрывается, если получатель не успевает их читать или поток остановлен;
+// получатель может продолжить с последнего события через Replay
+func (s *EventStream) Subscribe(ctx context.Context, userID string) (*StreamSubscription, error) {
+	if userID != "" && !uuidPattern.MatchString(userID) {
+		return nil, &ValidationError{Field: "user_id", Message: "ожидается UUID"}
+	}
+	access := accessFrom(ctx)
+	if access.tenantID == "" {
+		return nil, tenant.ErrUnknownTenant
+	}
+	if access.userID != "" {
+		if userID != "" && userID != access.userID {
+			return nil, ErrForbidden
+		}
+		userID = access.userID
+	}
+
+	subscription := &StreamSubscription{
+		stream:   s,
+		tenantID: access.tenantID,
+		userID:   userID,
+		events:   make(chan models.StoredEvent, streamBufferSize),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(subscription.events)
+		return subscription, nil
+	}
+	s.subscribers[subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+// Events возвращает канал событий подписки
+func (sub *StreamSubscription) Events() <-chan models.StoredEvent {
+	return sub.events
+}
+
+// Close отключает получателя от потока
+func (sub *StreamSubscription) Close() {
+	sub.stream.mu.Lock()
+	defer sub.stream.mu.Unlock()
+	sub.stream.remove(sub)
+}
+
+// Replay получает до limit событий журнала с номером больше after, которые видит получатель
+func (sub *StreamSubscription) Replay(after int64, limit int) ([]models.StoredEvent, error) {
+	return sub.stream.repo.ListAfter(after, sub.tenantID, sub.userID, limit)
+}
+
+// Run слушает уведомления о новых событиях и раздает их получателям, пока ctx не будет отменен.
+// После переподключения к базе данных пропущенные события дочитываются из журнала
+func (s *EventStream) Run(ctx context.Context) {
+	last, err := s.repo.LastSequence()
+	for err != nil {
+		slog.Error("Не удалось прочитать журнал событий", "ошибка", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		last, err = s.repo.LastSequence()
+	}
+	s.mu.Lock()
+	s.last = last
+	s.mu.Unlock()
+
+	delay := time.Second
+	for ctx.Err() == nil {
+		err := s.repo.Listen(ctx,
+			func() {
+				delay = time.Second
+				s.catchUp()
+			},
+			s.catchUp,
+		)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("Потеряно соединение с каналом событий", "ошибка", err, "повтор через", delay.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, streamMaxReconnectDelay)
+	}
+}
+
+// Close отключает всех получателей; новые получатели сразу получают закрытый канал
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for sub := range s.subscribers {
+		s.remove(sub)
+	}
+}
+
+// Prune удаляет из журнала события старше retention
+func (s *EventStream) Prune(retention time.Duration) (int64, error) {
+	return s.repo.DeleteBefore(time.Now().Add(-retention))
+}
+
+// catchUp читает из журнала новые события и раздает их получателям
+func (s *EventStream) catchUp() {
+	for {
+		s.mu.Lock()
+		last := s.last
+		s.mu.Unlock()
+
+		events, err := s.repo.ListAllAfter(last, streamBatchSize)
+		if err != nil {
+			slog.Error("Не удалось прочитать журнал событий", "ошибка", err)
+			return
+		}
+		s.broadcast(events)
+		if len(events) < streamBatchSize {
+			return
+		}
+	}
+}
+
+// broadcast отправляет события подходящим получателям.
+// Получатель с переполненным буфером отключается, чтобы не задерживать остальных
+func (s *EventStream) broadcast(events []models.StoredEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range events {
+		for sub := range s.subscribers {
+			if sub.tenantID != event.TenantID || (sub.userID != "" && sub.userID != event.UserID) {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+				s.remove(sub)
+			}
+		}
+	}
+	s.last = events[len(events)-1].Sequence
+}
+
+// remove отключает получателя; вызывается под блокировкой
+func (s *EventStream) remove(sub *StreamSubscription) {
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"effective-mobile-subscription/internal/models"
 	"effective-mobile-subscription/internal/repository"
 	"effective-mobile-subscription/pkg/utils"
@@ -8,101 +12,276 @@ import (
 
 // SubscriptionService обрабатывает бизнес-логику для подписок
 type SubscriptionService struct {
-	repo *repository.SubscriptionRepository
+	repo repository.Subscriptions
 }
 
-// NewSubscriptionService создает новый сервис подписок
-func NewSubscriptionService(repo *repository.SubscriptionRepository) *SubscriptionService {
+// NewSubscriptionService создает новый сервис подписок над хранилищем repo: PostgreSQL, SQLite или памятью.
+// События изменений записываются в outbox и публикуются OutboxRelay
+func NewSubscriptionService(repo repository.Subscriptions) *SubscriptionService {
 	return &SubscriptionService{repo: repo}
 }
 
-// CreateSubscription создает новую подписку
-func (s *SubscriptionService) CreateSubscription(subscription *models.Subscription) error {
-	// Убедимся, что StartDate установлена на первый день месяца
-	subscription.StartDate = utils.GetFirstDayOfMonth(subscription.StartDate)
+// CreateSubscription создает новую подписку. Обычный пользователь может создавать только свои подписки
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, subscription *models.Subscription) error {
+	repo, access, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
 
-	// Если EndDate предоставлена, установить ее на последний день месяца
-	if subscription.EndDate != nil {
-		endDate := utils.GetLastDayOfMonth(*subscription.EndDate)
-		subscription.EndDate = &endDate
+	// Привести даты к границам месяца и проверить поля
+	normalizeDates(subscription)
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	if err := access.checkOwner(subscription.UserID); err != nil {
+		return err
 	}
 
-	return s.repo.Create(subscription)
+	return repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		if err := repo.Create(subscription); err != nil {
+			return err
+		}
+		return record(ctx, outbox, audit, newEvent(models.EventSubscriptionCreated, subscription, nil))
+	})
 }
 
 // GetSubscription получает подписку по ID
-func (s *SubscriptionService) GetSubscription(id uint) (*models.Subscription, error) {
-	return s.repo.GetByID(id)
+func (s *SubscriptionService) GetSubscription(ctx context.Context, id uint) (*models.Subscription, error) {
+	return s.reader(ctx).GetByID(id)
 }
 
-// UpdateSubscription обновляет существующую подписку
-func (s *SubscriptionService) UpdateSubscription(id uint, subscription *models.Subscription) error {
-	// Убедиться, что StartDate установлена на первый день месяца
-	subscription.StartDate = utils.GetFirstDayOfMonth(subscription.StartDate)
+// GetSubscriptionAsOf получает подписку по ID в состоянии на момент at
+func (s *SubscriptionService) GetSubscriptionAsOf(ctx context.Context, id uint, at time.Time) (*models.Subscription, error) {
+	return s.reader(ctx).AsOf(&at).GetByID(id)
+}
 
-	// Если EndDate предоставлена, установить ее на последний день месяца
-	if subscription.EndDate != nil {
-		endDate := utils.GetLastDayOfMonth(*subscription.EndDate)
-		subscription.EndDate = &endDate
+// UpdateSubscription обновляет существующую подписку. Обычный пользователь не может
+// передать подписку другому пользователю
+func (s *SubscriptionService) UpdateSubscription(ctx context.Context, id uint, subscription *models.Subscription) error {
+	repo, access, err := s.writer(ctx)
+	if err != nil {
+		return err
 	}
 
-	return s.repo.Update(id, subscription)
+	// Привести даты к границам месяца и проверить заполненные поля
+	normalizeDates(subscription)
+	if err := validateSubscriptionUpdate(subscription); err != nil {
+		return err
+	}
+	if subscription.UserID != "" {
+		if err := access.checkOwner(subscription.UserID); err != nil {
+			return err
+		}
+	}
+
+	return repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		change, err := repo.Update(id, subscription)
+		if err != nil {
+			return err
+		}
+		return record(ctx, outbox, audit, changeEvents(change)...)
+	})
 }
 
-// DeleteSubscription удаляет подписку по ID
-func (s *SubscriptionService) DeleteSubscription(id uint) error {
-	return s.repo.Delete(id)
+// DeleteSubscription перемещает подписку в корзину; ее можно восстановить до окончательного удаления.
+// Если подписка не найдена, возвращается gorm.ErrRecordNotFound
+func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id uint) error {
+	repo, _, err := s.writer(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		deleted, err := repo.Delete(id)
+		if err != nil {
+			return err
+		}
+		return record(ctx, outbox, audit, newEvent(models.EventSubscriptionDeleted, deleted, nil))
+	})
+}
+
+// ListDeletedSubscriptions получает страницу подписок из корзины
+func (s *SubscriptionService) ListDeletedSubscriptions(ctx context.Context, page, limit int) ([]models.Subscription, error) {
+	return s.reader(ctx).ListDeleted((page-1)*limit, limit)
+}
+
+// RestoreSubscription возвращает подписку из корзины и публикует событие ее обновления.
+// Если подписки нет в корзине, возвращается gorm.ErrRecordNotFound
+func (s *SubscriptionService) RestoreSubscription(ctx context.Context, id uint) (*models.Subscription, error) {
+	repo, _, err := s.writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored *models.Subscription
+	err = repo.Transaction(func(repo repository.Subscriptions, outbox repository.OutboxWriter, audit repository.AuditWriter) error {
+		change, err := repo.Restore(id)
+		if err != nil {
+			return err
+		}
+		restored = change.After
+		return record(ctx, outbox, audit, changeEvents(change)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
 }
 
-// ListSubscriptions получает список подписок с опциональными фильтрами и пагинацией
-func (s *SubscriptionService) ListSubscriptions(page, limit int, userID, serviceName string) ([]models.Subscription, int64, error) {
-	// Вычислить смещение для пагинации
-	offset := (page - 1) * limit
+// PurgeDeleted окончательно удаляет подписки, находящиеся в корзине дольше retention, и возвращает их количество
+func (s *SubscriptionService) PurgeDeleted(retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeleted(time.Now().Add(-retention))
+}
+
+// MaxListLimit максимальный размер страницы списка подписок
+const MaxListLimit = 100
+
+// ListParams параметры получения списка подписок.
+// Если Cursor задан, используется keyset-пагинация и Page игнорируется
+type ListParams struct {
+	Page         int
+	Limit        int
+	Cursor       string
+	IncludeTotal bool
+	Filter       models.SubscriptionFilter
+	Sort         []models.SortField
+}
+
+// ListResult страница списка подписок
+type ListResult struct {
+	Subscriptions []models.Subscription
+	// Total равен nil, если общее количество не запрашивалось
+	Total *int64
+	// NextCursor пуст, если следующей страницы нет
+	NextCursor string
+}
+
+// ListSubscriptions получает список подписок с опциональными фильтрами, сортировкой и пагинацией
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, params ListParams) (*ListResult, error) {
+	if params.Limit < 1 || params.Limit > MaxListLimit {
+		return nil, &ValidationError{Field: "limit", Message: fmt.Sprintf("допустимо от 1 до %d", MaxListLimit)}
+	}
+	if err := validateFilter(&params.Filter); err != nil {
+		return nil, err
+	}
+	repo := s.reader(ctx).AsOf(params.Filter.AsOf)
+
+	// Запросить на одну строку больше, чтобы узнать, есть ли следующая страница
+	var subscriptions []models.Subscription
+	var err error
+	if params.Cursor != "" {
+		cursor, cursorErr := repository.DecodeSubscriptionCursor(params.Cursor, params.Sort)
+		if cursorErr != nil {
+			return nil, &ValidationError{Field: "cursor", Message: cursorErr.Error()}
+		}
+		subscriptions, err = repo.ListAfter(&params.Filter, params.Sort, cursor, params.Limit+1)
+	} else {
+		// Вычислить смещение для пагинации
+		offset := (params.Page - 1) * params.Limit
+		subscriptions, err = repo.List(&params.Filter, params.Sort, offset, params.Limit+1)
+	}
+	if err == repository.ErrInvalidCursor {
+		return nil, &ValidationError{Field: "cursor", Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ListResult{Subscriptions: subscriptions}
+
+	// Сформировать курсор следующей страницы по последней возвращаемой строке
+	if len(subscriptions) > params.Limit {
+		result.Subscriptions = subscriptions[:params.Limit]
+		result.NextCursor = repository.CursorAfter(&result.Subscriptions[params.Limit-1], params.Sort).Encode()
+	}
 
-	// Получить результаты с пагинацией
-	subscriptions, err := s.repo.List(offset, limit, userID, serviceName)
+	// Получить общее количество, только если оно нужно клиенту
+	if params.IncludeTotal {
+		total, err := repo.Count(&params.Filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	return result, nil
+}
+
+// CalculateTotalCost вычисляет общую стоимость подписок, подходящих под фильтр,
+// с датой начала в периоде from-to в формате ММ-ГГГГ
+func (s *SubscriptionService) CalculateTotalCost(ctx context.Context, filter models.SubscriptionFilter, from, to string) (int, error) {
+	startDate, endDate, err := costRange(&filter, from, to)
 	if err != nil {
-		return nil, 0, err
+		return 0, err
 	}
 
-	// Получить общее количество
-	total, err := s.repo.Count(userID, serviceName)
+	// Выполнить запрос
+	totalCost, err := s.reader(ctx).AsOf(filter.AsOf).CalculateTotalCost(&filter, startDate, endDate)
 	if err != nil {
-		return nil, 0, err
+		return 0, err
 	}
 
-	return subscriptions, total, nil
+	return int(totalCost), nil
 }
 
-// CalculateTotalCost вычисляет общую стоимость подписок с опциональными фильтрами
-func (s *SubscriptionService) CalculateTotalCost(userID, serviceName, from, to string) (int, error) {
+// costRange проверяет фильтр и разбирает период расчета стоимости.
+// Незаданные границы возвращаются как nil, чтобы репозиторий их не применял
+func costRange(filter *models.SubscriptionFilter, from, to string) (any, any, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, nil, err
+	}
+
 	// Применить фильтры по диапазону дат
+	fromDate, toDate, err := parseMonthRange(from, to)
+	if err != nil {
+		return nil, nil, &ValidationError{Field: "from/to", Message: err.Error()}
+	}
+
 	var startDate, endDate any
+	if fromDate != nil {
+		startDate = *fromDate
+	}
+	if toDate != nil {
+		endDate = *toDate
+	}
+
+	return startDate, endDate, nil
+}
+
+// normalizeDates устанавливает StartDate на первый день месяца, а EndDate - на последний
+func normalizeDates(subscription *models.Subscription) {
+	subscription.StartDate = utils.GetFirstDayOfMonth(subscription.StartDate)
+
+	if subscription.EndDate != nil {
+		endDate := utils.GetLastDayOfMonth(*subscription.EndDate)
+		subscription.EndDate = &endDate
+	}
+}
+
+// parseMonthRange разбирает границы периода в формате ММ-ГГГГ.
+// from устанавливается на первый день месяца, to - на последний; пустые строки дают nil
+func parseMonthRange(from, to string) (*time.Time, *time.Time, error) {
+	var fromDate, toDate *time.Time
+
 	if from != "" {
-		fromDate, err := utils.ParseMonthYear(from)
+		date, err := utils.ParseMonthYear(from)
 		if err != nil {
-			return 0, err
+			return nil, nil, err
 		}
 		// Установить на первый день месяца
-		fromDate = utils.GetFirstDayOfMonth(fromDate)
-		startDate = fromDate
+		date = utils.GetFirstDayOfMonth(date)
+		fromDate = &date
 	}
 
 	if to != "" {
-		toDate, err := utils.ParseMonthYear(to)
+		date, err := utils.ParseMonthYear(to)
 		if err != nil {
-			return 0, err
+			return nil, nil, err
 		}
 		// Установить на последний день месяца
-		toDate = utils.GetLastDayOfMonth(toDate)
-		endDate = toDate
+		date = utils.GetLastDayOfMonth(date)
+		toDate = &date
 	}
 
-	// Выполнить запрос
-	totalCost, err := s.repo.CalculateTotalCost(userID, serviceName, startDate, endDate)
-	if err != nil {
-		return 0, err
-	}
-
-	return int(totalCost), nil
+	return fromDate, toDate, nil
 }
@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/internal/tenant"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openDB открывает пустую базу в памяти со всеми таблицами
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(&config.Config{DBDriver: database.DriverMemory}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("открытие базы данных: %v", err)
+	}
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// tenantContext контекст запроса организации по умолчанию без участника
+func tenantContext() context.Context {
+	return tenant.WithTenant(context.Background(), &models.Tenant{ID: models.DefaultTenantID})
+}
+
+func TestListSubscriptionsLimit(t *testing.T) {
+	service := services.NewSubscriptionService(repository.NewSubscriptionRepository(openDB(t)))
+
+	tests := []struct {
+		name  string
+		limit int
+		valid bool
+	}{
+		{"ноль", 0, false},
+		{"отрицательный", -1, false},
+		{"минимальный", 1, true},
+		{"максимальный", services.MaxListLimit, true},
+		{"больше максимального", services.MaxListLimit + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ListSubscriptions(tenantContext(), services.ListParams{Page: 1, Limit: tt.limit})
+			if tt.valid && err != nil {
+				t.Fatalf("limit=%d: %v", tt.limit, err)
+			}
+			if !tt.valid && !services.IsValidationError(err) {
+				t.Fatalf("limit=%d: %v, ожидалась ошибка проверки", tt.limit, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+var (
+	// tenantIDPattern проверяет ID организации: строчные латинские буквы, цифры и дефисы
+	tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+	// currencyPattern проверяет код валюты ISO 4217
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// TenantService управляет организациями и их настройками
+type TenantService struct {
+	repo *repository.TenantRepository
+}
+
+// NewTenantService создает новый сервис организаций
+func NewTenantService(repo *repository.TenantRepository) *TenantService {
+	return &TenantService{repo: repo}
+}
+
+// CreateTenant регистрирует организацию. Незаданные валюта и часовой пояс заполняются значениями по умолчанию
+func (s *TenantService) CreateTenant(t *models.Tenant) error {
+	if !tenantIDPattern.MatchString(t.ID) {
+		return &ValidationError{Field: "id", Message: "ожидаются строчные латинские буквы, цифры и дефисы, до 64 символов"}
+	}
+	if t.Name == "" {
+		return &ValidationError{Field: "name", Message: "обязательное поле"}
+	}
+	if t.Currency == "" {
+		t.Currency = models.DefaultTenantCurrency
+	}
+	if t.Timezone == "" {
+		t.Timezone = models.DefaultTenantTimezone
+	}
+	if err := validateTenantSettings(t); err != nil {
+		return err
+	}
+
+	return s.repo.Create(t)
+}
+
+// GetTenant получает организацию по ID
+func (s *TenantService) GetTenant(id string) (*models.Tenant, error) {
+	return s.repo.GetByID(id)
+}
+
+// ListTenants получает все организации
+func (s *TenantService) ListTenants() ([]models.Tenant, error) {
+	return s.repo.List()
+}
+
+// ForEachTenant выполняет fn в контексте каждой организации, например для фоновых задач.
+// Ошибка fn для одной организации не останавливает обработку остальных и возвращается в конце
+func (s *TenantService) ForEachTenant(ctx context.Context, fn func(ctx context.Context) error) error {
+	tenants, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range tenants {
+		if err := fn(tenant.WithTenant(ctx, &tenants[i])); err != nil {
+			errs = append(errs, fmt.Errorf("организация %s: %w", tenants[i].ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CurrentTenant возвращает организацию запроса
+func (s *TenantService) CurrentTenant(ctx context.Context) (*models.Tenant, error) {
+	current, ok := tenant.From(ctx)
+	if !ok {
+		return nil, tenant.ErrUnknownTenant
+	}
+	return current, nil
+}
+
+// UpdateSettings изменяет валюту и часовой пояс организации запроса; пустые значения не изменяются
+func (s *TenantService) UpdateSettings(ctx context.Context, currency, timezone string) (*models.Tenant, error) {
+	current, err := s.CurrentTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *current
+	if currency != "" {
+		updated.Currency = currency
+	}
+	if timezone != "" {
+		updated.Timezone = timezone
+	}
+	if err := validateTenantSettings(&updated); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSettings(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// validateTenantSettings проверяет валюту и часовой пояс организации
+func validateTenantSettings(t *models.Tenant) error {
+	if !currencyPattern.MatchString(t.Currency) {
+		return &ValidationError{Field: "currency", Message: "ожидается код валюты ISO 4217, например RUB"}
+	}
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return &ValidationError{Field: "timezone", Message: "неизвестный часовой пояс"}
+	}
+	return nil
+}
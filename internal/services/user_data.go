@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// UserDataService выгружает и обезличивает все данные пользователя по запросу субъекта данных
+type UserDataService struct {
+	repo repository.Subscriptions
+}
+
+// NewUserDataService создает новый сервис данных пользователя над хранилищем подписок repo,
+// общим с сервисом подписок
+func NewUserDataService(repo repository.Subscriptions) *UserDataService {
+	return &UserDataService{repo: repo}
+}
+
+// ExportUserData получает все данные пользователя userID в организации запроса.
+// Доступно администратору и самому пользователю
+func (s *UserDataService) ExportUserData(ctx context.Context, userID string) (*models.UserData, error) {
+	access, err := userDataAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.WithScope(repository.Scope{TenantID: access.tenantID}).UserData(userID)
+}
+
+// EraseUserData обезличивает все данные пользователя userID в организации запроса, сохраняя статистику стоимости.
+// Доступно администратору и самому пользователю
+func (s *UserDataService) EraseUserData(ctx context.Context, userID string) (*models.UserErasure, error) {
+	access, err := userDataAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	erasure, err := s.repo.WithScope(repository.Scope{TenantID: access.tenantID}).AnonymizeUser(userID, uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
+	// ID пользователя не пишется в журнал, чтобы не сохранить его после обезличивания
+	slog.Info("Данные пользователя обезличены",
+		"организация", access.tenantID,
+		"псевдоним", erasure.Pseudonym,
+		"подписок", erasure.Subscriptions,
+		"записей аудита", erasure.AuditEntries,
+	)
+	return erasure, nil
+}
+
+// userDataAccess проверяет ID пользователя и права участника на его данные.
+// Аналитик только читает отчеты и не получает доступа к личным данным
+func userDataAccess(ctx context.Context, userID string) (access, error) {
+	if !uuidPattern.MatchString(userID) {
+		return access{}, &ValidationError{Field: "user_id", Message: "ожидается UUID"}
+	}
+	a := accessFrom(ctx)
+	if err := a.checkWrite(); err != nil {
+		return a, err
+	}
+	return a, a.checkOwner(userID)
+}
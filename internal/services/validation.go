@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"unicode/utf8"
+
+	"effective-mobile-subscription/internal/models"
+)
+
+const (
+	// maxNotesLength максимальная длина заметок в символах
+	maxNotesLength = 2000
+
+	// maxTags максимальное количество тегов подписки
+	maxTags = 20
+
+	// maxTagLength максимальная длина тега в символах
+	maxTagLength = 50
+)
+
+// uuidPattern проверяет строковое представление UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidationError описывает ошибку проверки данных подписки
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// Error возвращает текст ошибки проверки
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// IsValidationError сообщает, является ли ошибка ошибкой проверки данных
+func IsValidationError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr)
+}
+
+// validateSubscription проверяет поля новой подписки
+func validateSubscription(subscription *models.Subscription) error {
+	if subscription.ServiceName == "" {
+		return &ValidationError{Field: "service_name", Message: "обязательное поле"}
+	}
+	if subscription.UserID == "" {
+		return &ValidationError{Field: "user_id", Message: "обязательное поле"}
+	}
+	if subscription.StartDate.IsZero() {
+		return &ValidationError{Field: "start_date", Message: "обязательное поле"}
+	}
+	return validateSubscriptionUpdate(subscription)
+}
+
+// validateSubscriptionUpdate проверяет только заполненные поля подписки
+func validateSubscriptionUpdate(subscription *models.Subscription) error {
+	if subscription.Price < 0 {
+		return &ValidationError{Field: "price", Message: "цена не может быть отрицательной"}
+	}
+	if subscription.UserID != "" && !uuidPattern.MatchString(subscription.UserID) {
+		return &ValidationError{Field: "user_id", Message: "ожидается UUID"}
+	}
+	if subscription.EndDate != nil && !subscription.StartDate.IsZero() && subscription.EndDate.Before(subscription.StartDate) {
+		return &ValidationError{Field: "end_date", Message: "дата окончания раньше даты начала"}
+	}
+	if utf8.RuneCountInString(subscription.Notes) > maxNotesLength {
+		return &ValidationError{Field: "notes", Message: "слишком длинные заметки"}
+	}
+	if len(subscription.Tags) > maxTags {
+		return &ValidationError{Field: "tags", Message: "слишком много тегов"}
+	}
+	for _, tag := range subscription.Tags {
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return &ValidationError{Field: "tags", Message: "тег должен быть непустым и не длиннее 50 символов"}
+		}
+	}
+	return nil
+}
+
+// validateFilter проверяет условия фильтра подписок
+func validateFilter(filter *models.SubscriptionFilter) error {
+	for _, userID := range filter.UserIDs {
+		if !uuidPattern.MatchString(userID) {
+			return &ValidationError{Field: "user_id", Message: "ожидается UUID"}
+		}
+	}
+	if filter.PriceMin != nil && filter.PriceMax != nil && *filter.PriceMin > *filter.PriceMax {
+		return &ValidationError{Field: "price_min", Message: "больше price_max"}
+	}
+	if filter.StartFrom != nil && filter.StartTo != nil && filter.StartFrom.After(*filter.StartTo) {
+		return &ValidationError{Field: "start_from", Message: "позже start_to"}
+	}
+	if filter.EndFrom != nil && filter.EndTo != nil && filter.EndFrom.After(*filter.EndTo) {
+		return &ValidationError{Field: "end_from", Message: "позже end_to"}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/tenant"
+)
+
+const (
+	// Заголовки запроса доставки вебхука
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+
+	// webhookBatchSize количество доставок, захватываемых за один проход
+	webhookBatchSize = 50
+
+	// webhookBaseBackoff задержка перед первым повтором; каждая следующая вдвое больше
+	webhookBaseBackoff = 30 * time.Second
+
+	// webhookMaxBackoff максимальная задержка между попытками
+	webhookMaxBackoff = time.Hour
+
+	// maxWebhookErrorLength максимальная длина сохраняемой ошибки доставки
+	maxWebhookErrorLength = 500
+)
+
+// WebhookService управляет вебхуками и доставляет им события подписок
+type WebhookService struct {
+	repo        *repository.WebhookRepository
+	client      *http.Client
+	timeout     time.Duration
+	maxAttempts int
+}
+
+// NewWebhookService создает новый сервис вебхуков.
+// timeout ограничивает одну попытку доставки, после maxAttempts неудачных попыток доставка прекращается
+func NewWebhookService(repo *repository.WebhookRepository, timeout time.Duration, maxAttempts int) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		client:      &http.Client{Timeout: timeout},
+		timeout:     timeout,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// CreateWebhook регистрирует вебхук организации из ctx. Если секрет не задан, он генерируется
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	tenantID := tenant.IDFrom(ctx)
+	if tenantID == "" {
+		return tenant.ErrUnknownTenant
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	webhook.TenantID = tenantID
+	if webhook.Secret == "" {
+		webhook.Secret = newWebhookSecret()
+	}
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+	webhook.Active = true
+
+	return s.repo.Create(webhook)
+}
+
+// GetWebhook получает вебхук организации из ctx по ID
+func (s *WebhookService) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	return s.repo.GetByID(tenant.IDFrom(ctx), id)
+}
+
+// ListWebhooks получает все вебхуки организации из ctx
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.repo.List(tenant.IDFrom(ctx))
+}
+
+// DeleteWebhook удаляет вебхук организации из ctx вместе с историей доставок
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	return s.repo.Delete(tenant.IDFrom(ctx), id)
+}
+
+// ListDeliveries получает доставки вебхука, начиная с последних.
+// Если вебхук не найден, возвращается gorm.ErrRecordNotFound
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID uint, status string, page, limit int) ([]models.WebhookDelivery, error) {
+	switch status {
+	case "", models.DeliveryStatusPending, models.DeliveryStatusSucceeded, models.DeliveryStatusFailed:
+	default:
+		return nil, &ValidationError{Field: "status", Message: "ожидается pending, succeeded или failed"}
+	}
+	tenantID := tenant.IDFrom(ctx)
+	if _, err := s.repo.GetByID(tenantID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(tenantID, webhookID, status, (page-1)*limit, limit)
+}
+
+// Publish ставит события в очередь доставки всем подходящим активным вебхукам организации события
+func (s *WebhookService) Publish(ctx context.Context, events []models.SubscriptionEvent) error {
+	var tenantIDs, eventTypes []string
+	for _, event := range events {
+		if !slices.Contains(tenantIDs, event.TenantID) {
+			tenantIDs = append(tenantIDs, event.TenantID)
+		}
+		if !slices.Contains(eventTypes, event.Type) {
+			eventTypes = append(eventTypes, event.Type)
+		}
+	}
+
+	webhooks, err := s.repo.ListActiveFor(ctx, tenantIDs, eventTypes)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			if webhook.TenantID != event.TenantID || !webhook.Subscribed(event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				TenantID:      webhook.TenantID,
+				WebhookID:     webhook.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Payload:       payload,
+				Status:        models.DeliveryStatusPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// DispatchDue отправляет доставки, время попытки которых наступило, и возвращает их количество.
+// Очередь обрабатывается пакетами, пока в ней есть готовые доставки.
+// Неудачные доставки планируются повторно с экспоненциальной задержкой
+func (s *WebhookService) DispatchDue(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := s.dispatchBatch(ctx)
+		total += n
+		if err != nil || n < webhookBatchSize {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// dispatchBatch захватывает и отправляет один пакет доставок
+func (s *WebhookService) dispatchBatch(ctx context.Context) (int, error) {
+	// Захватить доставки на время, заведомо большее таймаута всех попыток пакета
+	lease := s.timeout*webhookBatchSize + time.Minute
+	deliveries, err := s.repo.ClaimDueDeliveries(time.Now(), lease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			// Оставшиеся доставки будут повторены после истечения захвата
+			return i, ctx.Err()
+		}
+		delivery := &deliveries[i]
+		s.deliver(ctx, delivery)
+		if err := s.repo.SaveAttempt(delivery); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver выполняет одну попытку доставки и записывает ее результат в delivery
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+	delivery.LastError = ""
+
+	// Вебхук удален после захвата доставки
+	if delivery.Webhook == nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.LastError = "вебхук не найден"
+		return
+	}
+
+	status, err := s.send(ctx, delivery)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if err == nil {
+		delivery.Status = models.DeliveryStatusSucceeded
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), maxWebhookErrorLength)
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = models.DeliveryStatusFailed
+		slog.Warn("Доставка вебхука прекращена", "доставка", delivery.ID, "вебхук", delivery.WebhookID, "ошибка", err)
+		return
+	}
+	delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+}
+
+// send отправляет подписанный запрос вебхуку и возвращает HTTP статус ответа
+func (s *WebhookService) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Webhook.Secret, timestamp, delivery.Payload))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("получатель ответил статусом %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload вычисляет подпись доставки: "sha256=" и HMAC-SHA256 от
+// строки "<timestamp>.<тело запроса>" в шестнадцатеричном виде.
+// Получатель проверяет подпись и отклоняет запросы со старой меткой времени
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff возвращает задержку перед следующей попыткой после attempts неудачных попыток
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// validateWebhook проверяет адрес и типы событий вебхука
+func validateWebhook(webhook *models.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return &ValidationError{Field: "url", Message: "ожидается абсолютный http или https адрес"}
+	}
+	for _, eventType := range webhook.EventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return &ValidationError{Field: "event_types", Message: fmt.Sprintf("неизвестный тип события %q", eventType)}
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret создает случайный секрет вебхука
+func newWebhookSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+// truncate обрезает строку до n байт, не разрывая символы
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,197 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/internal/models"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/services"
+
+	"gorm.io/gorm"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := services.SignWebhookPayload("whsec", "1700000000", []byte(`{"id":"evt_1"}`))
+	want := "sha256=e7e846cdb96220c3674ade89e534304fc91f6f15064facee1e7a7096f5f57f62"
+	if got != want {
+		t.Fatalf("подпись = %s, ожидалась %s", got, want)
+	}
+	if other := services.SignWebhookPayload("whsec", "1700000001", []byte(`{"id":"evt_1"}`)); other == want {
+		t.Fatal("подпись не зависит от метки времени")
+	}
+}
+
+// webhookFixture вебхук с одной доставкой, ожидающей отправки на адрес url
+type webhookFixture struct {
+	db      *gorm.DB
+	service *services.WebhookService
+	webhook *models.Webhook
+}
+
+func newWebhookFixture(t *testing.T, url string, timeout time.Duration, maxAttempts int) *webhookFixture {
+	t.Helper()
+	db := openDB(t)
+	f := &webhookFixture{
+		db:      db,
+		service: services.NewWebhookService(repository.NewWebhookRepository(db), timeout, maxAttempts),
+		webhook: &models.Webhook{URL: url, Secret: "whsec"},
+	}
+	if err := f.service.CreateWebhook(tenantContext(), f.webhook); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	event := models.SubscriptionEvent{
+		ID:           "evt_1",
+		TenantID:     models.DefaultTenantID,
+		Type:         models.EventSubscriptionCreated,
+		OccurredAt:   time.Now(),
+		Subscription: &models.Subscription{ID: 1, ServiceName: "Netflix", Price: 990, UserID: ownerID},
+	}
+	if err := f.service.Publish(context.Background(), []models.SubscriptionEvent{event}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return f
+}
+
+// dispatch отправляет готовые доставки и возвращает единственную доставку вебхука
+func (f *webhookFixture) dispatch(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+	if _, err := f.service.DispatchDue(context.Background()); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	deliveries, err := f.service.ListDeliveries(tenantContext(), f.webhook.ID, "", 1, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries: %d доставок, %v", len(deliveries), err)
+	}
+	return deliveries[0]
+}
+
+// makeDue переносит время следующей попытки в прошлое, чтобы не ждать задержки повтора
+func (f *webhookFixture) makeDue(t *testing.T) {
+	t.Helper()
+	err := f.db.Model(&models.WebhookDelivery{}).Where("status = ?", models.DeliveryStatusPending).
+		Update("next_attempt_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchDueSignsRequest(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	f := newWebhookFixture(t, server.URL, time.Second, 3)
+	delivery := f.dispatch(t)
+	if delivery.Status != models.DeliveryStatusSucceeded || delivery.Attempts != 1 {
+		t.Fatalf("доставка: статус %s, попыток %d", delivery.Status, delivery.Attempts)
+	}
+
+	r, body := <-requests, <-bodies
+	signature := services.SignWebhookPayload("whsec", r.Header.Get(services.WebhookTimestampHeader), body)
+	if r.Header.Get(services.WebhookSignatureHeader) != signature {
+		t.Fatalf("подпись %q, ожидалась %q", r.Header.Get(services.WebhookSignatureHeader), signature)
+	}
+	if r.Header.Get(services.WebhookEventHeader) != models.EventSubscriptionCreated {
+		t.Fatalf("тип события %q", r.Header.Get(services.WebhookEventHeader))
+	}
+	if r.Header.Get(services.WebhookDeliveryHeader) == "" || r.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("заголовки %v", r.Header)
+	}
+}
+
+// Ответ 5xx оставляет доставку в очереди с задержкой повтора, следующая попытка успешна
+func TestDispatchDueRetriesServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	f := newWebhookFixture(t, server.URL, time.Second, 3)
+	delivery := f.dispatch(t)
+	if delivery.Status != models.DeliveryStatusPending || delivery.Attempts != 1 ||
+		delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("после 503: %+v", delivery)
+	}
+	if wait := time.Until(delivery.NextAttemptAt); wait < 25*time.Second || wait > 30*time.Second {
+		t.Fatalf("следующая попытка через %v, ожидалось 30s", wait)
+	}
+
+	// До наступления времени повтора доставка не отправляется
+	if n, err := f.service.DispatchDue(context.Background()); n != 0 || err != nil {
+		t.Fatalf("DispatchDue до повтора: %d, %v", n, err)
+	}
+
+	f.makeDue(t)
+	delivery = f.dispatch(t)
+	if delivery.Status != models.DeliveryStatusSucceeded || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Fatalf("после повтора: %+v", delivery)
+	}
+}
+
+// Получатель, не ответивший за таймаут, считается недоступным
+func TestDispatchDueRetriesTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	f := newWebhookFixture(t, server.URL, 50*time.Millisecond, 3)
+	delivery := f.dispatch(t)
+	if delivery.Status != models.DeliveryStatusPending || delivery.Attempts != 1 ||
+		delivery.ResponseStatus != nil || delivery.LastError == "" {
+		t.Fatalf("после таймаута: %+v", delivery)
+	}
+}
+
+// После maxAttempts неудачных попыток доставка прекращается
+func TestDispatchDueGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	const maxAttempts = 3
+	f := newWebhookFixture(t, server.URL, time.Second, maxAttempts)
+	var delivery models.WebhookDelivery
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		f.makeDue(t)
+		delivery = f.dispatch(t)
+		if delivery.Attempts != attempt {
+			t.Fatalf("попыток %d, ожидалось %d", delivery.Attempts, attempt)
+		}
+	}
+	if delivery.Status != models.DeliveryStatusFailed {
+		t.Fatalf("статус после %d попыток: %s", maxAttempts, delivery.Status)
+	}
+
+	// Прекращенная доставка больше не отправляется
+	f.makeDue(t)
+	if n, err := f.service.DispatchDue(context.Background()); n != 0 || err != nil {
+		t.Fatalf("DispatchDue после отказа: %d, %v", n, err)
+	}
+	if calls.Load() != maxAttempts {
+		t.Fatalf("запросов %d, ожидалось %d", calls.Load(), maxAttempts)
+	}
+}
@@ -0,0 +1,39 @@
+package tenant
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"effective-mobile-subscription/internal/auth"
+)
+
+// Middleware определяет организацию запроса по учетным данным или заголовку header
+// и помещает ее в контекст. Запросы к неизвестной или чужой организации отклоняются.
+// Открытые пути publicPaths не относятся к организации и пропускаются без проверки
+func Middleware(resolver *Resolver, header string, publicPaths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.IsPublic(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant, err := resolver.Resolve(r.Context(), r.Header.Get(header))
+			switch {
+			case errors.Is(err, ErrTenantMismatch):
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			case errors.Is(err, ErrUnknownTenant):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			case err != nil:
+				slog.Error("Не удалось определить организацию", "ошибка", err)
+				http.Error(w, "Не удалось определить организацию", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package tenant определяет организацию запроса и передает ее через контекст.
+// Все данные сервиса принадлежат организациям, запросы видят только данные своей организации
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownTenant возвращается, если организация не зарегистрирована
+	ErrUnknownTenant = errors.New("неизвестная организация")
+
+	// ErrTenantMismatch возвращается, если запрошена организация, к которой у участника нет доступа
+	ErrTenantMismatch = errors.New("учетные данные не дают доступа к этой организации")
+)
+
+// Lookup загружает настройки организации по ID
+type Lookup interface {
+	GetTenant(id string) (*models.Tenant, error)
+}
+
+// Resolver определяет организацию запроса
+type Resolver struct {
+	tenants Lookup
+}
+
+// NewResolver создает новый определитель организации
+func NewResolver(tenants Lookup) *Resolver {
+	return &Resolver{tenants: tenants}
+}
+
+// Resolve определяет организацию запроса. Организация аутентифицированного участника берется
+// из его учетных данных, requested (например, из заголовка) должен с ней совпадать.
+// Без аутентификации используется requested. Участник без организации и запрос без requested
+// относятся к организации по умолчанию
+func (r *Resolver) Resolve(ctx context.Context, requested string) (*models.Tenant, error) {
+	id := requested
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		id = principal.TenantID
+		if id == "" {
+			id = models.DefaultTenantID
+		}
+		if requested != "" && requested != id {
+			return nil, ErrTenantMismatch
+		}
+	}
+	if id == "" {
+		id = models.DefaultTenantID
+	}
+
+	tenant, err := r.tenants.GetTenant(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownTenant
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// tenantKey ключ организации в контексте
+type tenantKey struct{}
+
+// WithTenant возвращает контекст с организацией запроса
+func WithTenant(ctx context.Context, tenant *models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// From возвращает организацию запроса из контекста
+func From(ctx context.Context) (*models.Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(*models.Tenant)
+	return tenant, ok
+}
+
+// IDFrom возвращает ID организации запроса; пустую строку, если организация не определена
+func IDFrom(ctx context.Context) string {
+	if tenant, ok := From(ctx); ok {
+		return tenant.ID
+	}
+	return ""
+}
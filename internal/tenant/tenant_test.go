@@ -0,0 +1,107 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"effective-mobile-subscription/internal/auth"
+	"effective-mobile-subscription/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// tenants организации, известные проверкам
+type tenants map[string]*models.Tenant
+
+func (t tenants) GetTenant(id string) (*models.Tenant, error) {
+	if tenant, ok := t[id]; ok {
+		return tenant, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newTestResolver() *Resolver {
+	return NewResolver(tenants{
+		models.DefaultTenantID: {ID: models.DefaultTenantID},
+		"acme":                 {ID: "acme"},
+		"globex":               {ID: "globex"},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		requested string
+		want      string
+		err       error
+	}{
+		{name: "без аутентификации и заголовка", want: models.DefaultTenantID},
+		{name: "без аутентификации по заголовку", requested: "acme", want: "acme"},
+		{name: "без аутентификации неизвестная организация", requested: "initech", err: ErrUnknownTenant},
+		{name: "организация учетных данных", principal: &auth.Principal{TenantID: "acme"}, want: "acme"},
+		{name: "заголовок совпадает с учетными данными", principal: &auth.Principal{TenantID: "acme"}, requested: "acme", want: "acme"},
+		{name: "заголовок чужой организации", principal: &auth.Principal{TenantID: "acme"}, requested: "globex", err: ErrTenantMismatch},
+		{name: "учетные данные без организации", principal: &auth.Principal{}, want: models.DefaultTenantID},
+		{name: "учетные данные без организации и чужой заголовок", principal: &auth.Principal{}, requested: "acme", err: ErrTenantMismatch},
+		{name: "администратор не выходит за свою организацию", principal: &auth.Principal{TenantID: "acme", Roles: []string{auth.RoleAdmin}}, requested: "globex", err: ErrTenantMismatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			if c.principal != nil {
+				ctx = auth.WithPrincipal(ctx, c.principal)
+			}
+
+			tenant, err := newTestResolver().Resolve(ctx, c.requested)
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("ожидалась ошибка %v, получено %v, %v", c.err, tenant, err)
+				}
+				return
+			}
+			if err != nil || tenant.ID != c.want {
+				t.Fatalf("Resolve вернул %v, %v, ожидалась организация %s", tenant, err, c.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		path      string
+		header    string
+		status    int
+		tenant    string
+	}{
+		{name: "организация из заголовка", path: "/api/v1/subscriptions", header: "acme", status: http.StatusOK, tenant: "acme"},
+		{name: "чужая организация", principal: &auth.Principal{TenantID: "acme"}, path: "/api/v1/subscriptions", header: "globex", status: http.StatusForbidden},
+		{name: "неизвестная организация", path: "/api/v1/subscriptions", header: "initech", status: http.StatusBadRequest},
+		{name: "открытый путь без организации", path: "/health", header: "initech", status: http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got string
+			handler := Middleware(newTestResolver(), "X-Tenant-ID", []string{"/health"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = IDFrom(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, c.path, nil)
+			r.Header.Set("X-Tenant-ID", c.header)
+			if c.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), c.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != c.status || got != c.tenant {
+				t.Fatalf("ответ %d, организация %q, ожидалось %d и %q", w.Code, got, c.status, c.tenant)
+			}
+		})
+	}
+}
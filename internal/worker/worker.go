@@ -0,0 +1,28 @@
+// Package worker запускает периодические фоновые задачи сервиса
+package worker
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// Every вызывает fn сразу и затем каждые interval, пока ctx не будет отменен.
+// Ошибки fn записываются в журнал и не останавливают задачу
+func Every(ctx context.Context, interval time.Duration, name string, logger *utils.Logger, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Ошибка фоновой задачи", "задача", name, "ошибка", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// BulkMode режим массовой операции
+type BulkMode string
+
+const (
+	// BulkModeAtomic применяет все элементы или ни одного (по умолчанию)
+	BulkModeAtomic BulkMode = "atomic"
+
+	// BulkModeBestEffort применяет все корректные элементы
+	BulkModeBestEffort BulkMode = "best_effort"
+)
+
+// Статусы элементов массовой операции
+const (
+	BulkStatusCreated = "created"
+	BulkStatusUpdated = "updated"
+	BulkStatusDeleted = "deleted"
+	BulkStatusFailed  = "failed"
+	BulkStatusSkipped = "skipped"
+)
+
+// BulkItemResult результат обработки одного элемента
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	ID     uint   `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkResult результат массовой операции
+type BulkResult struct {
+	Mode      BulkMode         `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+
+	// Remaining количество подписок, подходящих под фильтр удаления, которые не поместились в запрос.
+	// В режиме best_effort запрос можно повторить, пока Remaining не станет 0
+	Remaining int64 `json:"remaining,omitempty"`
+}
+
+// BulkUpdateItem элемент массового обновления
+type BulkUpdateItem struct {
+	ID uint `json:"id"`
+	UpdateSubscriptionRequest
+}
+
+// BulkDeleteRequest запрос на массовое удаление: по списку ID или по фильтру
+type BulkDeleteRequest struct {
+	Mode   BulkMode
+	IDs    []uint
+	Filter *Filter
+}
+
+// BulkCreateSubscriptions создает несколько подписок в одной транзакции.
+// Если атомарная операция откатилась, возвращается результат по элементам вместе с ошибкой ErrUnprocessable
+func (c *Client) BulkCreateSubscriptions(ctx context.Context, mode BulkMode, items []CreateSubscriptionRequest) (*BulkResult, error) {
+	return c.bulk(ctx, http.MethodPost, map[string]any{"mode": mode, "items": items})
+}
+
+// BulkUpdateSubscriptions обновляет несколько подписок в одной транзакции
+func (c *Client) BulkUpdateSubscriptions(ctx context.Context, mode BulkMode, items []BulkUpdateItem) (*BulkResult, error) {
+	return c.bulk(ctx, http.MethodPatch, map[string]any{"mode": mode, "items": items})
+}
+
+// BulkDeleteSubscriptions удаляет подписки по списку ID или подписки, подходящие под фильтр.
+// По фильтру за один запрос удаляется не больше 10000 подписок, см. BulkResult.Remaining
+func (c *Client) BulkDeleteSubscriptions(ctx context.Context, req *BulkDeleteRequest) (*BulkResult, error) {
+	if req == nil {
+		return nil, errNilRequest
+	}
+
+	body := map[string]any{"mode": req.Mode}
+	if len(req.IDs) > 0 {
+		body["ids"] = req.IDs
+	}
+	if req.Filter != nil {
+		body["filter"] = req.Filter.body()
+	}
+	return c.bulk(ctx, http.MethodDelete, body)
+}
+
+// bulk выполняет массовую операцию. Массовые операции не повторяются автоматически
+func (c *Client) bulk(ctx context.Context, method string, body map[string]any) (*BulkResult, error) {
+	if mode, ok := body["mode"].(BulkMode); ok && mode == "" {
+		delete(body, "mode")
+	}
+
+	var result BulkResult
+	err := c.do(ctx, request{
+		method: method,
+		path:   apiPrefix + "/subscriptions/bulk",
+		body:   body,
+	}, &result, http.StatusUnprocessableEntity)
+	if err != nil {
+		if result.Results != nil {
+			return &result, err
+		}
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,294 @@
+// Package client реализует типизированный клиент REST API сервиса подписок.
+//
+// Клиент поддерживает контекст, повторяет запросы при ошибках сети и ответах 5xx
+// с экспоненциальной задержкой и возвращает ошибки сервера как *APIError
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// apiPrefix префикс маршрутов версии API, с которой работает клиент
+	apiPrefix = "/api/v1"
+
+	// maxErrorBodySize максимальный размер тела ответа с ошибкой, который читает клиент
+	maxErrorBodySize = 4 << 10
+
+	// Значения по умолчанию для повторных попыток
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// Client клиент API сервиса подписок. Безопасен для одновременного использования
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	header     http.Header
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option настраивает клиент
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP клиент, например с таймаутом или собственным транспортом
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries задает количество повторных попыток после первой; 0 отключает повторы
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff задает минимальную и максимальную задержку между повторными попытками
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithHeader добавляет заголовок ко всем запросам, например для авторизации
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// New создает клиент для сервиса по адресу baseURL, например http://localhost:8080
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("неверный адрес сервиса: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("неверный адрес сервиса %q: нужны схема и хост", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// request параметры запроса к API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+
+	// body тело запроса в JSON; для потоковых тел используется rawBody
+	body    any
+	rawBody io.Reader
+
+	// idempotent запрос можно безопасно повторить
+	idempotent bool
+}
+
+// do выполняет запрос с повторными попытками и декодирует JSON ответ в out, если out не nil.
+// Ответы с кодами из accept, кроме 2xx, также декодируются в out и возвращаются вместе с *APIError
+func (c *Client) do(ctx context.Context, req request, out any, accept ...int) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	apiErr := checkResponse(resp, accept)
+	if apiErr != nil && !isAccepted(resp.StatusCode, accept) {
+		return apiErr
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("не удалось разобрать ответ: %w", err)
+		}
+	}
+	if apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
+// send отправляет запрос и повторяет его при ошибках сети и ответах 5xx, если запрос идемпотентный.
+// Возвращает ответ, тело которого должен закрыть вызывающий код
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	// Тело JSON сериализуется один раз и отправляется заново при каждой попытке
+	var payload []byte
+	if req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("не удалось сериализовать запрос: %w", err)
+		}
+	}
+
+	// Потоковое тело нельзя отправить повторно
+	retries := c.maxRetries
+	if !req.idempotent || req.rawBody != nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := c.newRequest(ctx, req, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+
+		// Ошибка контекста не повторяется
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctxErr
+		}
+
+		if attempt >= retries {
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		// Освободить соединение перед следующей попыткой
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// newRequest создает HTTP запрос для одной попытки
+func (c *Client) newRequest(ctx context.Context, req request, payload []byte) (*http.Request, error) {
+	target := *c.baseURL
+	target.Path += req.path
+	if len(req.query) > 0 {
+		target.RawQuery = req.query.Encode()
+	}
+
+	var body io.Reader
+	switch {
+	case req.rawBody != nil:
+		body = req.rawBody
+	case payload != nil:
+		body = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		httpReq.Header[key] = append([]string(nil), values...)
+	}
+	for key, values := range req.header {
+		httpReq.Header[key] = append([]string(nil), values...)
+	}
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+
+	return httpReq, nil
+}
+
+// backoff возвращает задержку перед повторной попыткой: экспоненциальный рост со случайным разбросом
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.maxBackoff
+	if attempt < 32 {
+		delay = min(c.minBackoff<<attempt, c.maxBackoff)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// checkResponse возвращает *APIError для ответов с кодом не 2xx.
+// Тело ответа читается только для ошибок, которые не декодируются вызывающим кодом
+func checkResponse(resp *http.Response, accept []int) *APIError {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if !isAccepted(resp.StatusCode, accept) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		apiErr.Message = errorMessage(body)
+	}
+	return apiErr
+}
+
+// errorMessage извлекает текст ошибки из тела ответа: текст или JSON вида {"error": "..."}
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		return payload.Error
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// isAccepted сообщает, что код ответа входит в список кодов с телом в формате успешного ответа
+func isAccepted(statusCode int, accept []int) bool {
+	for _, code := range accept {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// newIdempotencyKey создает случайный ключ идемпотентности, чтобы запрос создания можно было повторить
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := cryptorand.Read(key); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,346 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/config"
+	"effective-mobile-subscription/internal/database"
+	"effective-mobile-subscription/internal/repository"
+	"effective-mobile-subscription/internal/routes"
+	"effective-mobile-subscription/internal/services"
+	"effective-mobile-subscription/pkg/client"
+	"effective-mobile-subscription/pkg/middleware"
+	"effective-mobile-subscription/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	userA = "11111111-1111-1111-1111-111111111111"
+	userB = "22222222-2222-2222-2222-222222222222"
+)
+
+// newRouter создает маршрутизатор сервиса, как cmd/server, над новой базой SQLite в памяти
+// без аутентификации и ограничения частоты запросов
+func newRouter(t *testing.T) http.Handler {
+	t.Helper()
+	cfg := &config.Config{
+		DBDriver:        database.DriverMemory,
+		IdempotencyTTL:  time.Hour,
+		TenantHeader:    "X-Tenant-ID",
+		AuthPublicPaths: []string{"/health", "/api/v1/health"},
+	}
+	db, err := database.Open(cfg, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("открытие базы данных: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := database.MigrateUp(db, 0); err != nil {
+		t.Fatalf("создание схемы: %v", err)
+	}
+
+	subscriptions := repository.NewSubscriptionRepository(db)
+	log := utils.NewLogger()
+	router := routes.SetupRoutes(db, cfg, log, routes.Services{
+		Subscriptions: services.NewSubscriptionService(subscriptions),
+		Webhooks:      services.NewWebhookService(repository.NewWebhookRepository(db), time.Second, 1),
+		Events:        services.NewEventStream(repository.NewEventRepository(db)),
+		APIKeys:       services.NewAPIKeyService(repository.NewAPIKeyRepository(db)),
+		Tenants:       services.NewTenantService(repository.NewTenantRepository(db)),
+		UserData:      services.NewUserDataService(subscriptions),
+		Idempotency:   services.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour),
+	})
+	router.Use(middleware.ErrorMiddleware(log))
+	return router
+}
+
+// newClient запускает сервис на handler и создает клиент с короткими задержками повторов
+func newClient(t *testing.T, handler http.Handler, opts ...client.Option) *client.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL, append([]client.Option{client.WithBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// failing отвечает 503 на первые failures запросов, подходящих под match. Если process задан,
+// запрос выполняется сервисом, а ответ теряется, как при обрыве соединения после выполнения
+func failing(next http.Handler, failures int32, process bool, match func(*http.Request) bool) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !match(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if calls.Add(1) > failures {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if process {
+			next.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		http.Error(w, "временно недоступен", http.StatusServiceUnavailable)
+	}), &calls
+}
+
+func TestSubscriptionCRUD(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newRouter(t))
+
+	created, err := c.CreateSubscription(ctx, &client.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       990,
+		UserID:      userA,
+		StartDate:   "01-2025",
+		Tags:        []string{"video"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if created.ID == 0 || created.ServiceName != "Netflix" || created.Price != 990 || created.UserID != userA ||
+		!created.StartDate.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("CreateSubscription вернул %+v", created)
+	}
+
+	got, err := c.GetSubscription(ctx, created.ID)
+	if err != nil || got.ID != created.ID || len(got.Tags) != 1 {
+		t.Fatalf("GetSubscription вернул %+v, %v", got, err)
+	}
+
+	updated, err := c.UpdateSubscription(ctx, created.ID, &client.UpdateSubscriptionRequest{Price: 1290, EndDate: "12-2025"})
+	if err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	if updated.Price != 1290 || updated.ServiceName != "Netflix" || updated.EndDate == nil || updated.EndDate.Month() != time.December {
+		t.Fatalf("UpdateSubscription вернул %+v", updated)
+	}
+
+	if err := c.DeleteSubscription(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if _, err := c.GetSubscription(ctx, created.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetSubscription удаленной подписки: ожидалась ErrNotFound, получено %v", err)
+	}
+	if err := c.DeleteSubscription(ctx, created.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("повторный DeleteSubscription: ожидалась ErrNotFound, получено %v", err)
+	}
+}
+
+func TestPages(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newRouter(t))
+
+	const count = 23
+	for i := range count {
+		userID := userA
+		if i%2 == 1 {
+			userID = userB
+		}
+		_, err := c.CreateSubscription(ctx, &client.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 100 + i, UserID: userID, StartDate: "01-2025"})
+		if err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+
+	// Перебор страниц с сортировкой возвращает каждую подписку один раз и по порядку
+	var pages, prices []int
+	seen := make(map[uint]bool)
+	for page, err := range c.Pages(ctx, &client.ListOptions{Sort: "-price", Limit: 10, IncludeTotal: true}) {
+		if err != nil {
+			t.Fatalf("Pages: %v", err)
+		}
+		if page.Pagination.Total == nil || *page.Pagination.Total != count {
+			t.Fatalf("Pages вернул общее количество %v", page.Pagination.Total)
+		}
+		pages = append(pages, len(page.Subscriptions))
+		for _, s := range page.Subscriptions {
+			if seen[s.ID] {
+				t.Fatalf("подписка %d получена дважды", s.ID)
+			}
+			seen[s.ID] = true
+			prices = append(prices, s.Price)
+		}
+	}
+	if len(pages) != 3 || pages[0] != 10 || pages[2] != 3 || len(prices) != count {
+		t.Fatalf("Pages вернул страницы размером %v", pages)
+	}
+	for i := 1; i < len(prices); i++ {
+		if prices[i] > prices[i-1] {
+			t.Fatalf("подписки не отсортированы по убыванию цены: %v", prices)
+		}
+	}
+
+	// All применяет фильтр и останавливается по требованию вызывающего кода
+	var users int
+	for s, err := range c.All(ctx, &client.ListOptions{Filter: client.Filter{UserIDs: []string{userB}}, Limit: 5}) {
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		if s.UserID != userB {
+			t.Fatalf("All вернул подписку другого пользователя %+v", s)
+		}
+		users++
+	}
+	if users != count/2 {
+		t.Fatalf("All вернул %d подписок, ожидалось %d", users, count/2)
+	}
+	taken := 0
+	for range c.All(ctx, &client.ListOptions{Limit: 5}) {
+		if taken++; taken == 7 {
+			break
+		}
+	}
+	if taken != 7 {
+		t.Fatalf("All после остановки перебора вернул %d подписок", taken)
+	}
+}
+
+func TestCalculateTotalCost(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newRouter(t))
+
+	for _, req := range []client.CreateSubscriptionRequest{
+		{ServiceName: "Netflix", Price: 990, UserID: userA, StartDate: "01-2025"},
+		{ServiceName: "Spotify", Price: 299, UserID: userA, StartDate: "03-2025"},
+		{ServiceName: "Netflix", Price: 500, UserID: userB, StartDate: "06-2025"},
+	} {
+		if _, err := c.CreateSubscription(ctx, &req); err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+
+	total, err := c.CalculateTotalCost(ctx, nil)
+	if err != nil || total != 1789 {
+		t.Fatalf("CalculateTotalCost вернул %d, %v, ожидалось 1789", total, err)
+	}
+	total, err = c.CalculateTotalCost(ctx, &client.CostOptions{Filter: client.Filter{ServiceNames: []string{"Netflix"}}})
+	if err != nil || total != 1490 {
+		t.Fatalf("CalculateTotalCost по сервису вернул %d, %v, ожидалось 1490", total, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newRouter(t))
+
+	_, err := c.CreateSubscription(ctx, &client.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 990, UserID: "не uuid", StartDate: "01-2025"})
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrBadRequest) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message == "" {
+		t.Fatalf("CreateSubscription с неверным user_id: %v", err)
+	}
+	if errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrServer) {
+		t.Fatalf("ошибка 400 совпала с другими ошибками пакета: %v", err)
+	}
+
+	if _, err := c.GetSubscription(ctx, 12345); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetSubscription отсутствующей подписки: %v", err)
+	}
+	if _, err := c.CreateSubscription(ctx, nil); err == nil {
+		t.Fatal("CreateSubscription без запроса не вернул ошибку")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	isGet := func(r *http.Request) bool { return r.Method == http.MethodGet }
+
+	// Идемпотентный запрос повторяется после ответов 5xx
+	handler, calls := failing(newRouter(t), 2, false, isGet)
+	c := newClient(t, handler)
+	if _, err := c.ListSubscriptions(ctx, nil); err != nil {
+		t.Fatalf("ListSubscriptions после двух ошибок сервера: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("выполнено %d попыток, ожидалось 3", calls.Load())
+	}
+
+	// После исчерпания повторов возвращается ErrServer с кодом ответа
+	handler, calls = failing(newRouter(t), 10, false, isGet)
+	c = newClient(t, handler, client.WithRetries(2))
+	var apiErr *client.APIError
+	if _, err := c.ListSubscriptions(ctx, nil); !errors.Is(err, client.ErrServer) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("ListSubscriptions после исчерпания повторов: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("выполнено %d попыток, ожидалось 3", calls.Load())
+	}
+
+	// Повтор создания, ответ на которое потерян, возвращает сохраненный ответ по ключу идемпотентности
+	isCreate := func(r *http.Request) bool {
+		return r.Method == http.MethodPost && r.URL.Path == "/api/v1/subscriptions"
+	}
+	handler, calls = failing(newRouter(t), 1, true, isCreate)
+	c = newClient(t, handler)
+	created, err := c.CreateSubscription(ctx, &client.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 990, UserID: userA, StartDate: "01-2025"})
+	if err != nil {
+		t.Fatalf("CreateSubscription после потерянного ответа: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("выполнено %d попыток, ожидалось 2", calls.Load())
+	}
+	page, err := c.ListSubscriptions(ctx, &client.ListOptions{IncludeTotal: true})
+	if err != nil || len(page.Subscriptions) != 1 || page.Subscriptions[0].ID != created.ID {
+		t.Fatalf("повтор создания создал дубликат: %+v, %v", page, err)
+	}
+
+	// Отмена контекста прерывает ожидание повтора
+	handler, _ = failing(newRouter(t), 10, false, isGet)
+	c = newClient(t, handler, client.WithBackoff(time.Second, time.Second))
+	cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ListSubscriptions(cancelCtx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ListSubscriptions с отмененным контекстом: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("ожидание повтора не прервано отменой контекста: %v", elapsed)
+	}
+}
+
+// Удаление по фильтру ограничено 10000 подписками за запрос
+func TestBulkDeleteByFilterLimit(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newRouter(t))
+
+	items := make([]client.CreateSubscriptionRequest, services.MaxBulkItems)
+	for i := range items {
+		items[i] = client.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 990, UserID: userA, StartDate: "01-2025"}
+	}
+	for _, batch := range [][]client.CreateSubscriptionRequest{items, items[:1]} {
+		if _, err := c.BulkCreateSubscriptions(ctx, client.BulkModeAtomic, batch); err != nil {
+			t.Fatalf("BulkCreateSubscriptions: %v", err)
+		}
+	}
+	filter := &client.Filter{UserIDs: []string{userA}}
+
+	// В режиме atomic фильтр, под который подходит больше подписок, отклоняется целиком
+	_, err := c.BulkDeleteSubscriptions(ctx, &client.BulkDeleteRequest{Mode: client.BulkModeAtomic, Filter: filter})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("atomic: %v, ожидалась ErrBadRequest", err)
+	}
+
+	// В режиме best_effort подписки удаляются частями
+	result, err := c.BulkDeleteSubscriptions(ctx, &client.BulkDeleteRequest{Mode: client.BulkModeBestEffort, Filter: filter})
+	if err != nil || result.Succeeded != services.MaxBulkItems || result.Remaining != 1 {
+		t.Fatalf("best_effort: %+v, %v", result, err)
+	}
+	result, err = c.BulkDeleteSubscriptions(ctx, &client.BulkDeleteRequest{Mode: client.BulkModeAtomic, Filter: filter})
+	if err != nil || result.Succeeded != 1 || result.Remaining != 0 {
+		t.Fatalf("остаток: %+v, %v", result, err)
+	}
+}
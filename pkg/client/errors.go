@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrBadRequest возвращается, если сервер отклонил параметры или тело запроса (400)
+	ErrBadRequest = errors.New("неверный запрос")
+
+	// ErrNotFound возвращается, если ресурс не найден (404)
+	ErrNotFound = errors.New("не найдено")
+
+	// ErrConflict возвращается, если запрос конфликтует с текущим состоянием, например
+	// запрос с тем же ключом идемпотентности еще выполняется (409)
+	ErrConflict = errors.New("конфликт")
+
+	// ErrUnprocessable возвращается, если запрос не может быть выполнен, например
+	// атомарная массовая операция откатилась или ключ идемпотентности использован с другим телом (422)
+	ErrUnprocessable = errors.New("запрос не может быть выполнен")
+
+	// ErrServer возвращается при ошибке сервера (5xx) после исчерпания повторных попыток
+	ErrServer = errors.New("ошибка сервера")
+
+	// errNilRequest возвращается, если обязательный запрос не передан
+	errNilRequest = errors.New("запрос не задан")
+)
+
+// APIError ошибка, которую вернул сервер. Сравнивается с ErrBadRequest, ErrNotFound и другими
+// ошибками пакета через errors.Is по коду ответа
+type APIError struct {
+	// StatusCode HTTP код ответа
+	StatusCode int
+
+	// Message текст ошибки из тела ответа
+	Message string
+}
+
+// Error возвращает текст ошибки
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is сопоставляет код ответа с ошибками пакета
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
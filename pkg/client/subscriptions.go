@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CreateSubscription создает подписку. Запрос отправляется с ключом идемпотентности,
+// поэтому повторные попытки не создают дубликатов
+func (c *Client) CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*Subscription, error) {
+	if req == nil {
+		return nil, errNilRequest
+	}
+
+	header := make(http.Header)
+	idempotent := false
+	if key := newIdempotencyKey(); key != "" {
+		header.Set("Idempotency-Key", key)
+		idempotent = true
+	}
+
+	var subscription Subscription
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       apiPrefix + "/subscriptions",
+		header:     header,
+		body:       req,
+		idempotent: idempotent,
+	}, &subscription)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// GetSubscription получает подписку по ID. Возвращает ошибку ErrNotFound, если подписки нет
+func (c *Client) GetSubscription(ctx context.Context, id uint) (*Subscription, error) {
+	var subscription Subscription
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       subscriptionPath(id),
+		idempotent: true,
+	}, &subscription)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// UpdateSubscription обновляет заполненные поля подписки и возвращает ее новое состояние
+func (c *Client) UpdateSubscription(ctx context.Context, id uint, req *UpdateSubscriptionRequest) (*Subscription, error) {
+	if req == nil {
+		return nil, errNilRequest
+	}
+
+	var subscription Subscription
+	err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       subscriptionPath(id),
+		body:       req,
+		idempotent: true,
+	}, &subscription)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// DeleteSubscription удаляет подписку по ID
+func (c *Client) DeleteSubscription(ctx context.Context, id uint) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       subscriptionPath(id),
+		idempotent: true,
+	}, nil)
+}
+
+// ListSubscriptions получает одну страницу подписок
+func (c *Client) ListSubscriptions(ctx context.Context, opts *ListOptions) (*ListPage, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	query := url.Values{}
+	opts.Filter.apply(query)
+	setString(query, "sort", opts.Sort)
+	setString(query, "cursor", opts.Cursor)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	query.Set("include_total", strconv.FormatBool(opts.IncludeTotal))
+
+	var page ListPage
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       apiPrefix + "/subscriptions",
+		query:      query,
+		idempotent: true,
+	}, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Pages перебирает страницы подписок, начиная с opts.Cursor. Перебор останавливается
+// после последней страницы или первой ошибки
+func (c *Client) Pages(ctx context.Context, opts *ListOptions) iter.Seq2[*ListPage, error] {
+	pageOpts := ListOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+
+	return func(yield func(*ListPage, error) bool) {
+		for {
+			page, err := c.ListSubscriptions(ctx, &pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) || page.Pagination.NextCursor == "" {
+				return
+			}
+			pageOpts.Cursor = page.Pagination.NextCursor
+		}
+	}
+}
+
+// All перебирает все подписки, подходящие под параметры, загружая их постранично
+func (c *Client) All(ctx context.Context, opts *ListOptions) iter.Seq2[Subscription, error] {
+	return func(yield func(Subscription, error) bool) {
+		for page, err := range c.Pages(ctx, opts) {
+			if err != nil {
+				yield(Subscription{}, err)
+				return
+			}
+			for _, subscription := range page.Subscriptions {
+				if !yield(subscription, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CalculateTotalCost вычисляет общую стоимость подписок, подходящих под фильтр, за период
+func (c *Client) CalculateTotalCost(ctx context.Context, opts *CostOptions) (int, error) {
+	if opts == nil {
+		opts = &CostOptions{}
+	}
+
+	query := url.Values{}
+	opts.Filter.apply(query)
+	setString(query, "from", opts.From)
+	setString(query, "to", opts.To)
+
+	var response struct {
+		TotalCost int `json:"total_cost"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       apiPrefix + "/subscriptions/cost",
+		query:      query,
+		idempotent: true,
+	}, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.TotalCost, nil
+}
+
+// SearchSubscriptions ищет подписки по названию сервиса, заметкам и тегам с допуском опечаток
+func (c *Client) SearchSubscriptions(ctx context.Context, q string, opts *SearchOptions) ([]SearchHit, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	query := url.Values{"q": {q}}
+	setList(query, "user_id", opts.UserIDs)
+	setList(query, "service_name", opts.ServiceNames)
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var response struct {
+		Data []SearchHit `json:"data"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       apiPrefix + "/subscriptions/search",
+		query:      query,
+		idempotent: true,
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// Health проверяет, что сервис доступен
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       apiPrefix + "/health",
+		idempotent: true,
+	}, nil)
+}
+
+// subscriptionPath возвращает путь подписки по ID
+func subscriptionPath(id uint) string {
+	return apiPrefix + "/subscriptions/" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Форматы импорта и выгрузки
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// ImportOptions параметры импорта подписок
+type ImportOptions struct {
+	// Format формат файла: FormatCSV (по умолчанию) или FormatNDJSON
+	Format string
+
+	// Delimiter разделитель CSV; по умолчанию запятая
+	Delimiter string
+
+	// DryRun только проверяет строки без сохранения
+	DryRun bool
+}
+
+// ImportRowError ошибка строки импорта
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport отчет об импорте
+type ImportReport struct {
+	DryRun  bool `json:"dry_run"`
+	Summary struct {
+		TotalRows int `json:"total_rows"`
+		Valid     int `json:"valid"`
+		Imported  int `json:"imported"`
+		Failed    int `json:"failed"`
+	} `json:"summary"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+}
+
+// ImportSubscriptions импортирует подписки из потока CSV или JSON Lines.
+// Поток отправляется как есть, поэтому запрос не повторяется автоматически
+func (c *Client) ImportSubscriptions(ctx context.Context, r io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	query := url.Values{}
+	setString(query, "format", opts.Format)
+	setString(query, "delimiter", opts.Delimiter)
+	if opts.DryRun {
+		query.Set("dry_run", strconv.FormatBool(opts.DryRun))
+	}
+
+	contentType := "text/csv"
+	if opts.Format == FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	var report ImportReport
+	err := c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    apiPrefix + "/subscriptions/import",
+		query:   query,
+		header:  http.Header{"Content-Type": {contentType}},
+		rawBody: r,
+	}, &report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportOptions параметры выгрузки подписок
+type ExportOptions struct {
+	// Format формат файла: FormatCSV (по умолчанию), FormatNDJSON или FormatXLSX (не больше 100000 строк)
+	Format string
+	Filter Filter
+	Sort   string
+
+	// From и To период в формате ММ-ГГГГ для расчета колонки cost
+	From string
+	To   string
+}
+
+// ExportSubscriptions выгружает подписки. Возвращает поток с файлом, который нужно закрыть после чтения
+func (c *Client) ExportSubscriptions(ctx context.Context, opts *ExportOptions) (io.ReadCloser, error) {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	query := url.Values{}
+	opts.Filter.apply(query)
+	setString(query, "format", opts.Format)
+	setString(query, "sort", opts.Sort)
+	setString(query, "from", opts.From)
+	setString(query, "to", opts.To)
+
+	resp, err := c.send(ctx, request{
+		method:     http.MethodGet,
+		path:       apiPrefix + "/subscriptions/export",
+		query:      query,
+		header:     http.Header{"Accept": {"*/*"}},
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if apiErr := checkResponse(resp, nil); apiErr != nil {
+		resp.Body.Close()
+		return nil, apiErr
+	}
+	return resp.Body, nil
+}
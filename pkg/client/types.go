@@ -0,0 +1,174 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subscription подписка пользователя на сервис
+type Subscription struct {
+	ID          uint       `json:"id"`
+	ServiceName string     `json:"service_name"`
+	Price       int        `json:"price"`
+	UserID      string     `json:"user_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CreateSubscriptionRequest запрос на создание подписки. Даты в формате ММ-ГГГГ, см. MonthYear
+type CreateSubscriptionRequest struct {
+	ServiceName string   `json:"service_name"`
+	Price       int      `json:"price"`
+	UserID      string   `json:"user_id"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// UpdateSubscriptionRequest запрос на обновление подписки; пустые поля не изменяются
+type UpdateSubscriptionRequest struct {
+	ServiceName string   `json:"service_name,omitempty"`
+	Price       int      `json:"price,omitempty"`
+	UserID      string   `json:"user_id,omitempty"`
+	StartDate   string   `json:"start_date,omitempty"`
+	EndDate     string   `json:"end_date,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// MonthYear форматирует дату в формате ММ-ГГГГ, который API принимает для дат подписки
+func MonthYear(t time.Time) string {
+	return t.Format("01-2006")
+}
+
+// Filter фильтр подписок. Пустые поля не применяются; даты в формате ММ-ГГГГ или ГГГГ-ММ-ДД
+type Filter struct {
+	UserIDs             []string
+	ServiceNames        []string
+	ServiceNameContains string
+	PriceMin            *int
+	PriceMax            *int
+	StartFrom           string
+	StartTo             string
+	EndFrom             string
+	EndTo               string
+
+	// ActiveAt месяц, в котором подписка активна хотя бы один день
+	ActiveAt string
+
+	// OpenEnded true - только бессрочные подписки, false - только с датой окончания
+	OpenEnded *bool
+}
+
+// apply добавляет условия фильтра в параметры запроса
+func (f *Filter) apply(query url.Values) {
+	setList(query, "user_id", f.UserIDs)
+	setList(query, "service_name", f.ServiceNames)
+	setString(query, "service_name_contains", f.ServiceNameContains)
+	if f.PriceMin != nil {
+		query.Set("price_min", strconv.Itoa(*f.PriceMin))
+	}
+	if f.PriceMax != nil {
+		query.Set("price_max", strconv.Itoa(*f.PriceMax))
+	}
+	setString(query, "start_from", f.StartFrom)
+	setString(query, "start_to", f.StartTo)
+	setString(query, "end_from", f.EndFrom)
+	setString(query, "end_to", f.EndTo)
+	setString(query, "active_at", f.ActiveAt)
+	if f.OpenEnded != nil {
+		query.Set("open_ended", strconv.FormatBool(*f.OpenEnded))
+	}
+}
+
+// body возвращает фильтр в формате тела запроса массового удаления
+func (f *Filter) body() map[string]any {
+	query := url.Values{}
+	f.apply(query)
+
+	body := make(map[string]any, len(query))
+	for key := range query {
+		body[key] = query.Get(key)
+	}
+	return body
+}
+
+// ListOptions параметры получения списка подписок
+type ListOptions struct {
+	Filter Filter
+
+	// Sort сортировка по разрешенным полям, например "price,-start_date"
+	Sort string
+
+	// Limit размер страницы; по умолчанию 10
+	Limit int
+
+	// Cursor курсор страницы из ListPage.NextCursor; пустой - первая страница
+	Cursor string
+
+	// IncludeTotal запрашивает общее количество подписок; подсчет замедляет запрос
+	IncludeTotal bool
+}
+
+// Pagination сведения о странице списка
+type Pagination struct {
+	Limit int `json:"limit"`
+
+	// Total и Pages заданы, только если запрошено общее количество
+	Total *int64 `json:"total,omitempty"`
+	Pages *int   `json:"pages,omitempty"`
+
+	// NextCursor пуст на последней странице
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListPage страница списка подписок
+type ListPage struct {
+	Subscriptions []Subscription `json:"data"`
+	Pagination    Pagination     `json:"pagination"`
+}
+
+// CostOptions параметры расчета общей стоимости
+type CostOptions struct {
+	Filter Filter
+
+	// From и To границы периода в формате ММ-ГГГГ
+	From string
+	To   string
+}
+
+// SearchHit найденная подписка с релевантностью
+type SearchHit struct {
+	Subscription
+	Rank float64 `json:"rank"`
+}
+
+// SearchOptions параметры поиска подписок
+type SearchOptions struct {
+	UserIDs      []string
+	ServiceNames []string
+
+	// Limit максимальное количество результатов; по умолчанию 20
+	Limit int
+}
+
+// setString добавляет непустой параметр запроса
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// setList добавляет список значений через запятую
+func setList(query url.Values, key string, values []string) {
+	if len(values) > 0 {
+		query.Set(key, strings.Join(values, ","))
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DeprecationMiddleware помечает ответы устаревших маршрутов заголовками Deprecation, Sunset и Link.
+// successorPrefix - префикс пути версии API, которая заменяет устаревшие маршруты, например /api/v1
+func DeprecationMiddleware(deprecatedAt, sunset time.Time, successorPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Даты в формате RFC 9745 и RFC 8594
+			if deprecatedAt.IsZero() {
+				w.Header().Set("Deprecation", "true")
+			} else {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+			}
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			// Указать маршрут, на который следует перейти
+			w.Header().Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successorPrefix, r.URL.Path))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
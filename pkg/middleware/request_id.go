@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader заголовок с ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength максимальная длина ID запроса, переданного клиентом
+const maxRequestIDLength = 128
+
+// requestIDKey ключ ID запроса в контексте
+type requestIDKey struct{}
+
+// RequestIDMiddleware помещает в контекст ID запроса из заголовка X-Request-ID или создает новый
+// и возвращает его в одноименном заголовке ответа, чтобы запрос можно было найти в журналах и аудите
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = NewRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+		})
+	}
+}
+
+// NewRequestID создает случайный ID запроса
+func NewRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// WithRequestID возвращает контекст с ID запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom возвращает ID запроса из контекста; пусто, если его нет
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -32,3 +32,47 @@ func GetLastDayOfMonth(t time.Time) time.Time {
 	// Вычесть один день, чтобы получить последний день текущего месяца
 	return nextMonth.AddDate(0, 0, -1)
 }
+
+// MonthsInRange возвращает количество месяцев подписки с датами start и end, попадающих в окно from-to.
+// Пустая дата окончания подписки означает, что подписка действует по сей день.
+// Пустые from и to означают, что окно не ограничено с соответствующей стороны
+func MonthsInRange(start time.Time, end, from, to *time.Time) int {
+	// Начало периода - более поздняя из дат start и from
+	first := GetFirstDayOfMonth(start)
+	if from != nil && from.After(first) {
+		first = GetFirstDayOfMonth(*from)
+	}
+
+	// Конец периода - более ранняя из дат end и to; для бессрочной подписки без to - текущий месяц
+	last := time.Now()
+	if end != nil {
+		last = *end
+	} else if to != nil {
+		last = *to
+	}
+	if to != nil && to.Before(last) {
+		last = *to
+	}
+
+	months := (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// ParseDate разбирает дату в формате ММ-ГГГГ, ГГГГ-ММ, ГГГГ-ММ-ДД или RFC 3339.
+// wholeMonth равен true, если дата задана с точностью до месяца
+func ParseDate(dateStr string) (t time.Time, wholeMonth bool, err error) {
+	for _, layout := range []string{"01-2006", "2006-01"} {
+		if t, err := time.Parse(layout, dateStr); err == nil {
+			return t, true, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, dateStr); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("недопустимый формат даты %q, ожидается ММ-ГГГГ или ГГГГ-ММ-ДД", dateStr)
+}
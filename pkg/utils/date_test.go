@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"effective-mobile-subscription/pkg/utils"
+)
+
+// month возвращает указатель на первое число месяца
+func month(year int, m time.Month) *time.Time {
+	t := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestMonthsInRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      *time.Time
+		from, to *time.Time
+		want     int
+	}{
+		{"без окна", *month(2025, time.January), month(2025, time.March), nil, nil, 3},
+		{"один месяц", *month(2025, time.January), month(2025, time.January), nil, nil, 1},
+		{"через границу года", *month(2024, time.November), month(2025, time.February), nil, nil, 4},
+		{"даты внутри месяца", time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+			month(2025, time.February), nil, nil, 2},
+		{"окно внутри подписки", *month(2025, time.January), month(2025, time.December),
+			month(2025, time.March), month(2025, time.May), 3},
+		{"окно шире подписки", *month(2025, time.March), month(2025, time.May),
+			month(2025, time.January), month(2025, time.December), 3},
+		{"бессрочная подписка с to", *month(2025, time.January), nil, nil, month(2025, time.June), 6},
+		{"окно после окончания", *month(2025, time.January), month(2025, time.March), month(2025, time.May), nil, 0},
+		{"окно до начала", *month(2025, time.March), nil, nil, month(2025, time.January), 0},
+		{"окно до начала на месяц", *month(2025, time.March), nil, nil, month(2025, time.February), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.MonthsInRange(tt.start, tt.end, tt.from, tt.to); got != tt.want {
+				t.Fatalf("MonthsInRange = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+
+	// Бессрочная подписка без to действует по текущий месяц включительно
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month()-2, 15, 0, 0, 0, 0, now.Location())
+	if got := utils.MonthsInRange(start, nil, nil, nil); got != 3 {
+		t.Fatalf("бессрочная подписка, начатая два месяца назад: %d, ожидалось 3", got)
+	}
+}